@@ -0,0 +1,129 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runAWSAudit(args []string) int {
+	fs := flag.NewFlagSet("aws-audit", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck aws-audit [--profile name] [--region region] [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Reads the Secrets Manager secrets and SSM SecureString parameters of an AWS\n")
+		fmt.Fprintf(os.Stderr, "account and checks the values that look like passwords against HIBP. Findings\n")
+		fmt.Fprintf(os.Stderr, "are named by ARN, with the account and region as tags; values are never printed.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --profile <name>     Shared config profile (default $AWS_PROFILE or \"default\")\n")
+		fmt.Fprintf(os.Stderr, "      --region <region>    Region to audit (default $AWS_REGION or the profile's region)\n")
+		fmt.Fprintf(os.Stderr, "      --service <name>     Only audit secretsmanager or ssm (repeatable; default both)\n")
+		fmt.Fprintf(os.Stderr, "      --path <path>        Only audit SSM parameters under this hierarchy (default \"/\")\n")
+		fmt.Fprintf(os.Stderr, "      --keys <regexp>      Keys checked in JSON secrets (default %q)\n", checker.DefaultSecretKeys)
+		fmt.Fprintf(os.Stderr, "      --format <string>    Per-result output format: text, table, csv, json, markdown, sarif or junit (default \"text\")\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Write machine-readable results to a file; format from -format or the extension\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Treat passwords seen fewer than n times in breaches as acceptable\n")
+		fmt.Fprintf(os.Stderr, "      --fail-threshold <n> Exit 0 unless more than n compromised passwords are found (default 0)\n")
+		fmt.Fprintf(os.Stderr, "  -s, --stats              Show runtime and result summary after completion\n")
+		fmt.Fprintf(os.Stderr, "      --group-by <dim>     Also break the summary down by account or username\n")
+		fmt.Fprintf(os.Stderr, "  -q, --quiet              Suppress per-secret output; only the -stats summary and the exit code remain\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum HIBP requests per second, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP and AWS request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable ANSI colors\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each HIBP request to stderr\n")
+	}
+
+	var (
+		profile    string
+		region     string
+		services   stringList
+		path       string
+		keys       string
+		format     string
+		outputFile string
+		minCount   int
+		failThresh int
+		showStats  bool
+		groupBy    string
+		quiet      bool
+		rps        float64
+		timeout    time.Duration
+		noColor    bool
+		verbose    bool
+	)
+	fs.StringVar(&profile, "profile", "", "")
+	fs.StringVar(&region, "region", "", "")
+	fs.Var(&services, "service", "")
+	fs.StringVar(&path, "path", "/", "")
+	fs.StringVar(&keys, "keys", checker.DefaultSecretKeys, "")
+	fs.StringVar(&format, "format", "text", "")
+	fs.StringVar(&outputFile, "o", "", "")
+	fs.StringVar(&outputFile, "output", "", "")
+	fs.IntVar(&minCount, "min-count", 0, "")
+	fs.IntVar(&failThresh, "fail-threshold", 0, "")
+	fs.BoolVar(&showStats, "s", false, "")
+	fs.BoolVar(&showStats, "stats", false, "")
+	fs.StringVar(&groupBy, "group-by", "", "")
+	fs.BoolVar(&quiet, "q", false, "")
+	fs.BoolVar(&quiet, "quiet", false, "")
+	fs.Float64Var(&rps, "rps", 10, "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	if len(services) == 0 {
+		services = checker.AWSServices
+	}
+	for _, s := range services {
+		if !slices.Contains(checker.AWSServices, s) {
+			fmt.Fprintf(os.Stderr, "unknown --service %q (available: %s)\n", s, strings.Join(checker.AWSServices, ", "))
+			return 2
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		fmt.Fprintf(os.Stderr, "--path must start with /\n")
+		return 2
+	}
+	if minCount < 0 || failThresh < 0 {
+		fmt.Fprintf(os.Stderr, "--fail-threshold and --min-count must not be negative\n")
+		return 2
+	}
+	if rps < 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--timeout must be positive and --rps must not be negative\n")
+		return 2
+	}
+
+	return checker.Run(checker.Config{
+		AWS:           true,
+		AWSProfile:    profile,
+		AWSRegion:     region,
+		AWSServices:   services,
+		AWSPath:       path,
+		SecretKeys:    keys,
+		HidePassword:  true,
+		Format:        format,
+		OutputFile:    outputFile,
+		MinCount:      minCount,
+		FailThreshold: failThresh,
+		ShowStats:     showStats,
+		GroupBy:       groupBy,
+		Quiet:         quiet,
+		RPS:           rps,
+		Timeout:       timeout,
+		NoColor:       noColor,
+		Verbosity:     verbosity(verbose, false),
+	})
+}
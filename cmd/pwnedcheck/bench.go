@@ -0,0 +1,89 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck bench [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Measures SHA-1 and NTLM hashing, HIBP round-trip latency and throughput at\n")
+		fmt.Fprintf(os.Stderr, "doubling worker counts, cache hits, and --bloom and --index lookups on this\n")
+		fmt.Fprintf(os.Stderr, "machine, then suggests --rps and download --workers values.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --requests <n>       Range requests per measurement, 0 stays offline (default 20)\n")
+		fmt.Fprintf(os.Stderr, "      --workers <n>        Highest worker count tried (default 32, as for download)\n")
+		fmt.Fprintf(os.Stderr, "      --bloom <file>       Also measure lookups in this filter from build-bloom\n")
+		fmt.Fprintf(os.Stderr, "      --index <file>       Also measure lookups in this index from the index subcommand\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --pin-sha256 <hash>  Require HIBP to present this base64 SHA-256 public key (SPKI) hash (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --ca-cert <file>     Also trust the CA certificates in this PEM file\n")
+		fmt.Fprintf(os.Stderr, "      --client-cert <file> PEM client certificate for mutual TLS (needs --client-key)\n")
+		fmt.Fprintf(os.Stderr, "      --client-key <file>  PEM private key for --client-cert\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each request to stderr\n")
+	}
+
+	var (
+		requests   int
+		workers    int
+		bloomFile  string
+		indexFile  string
+		timeout    time.Duration
+		rawPins    stringList
+		caCert     string
+		clientCert string
+		clientKey  string
+		verbose    bool
+	)
+	fs.IntVar(&requests, "requests", 20, "")
+	fs.IntVar(&workers, "workers", 32, "")
+	fs.StringVar(&bloomFile, "bloom", "", "")
+	fs.StringVar(&indexFile, "index", "", "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.Var(&rawPins, "pin-sha256", "")
+	fs.StringVar(&caCert, "ca-cert", "", "")
+	fs.StringVar(&clientCert, "client-cert", "", "")
+	fs.StringVar(&clientKey, "client-key", "", "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	if requests < 0 || workers <= 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--workers and --timeout must be positive and --requests must not be negative\n")
+		return 2
+	}
+	pins, err := parsePins(rawPins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	tlsConfig, err := loadTLSConfig(caCert, clientCert, clientKey, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	return checker.Bench(checker.Config{
+		BenchRequests: requests,
+		Workers:       workers,
+		BloomFile:     bloomFile,
+		IndexFile:     indexFile,
+		Timeout:       timeout,
+		Pins:          pins,
+		TLS:           tlsConfig,
+		Verbosity:     verbosity(verbose, false),
+	})
+}
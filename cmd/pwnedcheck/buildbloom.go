@@ -0,0 +1,69 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runBuildBloom(args []string) int {
+	fs := flag.NewFlagSet("build-bloom", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck build-bloom -i pwnedpasswords.txt -o hibp.bloom [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Builds a Bloom filter from the downloaded SHA-1 corpus (HASH:COUNT lines) for\n")
+		fmt.Fprintf(os.Stderr, "offline checks with --bloom.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  -i, --input <file>       Downloaded corpus, optionally compressed (gzip, bzip2, zstd, zip), or an http(s):// URL\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Filter file to write (default \"hibp.bloom\")\n")
+		fmt.Fprintf(os.Stderr, "      --fp <rate>          False-positive rate; each tenfold drop costs about 4.8 bits per hash (default 0.001)\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Leave out hashes seen fewer than n times, shrinking the filter\n")
+		fmt.Fprintf(os.Stderr, "  -n, --entries <n>        Size the filter for n hashes instead of reading the input twice to count them\n")
+	}
+
+	var (
+		inputFile  string
+		outputFile string
+		fpRate     float64
+		minCount   int
+		entries    int
+	)
+	fs.StringVar(&inputFile, "i", "", "")
+	fs.StringVar(&inputFile, "input", "", "")
+	fs.StringVar(&outputFile, "o", "hibp.bloom", "")
+	fs.StringVar(&outputFile, "output", "hibp.bloom", "")
+	fs.Float64Var(&fpRate, "fp", 0.001, "")
+	fs.IntVar(&minCount, "min-count", 0, "")
+	fs.IntVar(&entries, "n", 0, "")
+	fs.IntVar(&entries, "entries", 0, "")
+	fs.Parse(args)
+
+	if inputFile == "" {
+		fmt.Fprintf(os.Stderr, "--input is required\n")
+		return 2
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fmt.Fprintf(os.Stderr, "--fp must be between 0 and 1\n")
+		return 2
+	}
+	if minCount < 0 || entries < 0 {
+		fmt.Fprintf(os.Stderr, "--min-count and --entries must not be negative\n")
+		return 2
+	}
+
+	return checker.BuildBloom(checker.Config{
+		InputFile:    inputFile,
+		BloomFile:    outputFile,
+		BloomFP:      fpRate,
+		BloomEntries: entries,
+		MinCount:     minCount,
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completionShells lists the shells completion can write a script for.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCommand is a subcommand and its flags, spelled as the usage
+// shows them; the top-level options have no name. Keep these in step with
+// the flag sets.
+type completionCommand struct {
+	name  string
+	flags []string
+}
+
+var completionCommands = []completionCommand{
+	{"", []string{
+		"-i", "--input", "--header", "-bw", "--bitwarden", "--keychain", "-H", "--hashed", "--input-format", "--keys", "--encoding", "--normalize", "--ntlm",
+		"--bloom", "--index", "--prompt", "-x", "--hide", "--mask", "--print-hash", "--secure-memory", "-o", "--output", "--report", "--export-bad", "--history-db", "--template", "--format",
+		"--fields", "--syslog", "--webhook", "--webhook-format", "--smtp", "--smtp-user", "--mail-from", "--mail-to", "--tag", "--min-count", "--fail-threshold", "-q", "--quiet", "-s", "--stats", "--reuse", "--top", "--stats-file", "--group-by", "--group-map",
+		"--sample", "--seed", "--max-line-length", "--stdio", "--strict-single", "--tui", "--budget", "--resume", "--watch", "--every", "--state", "--cursor", "--cache-ttl",
+		"--rps", "--pin-sha256", "--ca-cert", "--client-cert", "--client-key", "--insecure-skip-verify", "--timeout", "--breaker", "--breaker-retries", "--max-errors", "--deadline",
+		"--only-bad", "--only-good", "--dedupe", "--batch", "--ignore-file", "--strength", "--analyze", "--policy", "--variants", "--suggest",
+		"--suggest-length", "--suggest-charset", "--suggest-words", "--lang", "--no-color", "-v", "--verbose", "-vv", "-c", "--credits",
+	}},
+	{"verify-fix", []string{"--from", "-i", "--input", "-bw", "--bitwarden", "-H", "--hashed", "--input-format", "--encoding", "--ntlm", "--format", "--rps", "--pin-sha256", "--ca-cert", "--no-color", "-v", "--verbose"}},
+	{"proxy", []string{"--listen", "--cache-ttl", "--cache-entries", "--rps", "--timeout", "--pin-sha256", "--ca-cert", "--client-cert", "--client-key", "--history-db", "-v", "--verbose", "-vv"}},
+	{"k8s-audit", []string{
+		"--kubeconfig", "--context", "-n", "--namespace", "-A", "--all-namespaces", "--keys", "--format", "-o", "--output", "--min-count",
+		"--fail-threshold", "-s", "--stats", "--group-by", "-q", "--quiet", "--rps", "--timeout", "--no-color", "-v", "--verbose",
+	}},
+	{"aws-audit", []string{
+		"--profile", "--region", "--service", "--path", "--keys", "--format", "-o", "--output", "--min-count", "--fail-threshold",
+		"-s", "--stats", "--group-by", "-q", "--quiet", "--rps", "--timeout", "--no-color", "-v", "--verbose",
+	}},
+	{"scan-repo", []string{
+		"--history", "--rules", "--hide", "--mask", "--format", "--fields", "-o", "--output", "--min-count", "--fail-threshold",
+		"-s", "--stats", "--group-by", "-q", "--quiet", "--rps", "--timeout", "--no-color", "-v", "--verbose",
+	}},
+	{"hook", []string{"--staged", "--install", "--rules", "--min-count", "--rps", "--timeout", "--no-color", "-v", "--verbose"}},
+	{"download", []string{"-o", "--output", "--update", "--ntlm", "--workers", "--rps", "--timeout", "--pin-sha256", "--ca-cert", "--client-cert", "--client-key", "-v", "--verbose", "-vv"}},
+	{"build-bloom", []string{"-i", "--input", "-o", "--output", "--fp", "--min-count", "-n", "--entries"}},
+	{"index", []string{"-i", "--input", "-o", "--output", "--backend", "--min-count"}},
+	{"bench", []string{"--requests", "--workers", "--bloom", "--index", "--timeout", "--pin-sha256", "--ca-cert", "--client-cert", "--client-key", "-v", "--verbose"}},
+	{"doctor", []string{
+		"--bloom", "--index", "--timeout", "--pin-sha256", "--ca-cert", "--client-cert", "--client-key", "--insecure-skip-verify",
+		"--no-color", "-v", "--verbose",
+	}},
+	{"history", []string{"--db", "--source", "-n", "--runs", "--diff", "--run", "--no-color"}},
+	{"completion", nil},
+}
+
+func runCompletion(args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck completion bash|zsh|fish|powershell\n\n")
+		fmt.Fprintf(os.Stderr, "Writes a script completing the subcommands and flags of pwnedcheck to stdout.\n\n")
+		fmt.Fprintf(os.Stderr, "  bash        source <(pwnedcheck completion bash)\n")
+		fmt.Fprintf(os.Stderr, "  zsh         pwnedcheck completion zsh > \"${fpath[1]}/_pwnedcheck\"\n")
+		fmt.Fprintf(os.Stderr, "  fish        pwnedcheck completion fish > ~/.config/fish/completions/pwnedcheck.fish\n")
+		fmt.Fprintf(os.Stderr, "  powershell  pwnedcheck completion powershell | Out-String | Invoke-Expression\n")
+	}
+	if len(args) != 1 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		writeZshCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	case "powershell":
+		writePowerShellCompletion(os.Stdout)
+	case "-h", "-help", "--help":
+		usage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown shell %q; choose bash, zsh, fish or powershell\n", args[0])
+		return 2
+	}
+	return 0
+}
+
+func completionWords(c completionCommand) string {
+	if c.name == "completion" {
+		return strings.Join(completionShells, " ")
+	}
+	return strings.Join(c.flags, " ")
+}
+
+func subcommandNames() string {
+	var names []string
+	for _, c := range completionCommands[1:] {
+		names = append(names, c.name)
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# bash completion for pwnedcheck\n\n")
+	fmt.Fprintf(w, "_pwnedcheck() {\n")
+	fmt.Fprintf(w, "    local cur=${COMP_WORDS[COMP_CWORD]} cmd= words\n")
+	fmt.Fprintf(w, "    (( COMP_CWORD > 1 )) && cmd=${COMP_WORDS[1]}\n")
+	fmt.Fprintf(w, "    case $cmd in\n")
+	for _, c := range completionCommands[1:] {
+		fmt.Fprintf(w, "    %s) words=%q ;;\n", c.name, completionWords(c))
+	}
+	fmt.Fprintf(w, "    *)\n")
+	fmt.Fprintf(w, "        words=%q\n", completionWords(completionCommands[0]))
+	fmt.Fprintf(w, "        (( COMP_CWORD == 1 )) && words=%q\" $words\"\n", subcommandNames())
+	fmt.Fprintf(w, "        ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    if [[ $cur == -* || $cmd == completion ]] || (( COMP_CWORD == 1 )); then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "complete -o default -F _pwnedcheck pwnedcheck\n")
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintf(w, "#compdef pwnedcheck\n\n")
+	fmt.Fprintf(w, "_pwnedcheck() {\n")
+	fmt.Fprintf(w, "    local -a opts\n")
+	fmt.Fprintf(w, "    case $words[2] in\n")
+	for _, c := range completionCommands[1:] {
+		fmt.Fprintf(w, "    %s) opts=(%s) ;;\n", c.name, completionWords(c))
+	}
+	fmt.Fprintf(w, "    *) opts=(%s) ;;\n", completionWords(completionCommands[0]))
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    if (( CURRENT == 2 )) && [[ $PREFIX != -* ]]; then\n")
+	fmt.Fprintf(w, "        compadd -- %s\n", subcommandNames())
+	fmt.Fprintf(w, "        _files\n")
+	fmt.Fprintf(w, "    elif [[ $PREFIX == -* || $words[2] == completion ]]; then\n")
+	fmt.Fprintf(w, "        compadd -- $opts\n")
+	fmt.Fprintf(w, "    else\n")
+	fmt.Fprintf(w, "        _files\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "if [[ $funcstack[1] == _pwnedcheck ]]; then\n")
+	fmt.Fprintf(w, "    _pwnedcheck \"$@\"\n")
+	fmt.Fprintf(w, "else\n")
+	fmt.Fprintf(w, "    compdef _pwnedcheck pwnedcheck\n")
+	fmt.Fprintf(w, "fi\n")
+}
+
+func writeFishCompletion(w io.Writer) {
+	subcommands := subcommandNames()
+	fmt.Fprintf(w, "# fish completion for pwnedcheck\n\n")
+	fmt.Fprintf(w, "complete -c pwnedcheck -n __fish_use_subcommand -a '%s'\n", subcommands)
+	for _, c := range completionCommands {
+		cond := "__fish_seen_subcommand_from " + c.name
+		if c.name == "" {
+			cond = "not __fish_seen_subcommand_from " + subcommands
+		}
+		if c.name == "completion" {
+			fmt.Fprintf(w, "complete -c pwnedcheck -n '%s' -f -a '%s'\n", cond, completionWords(c))
+			continue
+		}
+		for _, f := range c.flags {
+			opt := "-o " + f[1:]
+			switch {
+			case strings.HasPrefix(f, "--"):
+				opt = "-l " + f[2:]
+			case len(f) == 2:
+				opt = "-s " + f[1:]
+			}
+			fmt.Fprintf(w, "complete -c pwnedcheck -n '%s' %s\n", cond, opt)
+		}
+	}
+}
+
+func writePowerShellCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# PowerShell completion for pwnedcheck\n\n")
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName pwnedcheck -ScriptBlock {\n")
+	fmt.Fprintf(w, "    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprintf(w, "    $elements = $commandAst.CommandElements\n")
+	fmt.Fprintf(w, "    $command = ''\n")
+	fmt.Fprintf(w, "    if ($elements.Count -gt 2 -or ($elements.Count -eq 2 -and $wordToComplete -eq '')) {\n")
+	fmt.Fprintf(w, "        $command = $elements[1].ToString()\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "    $words = switch ($command) {\n")
+	for _, c := range completionCommands[1:] {
+		fmt.Fprintf(w, "        '%s' { '%s' }\n", c.name, completionWords(c))
+	}
+	fmt.Fprintf(w, "        default {\n")
+	fmt.Fprintf(w, "            $top = '%s'\n", completionWords(completionCommands[0]))
+	fmt.Fprintf(w, "            if ($elements.Count -le 2) { '%s ' + $top } else { $top }\n", subcommandNames())
+	fmt.Fprintf(w, "        }\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "    $words -split ' ' | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "}\n")
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck doctor [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Checks the proxy settings, DNS, both HIBP range APIs, TLS, the clock, the\n")
+		fmt.Fprintf(os.Stderr, "working directory and the offline datasets, and says how to fix each failure.\n")
+		fmt.Fprintf(os.Stderr, "Exits 1 when a check failed.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --bloom <file>       Also check this filter from build-bloom\n")
+		fmt.Fprintf(os.Stderr, "      --index <file>       Also check this index from the index subcommand, reading it in full\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --pin-sha256 <hash>  Require HIBP to present this base64 SHA-256 public key (SPKI) hash (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --ca-cert <file>     Also trust the CA certificates in this PEM file\n")
+		fmt.Fprintf(os.Stderr, "      --client-cert <file> PEM client certificate for mutual TLS (needs --client-key)\n")
+		fmt.Fprintf(os.Stderr, "      --client-key <file>  PEM private key for --client-cert\n")
+		fmt.Fprintf(os.Stderr, "      --insecure-skip-verify Do not verify TLS certificates at all (dangerous, for debugging only)\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable colored output\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each request to stderr\n")
+	}
+
+	var (
+		bloomFile   string
+		indexFile   string
+		timeout     time.Duration
+		rawPins     stringList
+		caCert      string
+		clientCert  string
+		clientKey   string
+		insecureTLS bool
+		noColor     bool
+		verbose     bool
+	)
+	fs.StringVar(&bloomFile, "bloom", "", "")
+	fs.StringVar(&indexFile, "index", "", "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.Var(&rawPins, "pin-sha256", "")
+	fs.StringVar(&caCert, "ca-cert", "", "")
+	fs.StringVar(&clientCert, "client-cert", "", "")
+	fs.StringVar(&clientKey, "client-key", "", "")
+	fs.BoolVar(&insecureTLS, "insecure-skip-verify", false, "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	if fs.NArg() > 0 || timeout <= 0 {
+		fs.Usage()
+		return 2
+	}
+	pins, err := parsePins(rawPins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	tlsConfig, err := loadTLSConfig(caCert, clientCert, clientKey, insecureTLS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	return checker.Doctor(checker.Config{
+		BloomFile: bloomFile,
+		IndexFile: indexFile,
+		Timeout:   timeout,
+		Pins:      pins,
+		TLS:       tlsConfig,
+		NoColor:   noColor,
+		Verbosity: verbosity(verbose, false),
+	})
+}
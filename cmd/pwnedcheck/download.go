@@ -0,0 +1,102 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runDownload(args []string) int {
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck download [-o pwnedpasswords.txt] [--update] [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Downloads the whole HIBP corpus as sorted HASH:COUNT lines, for build-bloom and\n")
+		fmt.Fprintf(os.Stderr, "index. ETags are kept in <output>.etags so --update only re-fetches changed ranges.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Dataset file (default \"pwnedpasswords.txt\", or \"pwnedpasswords-ntlm.txt\" with --ntlm)\n")
+		fmt.Fprintf(os.Stderr, "      --update             Patch an earlier download, copying ranges whose ETag is unchanged\n")
+		fmt.Fprintf(os.Stderr, "      --ntlm               Download the NTLM corpus instead of SHA-1\n")
+		fmt.Fprintf(os.Stderr, "      --workers <n>        Ranges fetched at once (default 32)\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum requests per second, 0 disables the limit (default 0)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --pin-sha256 <hash>  Require HIBP to present this base64 SHA-256 public key (SPKI) hash (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --ca-cert <file>     Also trust the CA certificates in this PEM file\n")
+		fmt.Fprintf(os.Stderr, "      --client-cert <file> PEM client certificate for mutual TLS (needs --client-key)\n")
+		fmt.Fprintf(os.Stderr, "      --client-key <file>  PEM private key for --client-cert\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each request to stderr\n")
+		fmt.Fprintf(os.Stderr, "  -vv                      Also log unchanged ranges\n")
+	}
+
+	var (
+		outputFile  string
+		update      bool
+		ntlm        bool
+		workers     int
+		rps         float64
+		timeout     time.Duration
+		rawPins     stringList
+		caCert      string
+		clientCert  string
+		clientKey   string
+		verbose     bool
+		veryVerbose bool
+	)
+	fs.StringVar(&outputFile, "o", "", "")
+	fs.StringVar(&outputFile, "output", "", "")
+	fs.BoolVar(&update, "update", false, "")
+	fs.BoolVar(&ntlm, "ntlm", false, "")
+	fs.IntVar(&workers, "workers", 32, "")
+	fs.Float64Var(&rps, "rps", 0, "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.Var(&rawPins, "pin-sha256", "")
+	fs.StringVar(&caCert, "ca-cert", "", "")
+	fs.StringVar(&clientCert, "client-cert", "", "")
+	fs.StringVar(&clientKey, "client-key", "", "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.BoolVar(&veryVerbose, "vv", false, "")
+	fs.Parse(args)
+
+	if outputFile == "" {
+		outputFile = "pwnedpasswords.txt"
+		if ntlm {
+			outputFile = "pwnedpasswords-ntlm.txt"
+		}
+	}
+	if workers <= 0 || rps < 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--workers and --timeout must be positive and --rps must not be negative\n")
+		return 2
+	}
+	pins, err := parsePins(rawPins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	tlsConfig, err := loadTLSConfig(caCert, clientCert, clientKey, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	return checker.Download(checker.Config{
+		OutputFile: outputFile,
+		Update:     update,
+		NTLM:       ntlm,
+		Workers:    workers,
+		RPS:        rps,
+		Timeout:    timeout,
+		Pins:       pins,
+		TLS:        tlsConfig,
+		Verbosity:  verbosity(verbose, veryVerbose),
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck history --db history.db [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Lists the runs recorded with --history-db, oldest first, with the findings each\n")
+		fmt.Fprintf(os.Stderr, "gained and lost since the previous run of its source. --diff lists those findings.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --db <file>          History database written by --history-db (required)\n")
+		fmt.Fprintf(os.Stderr, "      --source <name>      Only runs of this input file, or argument, keychain, kubernetes or aws\n")
+		fmt.Fprintf(os.Stderr, "  -n, --runs <n>           Show the last n runs; 0 shows them all (default 20)\n")
+		fmt.Fprintf(os.Stderr, "      --diff               List the new and fixed findings of the latest run instead\n")
+		fmt.Fprintf(os.Stderr, "      --run <id>           Compare this run instead of the latest with --diff\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable colored output\n")
+	}
+
+	var (
+		db      string
+		source  string
+		runs    int
+		diff    bool
+		run     int64
+		noColor bool
+	)
+	fs.StringVar(&db, "db", "", "")
+	fs.StringVar(&source, "source", "", "")
+	fs.IntVar(&runs, "n", 20, "")
+	fs.IntVar(&runs, "runs", 20, "")
+	fs.BoolVar(&diff, "diff", false, "")
+	fs.Int64Var(&run, "run", 0, "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.Parse(args)
+
+	if db == "" {
+		fmt.Fprintf(os.Stderr, "--db is required\n")
+		return 2
+	}
+	if fs.NArg() > 0 || runs < 0 || run < 0 {
+		fs.Usage()
+		return 2
+	}
+	if run > 0 && !diff {
+		fmt.Fprintf(os.Stderr, "--run needs --diff\n")
+		return 2
+	}
+	if _, err := os.Stat(db); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	return checker.History(checker.Config{
+		HistoryDB:   db,
+		InputFile:   source,
+		HistoryRuns: runs,
+		HistoryDiff: diff,
+		HistoryRun:  run,
+		NoColor:     noColor,
+	})
+}
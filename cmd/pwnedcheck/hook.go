@@ -0,0 +1,124 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+// hookScript is the pre-commit hook --install writes.
+const hookScript = "#!/bin/sh\n# installed by pwnedcheck hook --install\nexec pwnedcheck hook --staged\n"
+
+func runHook(args []string) int {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck hook --staged [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck hook --install\n\n")
+		fmt.Fprintf(os.Stderr, "Checks the passwords in the lines staged for commit with the scan-repo rules,\n")
+		fmt.Fprintf(os.Stderr, "and exits non-zero with their file and line when any is breached. Run it\n")
+		fmt.Fprintf(os.Stderr, "from a git pre-commit hook; it prints nothing when there is nothing to check.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --staged             Check the staged changes of the repository in the current directory\n")
+		fmt.Fprintf(os.Stderr, "      --install            Install a pre-commit hook running \"pwnedcheck hook --staged\"\n")
+		fmt.Fprintf(os.Stderr, "      --rules <file>       YAML rules replacing the built-in assignment and URL rules\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Treat passwords seen fewer than n times in breaches as acceptable\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum HIBP requests per second, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable ANSI colors\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each HIBP request to stderr\n")
+	}
+
+	var (
+		staged    bool
+		install   bool
+		rulesFile string
+		minCount  int
+		rps       float64
+		timeout   time.Duration
+		noColor   bool
+		verbose   bool
+	)
+	fs.BoolVar(&staged, "staged", false, "")
+	fs.BoolVar(&install, "install", false, "")
+	fs.StringVar(&rulesFile, "rules", "", "")
+	fs.IntVar(&minCount, "min-count", 0, "")
+	fs.Float64Var(&rps, "rps", 10, "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	if staged == install {
+		fs.Usage()
+		return 2
+	}
+	if install {
+		if err := installHook(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if minCount < 0 {
+		fmt.Fprintf(os.Stderr, "--min-count must not be negative\n")
+		return 2
+	}
+	if rps < 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--timeout must be positive and --rps must not be negative\n")
+		return 2
+	}
+
+	return checker.Run(checker.Config{
+		RepoPath:     ".",
+		RepoStaged:   true,
+		RulesFile:    rulesFile,
+		HidePassword: true,
+		MinCount:     minCount,
+		RPS:          rps,
+		Timeout:      timeout,
+		NoColor:      noColor,
+		Verbosity:    verbosity(verbose, false),
+	})
+}
+
+// installHook writes the pre-commit hook where git looks for it, honouring
+// core.hooksPath, and never replaces a hook it did not write.
+func installHook() error {
+	out, err := exec.Command("git", "rev-parse", "--git-path", "hooks/pre-commit").Output()
+	if err != nil {
+		return errors.New("not inside a git repository")
+	}
+	path := strings.TrimSpace(string(out))
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && !bytes.Equal(existing, []byte(hookScript)):
+		return fmt.Errorf("%s already exists; add \"pwnedcheck hook --staged\" to it instead", path)
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(hookScript), 0o755); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Installed %s\n", path)
+	return nil
+}
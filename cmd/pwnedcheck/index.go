@@ -0,0 +1,60 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runIndex(args []string) int {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck index -i pwnedpasswords.txt -o hibp.idx [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Packs the downloaded SHA-1 or NTLM corpus (HASH:COUNT lines, sorted by hash)\n")
+		fmt.Fprintf(os.Stderr, "into a binary index or an SQLite database for exact offline checks with --index.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  -i, --input <file>       Downloaded corpus, optionally compressed (gzip, bzip2, zstd, zip), or an http(s):// URL\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Index file to write (default \"hibp.idx\")\n")
+		fmt.Fprintf(os.Stderr, "      --backend <name>     Index format: binary, or sqlite for a database you can query (default \"binary\")\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Leave out hashes seen fewer than n times, shrinking the index\n")
+	}
+
+	var (
+		inputFile  string
+		outputFile string
+		backend    string
+		minCount   int
+	)
+	fs.StringVar(&inputFile, "i", "", "")
+	fs.StringVar(&inputFile, "input", "", "")
+	fs.StringVar(&outputFile, "o", "hibp.idx", "")
+	fs.StringVar(&outputFile, "output", "hibp.idx", "")
+	fs.StringVar(&backend, "backend", "binary", "")
+	fs.IntVar(&minCount, "min-count", 0, "")
+	fs.Parse(args)
+
+	if inputFile == "" {
+		fmt.Fprintf(os.Stderr, "--input is required\n")
+		return 2
+	}
+	if minCount < 0 {
+		fmt.Fprintf(os.Stderr, "--min-count must not be negative\n")
+		return 2
+	}
+
+	return checker.BuildIndex(checker.Config{
+		InputFile:    inputFile,
+		IndexFile:    outputFile,
+		IndexBackend: backend,
+		MinCount:     minCount,
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+	"github.com/mohamedation/PwnedCheck/internal/kube"
+)
+
+func runK8sAudit(args []string) int {
+	fs := flag.NewFlagSet("k8s-audit", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck k8s-audit [-n namespace ...] [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Lists the Secrets of a Kubernetes cluster and checks the values under\n")
+		fmt.Fprintf(os.Stderr, "password-like keys, plus image pull secret passwords, against HIBP. Findings\n")
+		fmt.Fprintf(os.Stderr, "are named namespace/secret/key; secret values are never printed.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --kubeconfig <file>  Kubeconfig to use (default $KUBECONFIG or ~/.kube/config)\n")
+		fmt.Fprintf(os.Stderr, "      --context <name>     Kubeconfig context (default the current context)\n")
+		fmt.Fprintf(os.Stderr, "  -n, --namespace <ns>     Namespace to audit (repeatable; default the context's namespace)\n")
+		fmt.Fprintf(os.Stderr, "  -A, --all-namespaces     Audit every namespace\n")
+		fmt.Fprintf(os.Stderr, "      --keys <regexp>      Secret data keys to check (default %q)\n", checker.DefaultSecretKeys)
+		fmt.Fprintf(os.Stderr, "      --format <string>    Per-result output format: text, table, csv, json, markdown, sarif or junit (default \"text\")\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Write machine-readable results to a file; format from -format or the extension\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Treat passwords seen fewer than n times in breaches as acceptable\n")
+		fmt.Fprintf(os.Stderr, "      --fail-threshold <n> Exit 0 unless more than n compromised passwords are found (default 0)\n")
+		fmt.Fprintf(os.Stderr, "  -s, --stats              Show runtime and result summary after completion\n")
+		fmt.Fprintf(os.Stderr, "      --group-by <dim>     Also break the summary down by account, username or folder (the namespace)\n")
+		fmt.Fprintf(os.Stderr, "  -q, --quiet              Suppress per-secret output; only the -stats summary and the exit code remain\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum HIBP requests per second, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP and Kubernetes API request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable ANSI colors\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each HIBP request to stderr\n")
+	}
+
+	var (
+		kubeconfig    string
+		context       string
+		namespaces    stringList
+		allNamespaces bool
+		keys          string
+		format        string
+		outputFile    string
+		minCount      int
+		failThresh    int
+		showStats     bool
+		groupBy       string
+		quiet         bool
+		rps           float64
+		timeout       time.Duration
+		noColor       bool
+		verbose       bool
+	)
+	fs.StringVar(&kubeconfig, "kubeconfig", kube.DefaultConfigPath(), "")
+	fs.StringVar(&context, "context", "", "")
+	fs.Var(&namespaces, "n", "")
+	fs.Var(&namespaces, "namespace", "")
+	fs.BoolVar(&allNamespaces, "A", false, "")
+	fs.BoolVar(&allNamespaces, "all-namespaces", false, "")
+	fs.StringVar(&keys, "keys", checker.DefaultSecretKeys, "")
+	fs.StringVar(&format, "format", "text", "")
+	fs.StringVar(&outputFile, "o", "", "")
+	fs.StringVar(&outputFile, "output", "", "")
+	fs.IntVar(&minCount, "min-count", 0, "")
+	fs.IntVar(&failThresh, "fail-threshold", 0, "")
+	fs.BoolVar(&showStats, "s", false, "")
+	fs.BoolVar(&showStats, "stats", false, "")
+	fs.StringVar(&groupBy, "group-by", "", "")
+	fs.BoolVar(&quiet, "q", false, "")
+	fs.BoolVar(&quiet, "quiet", false, "")
+	fs.Float64Var(&rps, "rps", 10, "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	if kubeconfig == "" {
+		fmt.Fprintf(os.Stderr, "no kubeconfig found; pass --kubeconfig\n")
+		return 2
+	}
+	if allNamespaces && len(namespaces) > 0 {
+		fmt.Fprintf(os.Stderr, "--namespace and --all-namespaces cannot be combined\n")
+		return 2
+	}
+	if minCount < 0 || failThresh < 0 {
+		fmt.Fprintf(os.Stderr, "--fail-threshold and --min-count must not be negative\n")
+		return 2
+	}
+	if rps < 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--timeout must be positive and --rps must not be negative\n")
+		return 2
+	}
+
+	return checker.Run(checker.Config{
+		KubeConfig:        kubeconfig,
+		KubeContext:       context,
+		KubeNamespaces:    namespaces,
+		KubeAllNamespaces: allNamespaces,
+		SecretKeys:        keys,
+		HidePassword:      true,
+		Format:            format,
+		OutputFile:        outputFile,
+		MinCount:          minCount,
+		FailThreshold:     failThresh,
+		ShowStats:         showStats,
+		GroupBy:           groupBy,
+		Quiet:             quiet,
+		RPS:               rps,
+		Timeout:           timeout,
+		NoColor:           noColor,
+		Verbosity:         verbosity(verbose, false),
+	})
+}
@@ -8,25 +8,154 @@
 package main
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/mohamedation/PwnedCheck/internal/checker"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify-fix":
+			os.Exit(runVerifyFix(os.Args[2:]))
+		case "proxy":
+			os.Exit(runProxy(os.Args[2:]))
+		case "build-bloom":
+			os.Exit(runBuildBloom(os.Args[2:]))
+		case "index":
+			os.Exit(runIndex(os.Args[2:]))
+		case "download":
+			os.Exit(runDownload(os.Args[2:]))
+		case "k8s-audit":
+			os.Exit(runK8sAudit(os.Args[2:]))
+		case "aws-audit":
+			os.Exit(runAWSAudit(os.Args[2:]))
+		case "scan-repo":
+			os.Exit(runScanRepo(os.Args[2:]))
+		case "hook":
+			os.Exit(runHook(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "completion":
+			os.Exit(runCompletion(os.Args[2:]))
+		}
+	}
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "PwnedCheck\n")
 		fmt.Fprintf(os.Stderr, "by mohamedation - v%s\n\n", "1.0.0")
-		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck [options] [password ...]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck [options] [password ...]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck verify-fix -from report.json [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck proxy [-listen addr] [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck k8s-audit [-n namespace ...] [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck aws-audit [--profile name] [--region region] [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck scan-repo [options] [path]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck hook --staged [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck download [-o pwnedpasswords.txt] [--update] [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck build-bloom -i pwnedpasswords.txt -o hibp.bloom [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck index -i pwnedpasswords.txt -o hibp.idx [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck bench [--requests n] [--workers n] [--bloom file] [--index file]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck doctor [--bloom file] [--index file] [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck history --db history.db [--diff] [options]\n")
+		fmt.Fprintf(os.Stderr, "       pwnedcheck completion bash|zsh|fish|powershell\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
-		fmt.Fprintf(os.Stderr, "  -i, --input <string>     Input file containing passwords or JSON export (default \"passwords.txt\")\n")
+		fmt.Fprintf(os.Stderr, "  -i, --input <string>     Input file or http(s):// URL containing passwords or JSON export (default \"passwords.txt\")\n")
+		fmt.Fprintf(os.Stderr, "      --header <string>    HTTP header sent when --input is an http(s):// URL, e.g. 'Authorization: Bearer $TOKEN' (repeatable)\n")
 		fmt.Fprintf(os.Stderr, "  -bw, --bitwarden         Treat input file as a Bitwarden password-protected encrypted JSON export\n")
-		fmt.Fprintf(os.Stderr, "  -H, --hashed             Input file contains pre-computed SHA-1 hashes instead of plaintext\n")
+		fmt.Fprintf(os.Stderr, "      --keychain           Check the passwords saved in the macOS Keychain, Windows Credential Manager or Secret Service keyring\n")
+		fmt.Fprintf(os.Stderr, "  -H, --hashed             Input file contains pre-computed SHA-1 hashes instead of plaintext; malformed lines are reported as errors\n")
+		fmt.Fprintf(os.Stderr, "      --input-format <name> Input line layout: auto, lines, userpass for user:password or user<TAB>password, pwdump/secretsdump for user:rid:lm:nt::: dumps (implies --ntlm --hashed), potfile for hashcat hash:plain, or dotenv for KEY=value .env files (default \"auto\")\n")
+		fmt.Fprintf(os.Stderr, "      --keys <regexp>      Variables checked with --input-format dotenv (default %q)\n", checker.DefaultSecretKeys)
+		fmt.Fprintf(os.Stderr, "      --encoding <name>    Input text encoding: auto, utf8, utf16le, utf16be, latin1 or cp1252 (default \"auto\")\n")
+		fmt.Fprintf(os.Stderr, "      --normalize <form>   Unicode-normalize plaintext before hashing: nfc, nfkc or none (default \"none\")\n")
+		fmt.Fprintf(os.Stderr, "      --ntlm               Check NTLM hashes against the HIBP NTLM corpus; with -hashed, input lines are 32-hex NTLM\n")
+		fmt.Fprintf(os.Stderr, "      --bloom <file>       Check offline against a filter from build-bloom; nothing is sent, hits have no count\n")
+		fmt.Fprintf(os.Stderr, "      --index <file>       Check offline against an exact index or SQLite database from the index subcommand; nothing is sent\n")
+		fmt.Fprintf(os.Stderr, "      --prompt             Read one password interactively with echo disabled instead of from the command line\n")
 		fmt.Fprintf(os.Stderr, "  -x, --hide               Hide plaintext passwords from console output\n")
-		fmt.Fprintf(os.Stderr, "  -s, --stats              Show runtime and result summary after completion\n")
-		fmt.Fprintf(os.Stderr, "  -v, --verbose            Print each HIBP request to show exactly what is sent to the API\n")
+		fmt.Fprintf(os.Stderr, "      --mask               Show only the first and last character of passwords, plus the length\n")
+		fmt.Fprintf(os.Stderr, "      --print-hash         Show the SHA-1 (or NTLM) hash instead of the password in all output\n")
+		fmt.Fprintf(os.Stderr, "      --secure-memory      Hash file and prompt input as it is read and zero the buffers; implies --hide\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Write machine-readable results to a file; format from -format or the extension (.json, .csv, .txt, .md, .sarif, .xml)\n")
+		fmt.Fprintf(os.Stderr, "      --report <file>      Write a standalone HTML audit report with charts and masked findings\n")
+		fmt.Fprintf(os.Stderr, "      --export-bad <file>  Write compromised entries, masked, as CSV for ticketing or forced-reset tooling\n")
+		fmt.Fprintf(os.Stderr, "      --history-db <file>  Record the run's findings in this SQLite database, dating findings with first_seen and last_seen\n")
+		fmt.Fprintf(os.Stderr, "      --syslog <target>    Also send findings to syslog: local, udp://host:port or tcp://host:port\n")
+		fmt.Fprintf(os.Stderr, "      --webhook <url>      POST findings and the summary as JSON to this URL when the run ends, and each finding with --watch\n")
+		fmt.Fprintf(os.Stderr, "      --webhook-format <f> Webhook body: json, or a message card for a slack or teams incoming webhook (default \"json\")\n")
+		fmt.Fprintf(os.Stderr, "      --smtp <url>         Mail findings with the HTML report via smtp://host:port (STARTTLS) or smtps://host:port\n")
+		fmt.Fprintf(os.Stderr, "      --smtp-user <name>   SMTP login; the password is read from $PWNEDCHECK_SMTP_PASSWORD\n")
+		fmt.Fprintf(os.Stderr, "      --mail-from <addr>   Sender of the findings email\n")
+		fmt.Fprintf(os.Stderr, "      --mail-to <addr>     Recipient of the findings email (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --format <string>    Per-result output format: text, table, csv, json, markdown, sarif or junit (default \"text\")\n")
+		fmt.Fprintf(os.Stderr, "      --fields <list>      Comma-separated columns for table/csv/json/markdown output (default \"item,source,account,username,status,count\")\n")
+		fmt.Fprintf(os.Stderr, "                           Available: item,line,source,account,username,folder,password,hash,status,count,error,strength,crack_time,length,classes,entropy,policy,violations,variant,variant_count\n")
+		fmt.Fprintf(os.Stderr, "      --template <tmpl>    Go text/template rendered per result instead of -format, e.g. '{{.Line}}\\t{{.Pwned}}\\t{{.Count}}'\n")
+		fmt.Fprintf(os.Stderr, "      --tag <key=value>    Label attached to every finding and the summary in all outputs (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --lang <code>        Language of findings, notes and the summary: en, de or es (default: from LC_ALL, LC_MESSAGES or LANG)\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable ANSI colors (also disabled by NO_COLOR or when output is not a terminal)\n")
+		fmt.Fprintf(os.Stderr, "      --only-bad           Only list pwned passwords (and lookup errors); good ones are still counted\n")
+		fmt.Fprintf(os.Stderr, "      --only-good          Only list passwords that were not found (and lookup errors)\n")
+		fmt.Fprintf(os.Stderr, "      --strength           Add a local zxcvbn strength score (0-4) and crack-time estimate, warning on weak passwords\n")
+		fmt.Fprintf(os.Stderr, "      --analyze            Report length, character classes and Shannon entropy per password, plus their distribution\n")
+		fmt.Fprintf(os.Stderr, "      --policy <file>      Also evaluate every password against a YAML policy (min_length, require, min_classes, banned_substrings)\n")
+		fmt.Fprintf(os.Stderr, "      --variants           Also check case flips, trailing-digit changes and leetspeak of clean passwords\n")
+		fmt.Fprintf(os.Stderr, "      --suggest            Print a cryptographically random replacement next to every pwned password\n")
+		fmt.Fprintf(os.Stderr, "      --suggest-length <n> Length of suggested passwords (default 20)\n")
+		fmt.Fprintf(os.Stderr, "      --suggest-charset <s> Characters for suggestions: alnum, symbols, hex or a literal set (default \"symbols\")\n")
+		fmt.Fprintf(os.Stderr, "      --suggest-words <n>  Suggest a diceware-style passphrase of n words instead\n")
+		fmt.Fprintf(os.Stderr, "      --ignore-file <file> Skip accepted passwords, listed one per line as SHA-1 hashes or plaintext\n")
+		fmt.Fprintf(os.Stderr, "      --dedupe             Check each distinct password once and report how many duplicates were collapsed\n")
+		fmt.Fprintf(os.Stderr, "      --batch              Hash the whole input first and request each range once, in prefix order, before reporting\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Treat passwords seen fewer than n times in breaches as acceptable\n")
+		fmt.Fprintf(os.Stderr, "      --fail-threshold <n> Exit 0 unless more than n compromised passwords are found (default 0)\n")
+		fmt.Fprintf(os.Stderr, "  -q, --quiet              Suppress per-password output; only the -stats summary and the exit code remain\n")
+		fmt.Fprintf(os.Stderr, "  -s, --stats              Show runtime, throughput, result summary, cache, API and latency figures after completion, and progress lines when there is no bar\n")
+		fmt.Fprintf(os.Stderr, "      --reuse              Finish with the passwords several accounts share, breached or not, naming the accounts\n")
+		fmt.Fprintf(os.Stderr, "      --top <n>            Finish with the n most breached passwords found, masked, with their counts and where they were\n")
+		fmt.Fprintf(os.Stderr, "      --stats-file <file>  Write the run summary with timings, error kinds and cache efficiency to this file as JSON\n")
+		fmt.Fprintf(os.Stderr, "      --tui                Show a live dashboard of results, progress and cache stats; p pauses, f and / filter findings\n")
+		fmt.Fprintf(os.Stderr, "      --group-by <dim>     Also break the summary down by username, account, domain, folder or source\n")
+		fmt.Fprintf(os.Stderr, "      --group-map <file>   name,group lines mapping --group-by values to groups such as OUs or departments\n")
+		fmt.Fprintf(os.Stderr, "      --sample <n>         Check a uniform random sample of n lines from the input file and estimate the pwned rate\n")
+		fmt.Fprintf(os.Stderr, "      --seed <n>           Random seed for --sample, for reproducible audits (default: time-based)\n")
+		fmt.Fprintf(os.Stderr, "      --max-line-length <n> Skip and report input lines longer than n bytes; 0 for no limit (default: 1048576)\n")
+		fmt.Fprintf(os.Stderr, "      --stdio              Read passwords from stdin and answer \"status<TAB>count\" per line, for scripting\n")
+		fmt.Fprintf(os.Stderr, "      --strict-single      Check exactly one password from stdin silently; answer by exit code and a JSON line on fd 3 if open\n")
+		fmt.Fprintf(os.Stderr, "      --budget <duration>  Check as much of the input file as fits in the time budget (e.g. 30m), then save a cursor and resume there next run\n")
+		fmt.Fprintf(os.Stderr, "      --resume             Keep a checkpoint while checking the input file and continue from it after an interruption\n")
+		fmt.Fprintf(os.Stderr, "      --watch              After the first pass, keep watching the input file and check new or changed lines until Ctrl-C\n")
+		fmt.Fprintf(os.Stderr, "      --every <dur>        Keep running and repeat the audit on this interval, alerting only on new findings\n")
+		fmt.Fprintf(os.Stderr, "      --state <file>       Findings remembered between --every runs (default: <input>.state)\n")
+		fmt.Fprintf(os.Stderr, "      --cursor <file>      Checkpoint file for --budget and --resume (default: <input>.cursor)\n")
+		fmt.Fprintf(os.Stderr, "      --cache-ttl <dur>    How long a fetched hash range answers later lookups locally, 0 disables (default 1h)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request, and each wait on an http(s) input (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --max-errors <n>     Abort after n lookups fail at the API and report the remaining entries as skipped; 0 never aborts\n")
+		fmt.Fprintf(os.Stderr, "      --breaker <n>        Pause and retry with backoff after n lookups fail in a row from an outage; 0 never pauses (default 5)\n")
+		fmt.Fprintf(os.Stderr, "      --breaker-retries <n> Give up and exit 1 after the breaker retried one outage n times; 0 retries forever (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --deadline <dur>     Stop checking after this long and report the remaining entries as skipped\n")
+		fmt.Fprintf(os.Stderr, "      --pin-sha256 <hash>  Require the API server to present this base64 SHA-256 public key (SPKI) hash (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --ca-cert <file>     Also trust the CA certificates in this PEM file, e.g. a corporate proxy's\n")
+		fmt.Fprintf(os.Stderr, "      --client-cert <file> PEM client certificate for mutual TLS with a proxy or mirror (needs --client-key)\n")
+		fmt.Fprintf(os.Stderr, "      --client-key <file>  PEM private key for --client-cert\n")
+		fmt.Fprintf(os.Stderr, "      --insecure-skip-verify Do not verify TLS certificates at all (dangerous, for debugging only)\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum HIBP requests per second, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each HIBP request (prefix only), its status and timing to stderr\n")
+		fmt.Fprintf(os.Stderr, "  -vv                      Also log cache hits, local matching and checkpoints\n")
 		fmt.Fprintf(os.Stderr, "  -c, --credits            Show credits\n")
 		fmt.Fprintf(os.Stderr, "  -h, --help               Show help\n")
 	}
@@ -34,44 +163,526 @@ func main() {
 	var (
 		inputFile    string
 		hashed       bool
+		ntlm         bool
+		bloomFile    string
+		indexFile    string
 		hidePassword bool
+		maskPassword bool
+		printHash    bool
+		secureMemory bool
 		showStats    bool
+		statsFile    string
 		bitwarden    bool
+		keychain     bool
 		verbose      bool
+		veryVerbose  bool
 		credits      bool
+		sampleSize   int
+		sampleSeed   int64
+		maxLine      int
+		stdio        bool
+		strictSingle bool
+		tui          bool
+		headers      stringList
+		prompt       bool
+		budget       time.Duration
+		cursorFile   string
+		cacheTTL     time.Duration
+		rps          float64
+		rawPins      stringList
+		caCert       string
+		clientCert   string
+		clientKey    string
+		insecureTLS  bool
+		timeout      time.Duration
+		breakAfter   int
+		breakRetries int
+		maxErrors    int
+		top          int
+		reuse        bool
+		deadline     time.Duration
+		resume       bool
+		watch        bool
+		every        time.Duration
+		stateFile    string
+		format       string
+		fields       string
+		tmpl         string
+		quiet        bool
+		onlyBad      bool
+		onlyGood     bool
+		failThresh   int
+		minCount     int
+		dedupe       bool
+		batch        bool
+		ignoreFile   string
+		strength     bool
+		analyzeComp  bool
+		policyFile   string
+		checkVariant bool
+		suggest      bool
+		suggestLen   int
+		suggestChars string
+		suggestWords int
+		normalize    string
+		encoding     string
+		inputFormat  string
+		secretKeys   string
+		groupBy      string
+		groupMap     string
+		rawTags      stringList
+		noColor      bool
+		lang         string
+		outputFile   string
+		reportFile   string
+		exportBad    string
+		historyDB    string
+		syslogTarget string
+		webhook      string
+		webhookFmt   string
+		smtpServer   string
+		smtpUser     string
+		mailFrom     string
+		mailTo       stringList
 	)
 
 	flag.StringVar(&inputFile, "i", "passwords.txt", "")
 	flag.StringVar(&inputFile, "input", "passwords.txt", "")
 	flag.BoolVar(&hashed, "hashed", false, "")
 	flag.BoolVar(&hashed, "H", false, "")
+	flag.BoolVar(&ntlm, "ntlm", false, "")
+	flag.StringVar(&bloomFile, "bloom", "", "")
+	flag.StringVar(&indexFile, "index", "", "")
 	flag.BoolVar(&hidePassword, "hide", false, "")
 	flag.BoolVar(&hidePassword, "x", false, "")
+	flag.BoolVar(&maskPassword, "mask", false, "")
+	flag.BoolVar(&printHash, "print-hash", false, "")
+	flag.BoolVar(&secureMemory, "secure-memory", false, "")
 	flag.BoolVar(&showStats, "stats", false, "")
 	flag.BoolVar(&showStats, "s", false, "")
+	flag.StringVar(&statsFile, "stats-file", "", "")
 	flag.BoolVar(&bitwarden, "bw", false, "")
 	flag.BoolVar(&bitwarden, "bitwarden", false, "")
+	flag.BoolVar(&keychain, "keychain", false, "")
 	flag.BoolVar(&verbose, "v", false, "")
 	flag.BoolVar(&verbose, "verbose", false, "")
+	flag.BoolVar(&veryVerbose, "vv", false, "")
 	flag.BoolVar(&credits, "c", false, "")
 	flag.BoolVar(&credits, "credits", false, "")
+	flag.IntVar(&sampleSize, "sample", 0, "")
+	flag.Int64Var(&sampleSeed, "seed", 0, "")
+	flag.IntVar(&maxLine, "max-line-length", 1<<20, "")
+	flag.BoolVar(&stdio, "stdio", false, "")
+	flag.BoolVar(&strictSingle, "strict-single", false, "")
+	flag.BoolVar(&tui, "tui", false, "")
+	flag.Var(&headers, "header", "")
+	flag.BoolVar(&prompt, "prompt", false, "")
+	flag.DurationVar(&budget, "budget", 0, "")
+	flag.StringVar(&cursorFile, "cursor", "", "")
+	flag.BoolVar(&resume, "resume", false, "")
+	flag.BoolVar(&watch, "watch", false, "")
+	flag.DurationVar(&every, "every", 0, "")
+	flag.StringVar(&stateFile, "state", "", "")
+	flag.StringVar(&format, "format", "text", "")
+	flag.StringVar(&fields, "fields", "", "")
+	flag.StringVar(&tmpl, "template", "", "")
+	flag.BoolVar(&quiet, "q", false, "")
+	flag.BoolVar(&quiet, "quiet", false, "")
+	flag.BoolVar(&onlyBad, "only-bad", false, "")
+	flag.BoolVar(&onlyGood, "only-good", false, "")
+	flag.IntVar(&failThresh, "fail-threshold", 0, "")
+	flag.IntVar(&minCount, "min-count", 0, "")
+	flag.BoolVar(&dedupe, "dedupe", false, "")
+	flag.BoolVar(&batch, "batch", false, "")
+	flag.StringVar(&ignoreFile, "ignore-file", "", "")
+	flag.BoolVar(&strength, "strength", false, "")
+	flag.BoolVar(&analyzeComp, "analyze", false, "")
+	flag.StringVar(&policyFile, "policy", "", "")
+	flag.BoolVar(&checkVariant, "variants", false, "")
+	flag.BoolVar(&suggest, "suggest", false, "")
+	flag.IntVar(&suggestLen, "suggest-length", 20, "")
+	flag.StringVar(&suggestChars, "suggest-charset", "symbols", "")
+	flag.IntVar(&suggestWords, "suggest-words", 0, "")
+	flag.StringVar(&normalize, "normalize", "none", "")
+	flag.StringVar(&encoding, "encoding", "auto", "")
+	flag.StringVar(&inputFormat, "input-format", "auto", "")
+	flag.StringVar(&secretKeys, "keys", checker.DefaultSecretKeys, "")
+	flag.StringVar(&groupBy, "group-by", "", "")
+	flag.StringVar(&groupMap, "group-map", "", "")
+	flag.Var(&rawTags, "tag", "")
+	flag.BoolVar(&noColor, "no-color", false, "")
+	flag.StringVar(&lang, "lang", "", "")
+	flag.StringVar(&outputFile, "o", "", "")
+	flag.StringVar(&outputFile, "output", "", "")
+	flag.StringVar(&reportFile, "report", "", "")
+	flag.StringVar(&exportBad, "export-bad", "", "")
+	flag.StringVar(&historyDB, "history-db", "", "")
+	flag.StringVar(&syslogTarget, "syslog", "", "")
+	flag.StringVar(&webhook, "webhook", "", "")
+	flag.StringVar(&webhookFmt, "webhook-format", "json", "")
+	flag.StringVar(&smtpServer, "smtp", "", "")
+	flag.StringVar(&smtpUser, "smtp-user", "", "")
+	flag.StringVar(&mailFrom, "mail-from", "", "")
+	flag.Var(&mailTo, "mail-to", "")
+	flag.DurationVar(&cacheTTL, "cache-ttl", time.Hour, "")
+	flag.Float64Var(&rps, "rps", 10, "")
+	flag.Var(&rawPins, "pin-sha256", "")
+	flag.StringVar(&caCert, "ca-cert", "", "")
+	flag.StringVar(&clientCert, "client-cert", "", "")
+	flag.StringVar(&clientKey, "client-key", "", "")
+	flag.BoolVar(&insecureTLS, "insecure-skip-verify", false, "")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	flag.IntVar(&breakAfter, "breaker", 5, "")
+	flag.IntVar(&breakRetries, "breaker-retries", 10, "")
+	flag.IntVar(&maxErrors, "max-errors", 0, "")
+	flag.IntVar(&top, "top", 0, "")
+	flag.BoolVar(&reuse, "reuse", false, "")
+	flag.DurationVar(&deadline, "deadline", 0, "")
 
 	flag.Parse()
 
+	if strictSingle && (batch || statsFile != "" || webhook != "" || smtpServer != "" || bitwarden || prompt || stdio || watch || every > 0 || sampleSize > 0 || budget > 0 || resume || len(flag.Args()) > 0) {
+		fmt.Fprintf(os.Stderr, "--strict-single reads its password from stdin and cannot be combined with --batch, --stats-file, --webhook, --smtp, --bitwarden, --prompt, --stdio, --watch, --every, --sample, --budget, --resume or password arguments\n")
+		os.Exit(2)
+	}
+	if tui && (prompt || stdio || strictSingle || watch || every > 0 || quiet || verbose || veryVerbose || len(flag.Args()) > 0) {
+		fmt.Fprintf(os.Stderr, "--tui takes over the terminal and cannot be combined with --prompt, --stdio, --strict-single, --watch, --every, --quiet, --verbose or password arguments\n")
+		os.Exit(2)
+	}
+	if inputFormat != "auto" && inputFormat != "lines" && (bitwarden || prompt || stdio || sampleSize > 0 || len(flag.Args()) > 0) {
+		fmt.Fprintf(os.Stderr, "--input-format applies to the input file and cannot be combined with --bitwarden, --prompt, --stdio, --sample or password arguments\n")
+		os.Exit(2)
+	}
+	if inputFormat == "potfile" && (secureMemory || hashed || ntlm) {
+		fmt.Fprintf(os.Stderr, "--input-format potfile checks the cracked plaintexts and cannot be combined with --secure-memory, --hashed or --ntlm\n")
+		os.Exit(2)
+	}
+	if inputFormat == "dotenv" && (secureMemory || hashed) {
+		fmt.Fprintf(os.Stderr, "--input-format dotenv checks plaintext values and cannot be combined with --secure-memory or --hashed\n")
+		os.Exit(2)
+	}
+	if inputFormat == "userpass" && secureMemory {
+		fmt.Fprintf(os.Stderr, "--input-format userpass cannot be combined with --secure-memory, which hashes whole lines as they are read\n")
+		os.Exit(2)
+	}
+	// dump lines carry NT hashes
+	if inputFormat == "secretsdump" || inputFormat == "pwdump" {
+		ntlm, hashed = true, true
+	}
+
+	if watch && (bitwarden || prompt || stdio || sampleSize > 0 || budget > 0 || resume || deadline > 0 || len(flag.Args()) > 0) {
+		fmt.Fprintf(os.Stderr, "--watch only works on a plain input file and cannot be combined with --bitwarden, --prompt, --stdio, --sample, --budget, --resume, --deadline or password arguments\n")
+		os.Exit(2)
+	}
+
+	if keychain && (bitwarden || prompt || stdio || strictSingle || watch || every > 0 || sampleSize > 0 || budget > 0 || resume || hashed || len(flag.Args()) > 0) {
+		fmt.Fprintf(os.Stderr, "--keychain reads the system's saved passwords and cannot be combined with --bitwarden, --prompt, --stdio, --strict-single, --watch, --every, --sample, --budget, --resume, --hashed or password arguments\n")
+		os.Exit(2)
+	}
+
+	if every < 0 {
+		fmt.Fprintf(os.Stderr, "--every must not be negative\n")
+		os.Exit(2)
+	}
+	if every > 0 && (bitwarden || prompt || stdio || watch || sampleSize > 0 || budget > 0 || resume || len(flag.Args()) > 0) {
+		fmt.Fprintf(os.Stderr, "--every re-runs a file audit and cannot be combined with --bitwarden, --prompt, --stdio, --watch, --sample, --budget, --resume or password arguments\n")
+		os.Exit(2)
+	}
+
+	if (budget > 0 || resume) && sampleSize > 0 {
+		fmt.Fprintf(os.Stderr, "--budget and --resume cannot be combined with --sample\n")
+		os.Exit(2)
+	}
+	// the estimate extrapolates to every line, so the sample must be drawn
+	// from the lines the check would see
+	if (dedupe || ignoreFile != "") && sampleSize > 0 {
+		fmt.Fprintf(os.Stderr, "--dedupe and --ignore-file filter the input after sampling and cannot be combined with --sample\n")
+		os.Exit(2)
+	}
+
+	if onlyBad && onlyGood {
+		fmt.Fprintf(os.Stderr, "--only-bad and --only-good are mutually exclusive\n")
+		os.Exit(2)
+	}
+
+	if failThresh < 0 || minCount < 0 {
+		fmt.Fprintf(os.Stderr, "--fail-threshold and --min-count must not be negative\n")
+		os.Exit(2)
+	}
+
+	if bloomFile != "" && ntlm {
+		fmt.Fprintf(os.Stderr, "--bloom filters only cover SHA-1 and cannot be combined with --ntlm\n")
+		os.Exit(2)
+	}
+
+	if groupMap != "" && groupBy == "" {
+		fmt.Fprintf(os.Stderr, "--group-map needs --group-by to name the values it maps\n")
+		os.Exit(2)
+	}
+
+	if batch && (bloomFile != "" || indexFile != "") {
+		fmt.Fprintf(os.Stderr, "--batch saves API requests and has nothing to do with --bloom or --index\n")
+		os.Exit(2)
+	}
+
+	if bloomFile != "" && indexFile != "" {
+		fmt.Fprintf(os.Stderr, "--bloom and --index are mutually exclusive\n")
+		os.Exit(2)
+	}
+
+	if rps < 0 {
+		fmt.Fprintf(os.Stderr, "--rps must not be negative\n")
+		os.Exit(2)
+	}
+
+	if lang != "" && !slices.Contains(checker.Languages, lang) {
+		fmt.Fprintf(os.Stderr, "unknown --lang %q (available: %s)\n", lang, strings.Join(checker.Languages, ", "))
+		os.Exit(2)
+	}
+
+	if maxLine < 0 {
+		fmt.Fprintf(os.Stderr, "--max-line-length must not be negative\n")
+		os.Exit(2)
+	}
+
+	if breakAfter < 0 || breakRetries < 0 || maxErrors < 0 || top < 0 {
+		fmt.Fprintf(os.Stderr, "--breaker, --breaker-retries, --max-errors and --top must not be negative\n")
+		os.Exit(2)
+	}
+	if timeout <= 0 || deadline < 0 {
+		fmt.Fprintf(os.Stderr, "--timeout must be positive and --deadline must not be negative\n")
+		os.Exit(2)
+	}
+
+	if (strength || analyzeComp || policyFile != "" || checkVariant) && hashed {
+		fmt.Fprintf(os.Stderr, "--strength, --analyze, --policy and --variants need plaintext passwords and cannot be combined with --hashed\n")
+		os.Exit(2)
+	}
+
+	if secureMemory && (strength || analyzeComp || policyFile != "" || checkVariant) {
+		fmt.Fprintf(os.Stderr, "--secure-memory drops plaintext on read and cannot be combined with --strength, --analyze, --policy or --variants\n")
+		os.Exit(2)
+	}
+
 	if credits {
 		fmt.Println("PwnedCheck - v1.0.0\n\nby mohamedation\nReal work is done by Troy Hunt and the HIBP API.")
 		os.Exit(0)
 	}
 
+	inputHeaders, err := parseHeaders(headers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	pins, err := parsePins(rawPins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	tlsConfig, err := loadTLSConfig(caCert, clientCert, clientKey, insecureTLS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if insecureTLS {
+		fmt.Fprintf(os.Stderr, "WARNING: --insecure-skip-verify disables TLS certificate verification.\n")
+		fmt.Fprintf(os.Stderr, "WARNING: anyone on the network path can read and forge HIBP answers, so results cannot be trusted.\n")
+	}
+
+	tags, err := parseTags(rawTags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
 	cfg := checker.Config{
-		InputFile:    inputFile,
-		IsHashed:     hashed,
-		HidePassword: hidePassword,
-		ShowStats:    showStats,
-		Bitwarden:    bitwarden,
-		Verbose:      verbose,
-		Args:         flag.Args(),
+		InputFile:      inputFile,
+		IsHashed:       hashed,
+		NTLM:           ntlm,
+		BloomFile:      bloomFile,
+		IndexFile:      indexFile,
+		HidePassword:   hidePassword,
+		MaskPassword:   maskPassword,
+		PrintHash:      printHash,
+		SecureMemory:   secureMemory,
+		ShowStats:      showStats,
+		StatsFile:      statsFile,
+		Bitwarden:      bitwarden,
+		Keychain:       keychain,
+		Verbosity:      verbosity(verbose, veryVerbose),
+		SampleSize:     sampleSize,
+		SampleSeed:     sampleSeed,
+		MaxLineLength:  maxLine,
+		Stdio:          stdio,
+		StrictSingle:   strictSingle,
+		TUI:            tui,
+		InputHeaders:   inputHeaders,
+		Prompt:         prompt,
+		Budget:         budget,
+		CursorFile:     cursorFile,
+		Resume:         resume,
+		Watch:          watch,
+		Every:          every,
+		StateFile:      stateFile,
+		Format:         format,
+		Fields:         fields,
+		Template:       tmpl,
+		Quiet:          quiet,
+		OnlyBad:        onlyBad,
+		OnlyGood:       onlyGood,
+		FailThreshold:  failThresh,
+		MinCount:       minCount,
+		Dedupe:         dedupe,
+		Batch:          batch,
+		Breaker:        breakAfter,
+		BreakerRetries: breakRetries,
+		MaxErrors:      maxErrors,
+		Top:            top,
+		Reuse:          reuse,
+		IgnoreFile:     ignoreFile,
+		Strength:       strength,
+		Analyze:        analyzeComp,
+		PolicyFile:     policyFile,
+		Variants:       checkVariant,
+		Suggest:        suggest,
+		SuggestLength:  suggestLen,
+		SuggestCharset: suggestChars,
+		SuggestWords:   suggestWords,
+		Normalize:      normalize,
+		Encoding:       encoding,
+		InputFormat:    inputFormat,
+		SecretKeys:     secretKeys,
+		GroupBy:        groupBy,
+		GroupMap:       groupMap,
+		Tags:           tags,
+		NoColor:        noColor,
+		Lang:           lang,
+		OutputFile:     outputFile,
+		ReportFile:     reportFile,
+		ExportBad:      exportBad,
+		HistoryDB:      historyDB,
+		Syslog:         syslogTarget,
+		Webhook:        webhook,
+		WebhookFormat:  webhookFmt,
+		SMTPServer:     smtpServer,
+		SMTPUser:       smtpUser,
+		SMTPPassword:   os.Getenv("PWNEDCHECK_SMTP_PASSWORD"),
+		MailFrom:       mailFrom,
+		MailTo:         mailTo,
+		CacheTTL:       cacheTTL,
+		RPS:            rps,
+		Timeout:        timeout,
+		Deadline:       deadline,
+		Pins:           pins,
+		TLS:            tlsConfig,
+		Args:           flag.Args(),
 	}
 
 	os.Exit(checker.Run(cfg))
 }
+
+// stringList collects every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// parseHeaders turns "Name: value" pairs into a header set. Values are
+// environment-expanded so tokens can stay out of shell history.
+func parseHeaders(raw []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, h := range raw {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+		headers.Add(strings.TrimSpace(name), os.ExpandEnv(strings.TrimSpace(value)))
+	}
+	return headers, nil
+}
+
+// parsePins decodes base64 SHA-256 SPKI hashes, as printed by openssl or
+// curl's --pinnedpubkey, with or without curl's "sha256//" prefix.
+func parsePins(raw []string) ([][]byte, error) {
+	var pins [][]byte
+	for _, p := range raw {
+		pin, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(p), "sha256//"))
+		if err != nil || len(pin) != sha256.Size {
+			return nil, fmt.Errorf("invalid --pin-sha256 %q, expected a base64 SHA-256 hash", p)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// loadTLSConfig returns nil unless the defaults are overridden. A CA file
+// adds to the system roots rather than replacing them. The client
+// certificate is presented to whichever end asks for one, the egress proxy
+// or the server.
+func loadTLSConfig(caFile, certFile, keyFile string, insecure bool) (*tls.Config, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("--client-cert and --client-key must be given together")
+	}
+	if caFile == "" && certFile == "" && !insecure {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: insecure}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// parseTags accepts key=value pairs; a repeated key keeps its last value.
+func parseTags(raw []string) ([]checker.Tag, error) {
+	var tags []checker.Tag
+	for _, t := range raw {
+		key, value, ok := strings.Cut(t, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q, expected key=value", t)
+		}
+		if i := slices.IndexFunc(tags, func(tag checker.Tag) bool { return tag.Key == key }); i >= 0 {
+			tags[i].Value = value
+			continue
+		}
+		tags = append(tags, checker.Tag{Key: key, Value: value})
+	}
+	return tags, nil
+}
+
+func verbosity(verbose, veryVerbose bool) int {
+	switch {
+	case veryVerbose:
+		return 2
+	case verbose:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runProxy(args []string) int {
+	fs := flag.NewFlagSet("proxy", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck proxy [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Serves the HIBP /range/{prefix} API, answering from a shared cache and\n")
+		fmt.Fprintf(os.Stderr, "forwarding misses to HIBP under one rate limit. /healthz and /readyz report\n")
+		fmt.Fprintf(os.Stderr, "liveness and readiness to load balancers and Kubernetes, and /openapi.json\n")
+		fmt.Fprintf(os.Stderr, "describes the endpoints for client generators. / serves a web page for checking\n")
+		fmt.Fprintf(os.Stderr, "passwords, hashed in the browser, and listing the runs of --history-db.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --listen <addr>      Address to listen on (default \"localhost:8080\")\n")
+		fmt.Fprintf(os.Stderr, "      --cache-ttl <dur>    How long a fetched range is served before it is revalidated (default 1h)\n")
+		fmt.Fprintf(os.Stderr, "      --cache-entries <n>  Maximum number of cached ranges, roughly 70 KB each (default 4096)\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum requests per second sent to HIBP, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --pin-sha256 <hash>  Require HIBP to present this base64 SHA-256 public key (SPKI) hash (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --ca-cert <file>     Also trust the CA certificates in this PEM file\n")
+		fmt.Fprintf(os.Stderr, "      --client-cert <file> PEM client certificate for mutual TLS upstream (needs --client-key)\n")
+		fmt.Fprintf(os.Stderr, "      --client-key <file>  PEM private key for --client-cert\n")
+		fmt.Fprintf(os.Stderr, "      --history-db <file>  List the runs recorded in this database, e.g. by a -every audit, on the web page\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each upstream request to stderr\n")
+		fmt.Fprintf(os.Stderr, "  -vv                      Also log cache hits\n")
+	}
+
+	var (
+		listen       string
+		cacheTTL     time.Duration
+		cacheEntries int
+		rps          float64
+		timeout      time.Duration
+		rawPins      stringList
+		caCert       string
+		clientCert   string
+		clientKey    string
+		historyDB    string
+		verbose      bool
+		veryVerbose  bool
+	)
+	fs.StringVar(&listen, "listen", "localhost:8080", "")
+	fs.DurationVar(&cacheTTL, "cache-ttl", time.Hour, "")
+	fs.IntVar(&cacheEntries, "cache-entries", 4096, "")
+	fs.Float64Var(&rps, "rps", 10, "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.Var(&rawPins, "pin-sha256", "")
+	fs.StringVar(&caCert, "ca-cert", "", "")
+	fs.StringVar(&clientCert, "client-cert", "", "")
+	fs.StringVar(&clientKey, "client-key", "", "")
+	fs.StringVar(&historyDB, "history-db", "", "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.BoolVar(&veryVerbose, "vv", false, "")
+	fs.Parse(args)
+
+	if cacheTTL <= 0 || cacheEntries <= 0 {
+		fmt.Fprintf(os.Stderr, "--cache-ttl and --cache-entries must be positive; the proxy is only useful with a cache\n")
+		return 2
+	}
+	if rps < 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--rps must not be negative and --timeout must be positive\n")
+		return 2
+	}
+	pins, err := parsePins(rawPins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	tlsConfig, err := loadTLSConfig(caCert, clientCert, clientKey, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	return checker.Proxy(checker.Config{
+		Listen:       listen,
+		CacheTTL:     cacheTTL,
+		CacheEntries: cacheEntries,
+		RPS:          rps,
+		Timeout:      timeout,
+		Pins:         pins,
+		TLS:          tlsConfig,
+		HistoryDB:    historyDB,
+		Verbosity:    verbosity(verbose, veryVerbose),
+	})
+}
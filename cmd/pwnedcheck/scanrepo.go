@@ -0,0 +1,123 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runScanRepo(args []string) int {
+	fs := flag.NewFlagSet("scan-repo", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck scan-repo [options] [path]\n\n")
+		fmt.Fprintf(os.Stderr, "Scans the tracked and untracked, not ignored, files of a git working tree\n")
+		fmt.Fprintf(os.Stderr, "(default \".\") for passwords in configuration files and checks them against\n")
+		fmt.Fprintf(os.Stderr, "HIBP. Findings name their file and line; use --format sarif for code scanning.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --history            Also scan the lines every commit on every ref added\n")
+		fmt.Fprintf(os.Stderr, "      --rules <file>       YAML rules replacing the built-in assignment and URL rules\n")
+		fmt.Fprintf(os.Stderr, "      --hide               Hide plaintext passwords in output\n")
+		fmt.Fprintf(os.Stderr, "      --mask               Show only the first and last character of passwords\n")
+		fmt.Fprintf(os.Stderr, "      --format <string>    Per-result output format: text, table, csv, json, markdown, sarif or junit (default \"text\")\n")
+		fmt.Fprintf(os.Stderr, "      --fields <list>      Comma-separated result columns (default \"item,source,line,account,status,count\")\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output <file>      Write machine-readable results to a file; format from -format or the extension\n")
+		fmt.Fprintf(os.Stderr, "      --min-count <n>      Treat passwords seen fewer than n times in breaches as acceptable\n")
+		fmt.Fprintf(os.Stderr, "      --fail-threshold <n> Exit 0 unless more than n compromised passwords are found (default 0)\n")
+		fmt.Fprintf(os.Stderr, "  -s, --stats              Show runtime and result summary after completion\n")
+		fmt.Fprintf(os.Stderr, "      --group-by <dim>     Also break the summary down by account or source (the file)\n")
+		fmt.Fprintf(os.Stderr, "  -q, --quiet              Suppress per-password output; only the -stats summary and the exit code remain\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum HIBP requests per second, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout <dur>      Timeout for each HIBP request (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable ANSI colors\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each HIBP request to stderr\n")
+	}
+
+	var (
+		history    bool
+		rulesFile  string
+		hide       bool
+		mask       bool
+		format     string
+		fields     string
+		outputFile string
+		minCount   int
+		failThresh int
+		showStats  bool
+		groupBy    string
+		quiet      bool
+		rps        float64
+		timeout    time.Duration
+		noColor    bool
+		verbose    bool
+	)
+	fs.BoolVar(&history, "history", false, "")
+	fs.StringVar(&rulesFile, "rules", "", "")
+	fs.BoolVar(&hide, "hide", false, "")
+	fs.BoolVar(&mask, "mask", false, "")
+	fs.StringVar(&format, "format", "text", "")
+	fs.StringVar(&fields, "fields", "item,source,line,account,status,count", "")
+	fs.StringVar(&outputFile, "o", "", "")
+	fs.StringVar(&outputFile, "output", "", "")
+	fs.IntVar(&minCount, "min-count", 0, "")
+	fs.IntVar(&failThresh, "fail-threshold", 0, "")
+	fs.BoolVar(&showStats, "s", false, "")
+	fs.BoolVar(&showStats, "stats", false, "")
+	fs.StringVar(&groupBy, "group-by", "", "")
+	fs.BoolVar(&quiet, "q", false, "")
+	fs.BoolVar(&quiet, "quiet", false, "")
+	fs.Float64Var(&rps, "rps", 10, "")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	// flags may follow the path too
+	path := "."
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "scan-repo takes a single path\n")
+		return 2
+	}
+	if minCount < 0 || failThresh < 0 {
+		fmt.Fprintf(os.Stderr, "--fail-threshold and --min-count must not be negative\n")
+		return 2
+	}
+	if rps < 0 || timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "--timeout must be positive and --rps must not be negative\n")
+		return 2
+	}
+
+	return checker.Run(checker.Config{
+		RepoPath:      path,
+		RepoHistory:   history,
+		RulesFile:     rulesFile,
+		HidePassword:  hide,
+		MaskPassword:  mask,
+		Format:        format,
+		Fields:        fields,
+		OutputFile:    outputFile,
+		MinCount:      minCount,
+		FailThreshold: failThresh,
+		ShowStats:     showStats,
+		GroupBy:       groupBy,
+		Quiet:         quiet,
+		RPS:           rps,
+		Timeout:       timeout,
+		NoColor:       noColor,
+		Verbosity:     verbosity(verbose, false),
+	})
+}
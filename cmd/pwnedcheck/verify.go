@@ -0,0 +1,120 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mohamedation/PwnedCheck/internal/checker"
+)
+
+func runVerifyFix(args []string) int {
+	fs := flag.NewFlagSet("verify-fix", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pwnedcheck verify-fix -from report.json [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Re-checks only the entries flagged as pwned in a prior -format json report\n")
+		fmt.Fprintf(os.Stderr, "and reports which are now fixed, removed, or still pwned.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "      --from <file>        Prior JSON report (required)\n")
+		fmt.Fprintf(os.Stderr, "  -i, --input <string>     Current input to match findings against; without it the report's hashes are re-checked\n")
+		fmt.Fprintf(os.Stderr, "  -bw, --bitwarden         Treat the current input as a Bitwarden encrypted export\n")
+		fmt.Fprintf(os.Stderr, "  -H, --hashed             Current input contains SHA-1 hashes instead of plaintext\n")
+		fmt.Fprintf(os.Stderr, "      --input-format <name> Current input line layout, as for the check that wrote the report (default \"auto\")\n")
+		fmt.Fprintf(os.Stderr, "      --encoding <name>    Current input text encoding: auto, utf8, utf16le, utf16be, latin1 or cp1252 (default \"auto\")\n")
+		fmt.Fprintf(os.Stderr, "      --ntlm               Check NTLM hashes against the NTLM corpus instead of SHA-1\n")
+		fmt.Fprintf(os.Stderr, "      --format <string>    Closure report format: text or json (default \"text\")\n")
+		fmt.Fprintf(os.Stderr, "      --rps <n>            Maximum HIBP requests per second, 0 disables the limit (default 10)\n")
+		fmt.Fprintf(os.Stderr, "      --pin-sha256 <hash>  Require HIBP to present this base64 SHA-256 public key (SPKI) hash (repeatable)\n")
+		fmt.Fprintf(os.Stderr, "      --ca-cert <file>     Also trust the CA certificates in this PEM file\n")
+		fmt.Fprintf(os.Stderr, "      --no-color           Disable ANSI colors\n")
+		fmt.Fprintf(os.Stderr, "  -v, --verbose            Log each HIBP request to stderr\n")
+	}
+
+	var (
+		from        string
+		inputFile   string
+		bitwarden   bool
+		hashed      bool
+		inputFormat string
+		encoding    string
+		ntlm        bool
+		format      string
+		rps         float64
+		rawPins     stringList
+		caCert      string
+		noColor     bool
+		verbose     bool
+	)
+	fs.StringVar(&from, "from", "", "")
+	fs.StringVar(&inputFile, "i", "", "")
+	fs.StringVar(&inputFile, "input", "", "")
+	fs.BoolVar(&bitwarden, "bw", false, "")
+	fs.BoolVar(&bitwarden, "bitwarden", false, "")
+	fs.BoolVar(&hashed, "H", false, "")
+	fs.BoolVar(&hashed, "hashed", false, "")
+	fs.StringVar(&inputFormat, "input-format", "auto", "")
+	fs.StringVar(&encoding, "encoding", "auto", "")
+	fs.BoolVar(&ntlm, "ntlm", false, "")
+	fs.StringVar(&format, "format", "text", "")
+	fs.Float64Var(&rps, "rps", 10, "")
+	fs.Var(&rawPins, "pin-sha256", "")
+	fs.StringVar(&caCert, "ca-cert", "", "")
+	fs.BoolVar(&noColor, "no-color", false, "")
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	fs.Parse(args)
+
+	if from == "" {
+		fs.Usage()
+		return 2
+	}
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (available: text, json)\n", format)
+		return 2
+	}
+	if rps < 0 {
+		fmt.Fprintf(os.Stderr, "--rps must not be negative\n")
+		return 2
+	}
+	if bitwarden && inputFile == "" {
+		fmt.Fprintf(os.Stderr, "--bitwarden requires --input\n")
+		return 2
+	}
+	if inputFormat != "auto" && inputFormat != "lines" && (bitwarden || inputFile == "") {
+		fmt.Fprintf(os.Stderr, "--input-format applies to the current input file and needs --input without --bitwarden\n")
+		return 2
+	}
+	pins, err := parsePins(rawPins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	tlsConfig, err := loadTLSConfig(caCert, "", "", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	return checker.VerifyFix(checker.Config{
+		VerifyFrom:  from,
+		InputFile:   inputFile,
+		Bitwarden:   bitwarden,
+		IsHashed:    hashed,
+		InputFormat: inputFormat,
+		Encoding:    encoding,
+		NTLM:        ntlm,
+		Format:      format,
+		RPS:         rps,
+		Pins:        pins,
+		TLS:         tlsConfig,
+		NoColor:     noColor,
+		Verbosity:   verbosity(verbose, false),
+	})
+}
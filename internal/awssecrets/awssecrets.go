@@ -0,0 +1,136 @@
+// Package awssecrets reads the secret values stored in AWS Secrets Manager
+// and SSM Parameter Store, with credentials from the usual SDK chain.
+package awssecrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Source reads secrets from one account and region.
+type Source struct {
+	sm  *secretsmanager.Client
+	ssm *ssm.Client
+	// Account is the ID of the account the credentials belong to.
+	Account string
+	Region  string
+}
+
+// Secret is one secret or SecureString parameter. Err is set when its value
+// could not be read, typically for lack of kms:Decrypt or
+// secretsmanager:GetSecretValue on it.
+type Secret struct {
+	ARN   string
+	Name  string
+	Value string
+	Err   error
+}
+
+// Open loads the SDK configuration, optionally for a named profile or an
+// explicit region, and resolves the caller's account.
+func Open(ctx context.Context, profile, region string, timeout time.Duration) (*Source, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(timeout)),
+	}
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("no AWS region configured; pass -region or set AWS_REGION")
+	}
+	id, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve AWS credentials: %w", err)
+	}
+	return &Source{
+		sm:      secretsmanager.NewFromConfig(cfg),
+		ssm:     ssm.NewFromConfig(cfg),
+		Account: aws.ToString(id.Account),
+		Region:  cfg.Region,
+	}, nil
+}
+
+// Secrets lists every Secrets Manager secret and reads its current string
+// value; binary secrets are left out.
+func (s *Source) Secrets(ctx context.Context) ([]Secret, error) {
+	var secrets []Secret
+	pages := secretsmanager.NewListSecretsPaginator(s.sm, &secretsmanager.ListSecretsInput{})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, e := range page.SecretList {
+			sec := Secret{ARN: aws.ToString(e.ARN), Name: aws.ToString(e.Name)}
+			out, err := s.sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: e.ARN})
+			switch {
+			case err != nil:
+				sec.Err = err
+			case out.SecretString == nil:
+				continue
+			default:
+				sec.Value = *out.SecretString
+			}
+			secrets = append(secrets, sec)
+		}
+	}
+	return secrets, nil
+}
+
+// getParametersMax is how many names one GetParameters call accepts.
+const getParametersMax = 10
+
+// Parameters lists the SecureString parameters under path, or every one
+// when it is "/", and reads their decrypted values.
+func (s *Source) Parameters(ctx context.Context, path string) ([]Secret, error) {
+	filters := []ssmtypes.ParameterStringFilter{
+		{Key: aws.String("Type"), Option: aws.String("Equals"), Values: []string{string(ssmtypes.ParameterTypeSecureString)}},
+	}
+	if path != "/" {
+		filters = append(filters, ssmtypes.ParameterStringFilter{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: []string{path}})
+	}
+	var names []string
+	pages := ssm.NewDescribeParametersPaginator(s.ssm, &ssm.DescribeParametersInput{ParameterFilters: filters})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SSM parameters: %w", err)
+		}
+		for _, p := range page.Parameters {
+			names = append(names, aws.ToString(p.Name))
+		}
+	}
+
+	var params []Secret
+	for batch := range slices.Chunk(names, getParametersMax) {
+		out, err := s.ssm.GetParameters(ctx, &ssm.GetParametersInput{Names: batch, WithDecryption: aws.Bool(true)})
+		if err != nil {
+			for _, name := range batch {
+				params = append(params, Secret{Name: name, Err: err})
+			}
+			continue
+		}
+		for _, p := range out.Parameters {
+			params = append(params, Secret{ARN: aws.ToString(p.ARN), Name: aws.ToString(p.Name), Value: aws.ToString(p.Value)})
+		}
+	}
+	return params, nil
+}
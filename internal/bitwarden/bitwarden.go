@@ -27,10 +27,15 @@ type BitwardenEncryptedExport struct {
 }
 
 type BitwardenDecryptedSchema struct {
+	Folders []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
 	Items []struct {
-		Type  int    `json:"type"`
-		Name  string `json:"name"`
-		Login *struct {
+		Type     int    `json:"type"`
+		Name     string `json:"name"`
+		FolderID string `json:"folderId"`
+		Login    *struct {
 			Username string `json:"username"`
 			Password string `json:"password"`
 		} `json:"login"`
@@ -42,6 +47,8 @@ type VaultEntry struct {
 	AccountName string
 	Username    string
 	Password    string
+	// Folder is the vault folder's name, empty for items outside folders.
+	Folder string
 }
 
 // extraction
@@ -130,6 +137,8 @@ func ExtractEntries(filePath string, exportPassword string) ([]VaultEntry, error
 	}
 
 	plaintext := make([]byte, len(cipherBytes))
+	// the decrypted vault holds every password; don't leave it to the GC
+	defer clear(plaintext)
 	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plaintext, cipherBytes)
 
 	plaintext, err = pkcs7Unpad(plaintext, aes.BlockSize)
@@ -142,6 +151,10 @@ func ExtractEntries(filePath string, exportPassword string) ([]VaultEntry, error
 		return nil, fmt.Errorf("failed to parse decrypted vault: %w", err)
 	}
 
+	folders := make(map[string]string, len(decryptedData.Folders))
+	for _, f := range decryptedData.Folders {
+		folders[f.ID] = f.Name
+	}
 	var entries []VaultEntry
 	for _, item := range decryptedData.Items {
 		if item.Type == 1 && item.Login != nil && item.Login.Password != "" {
@@ -149,6 +162,7 @@ func ExtractEntries(filePath string, exportPassword string) ([]VaultEntry, error
 				AccountName: item.Name,
 				Username:    item.Login.Username,
 				Password:    item.Login.Password,
+				Folder:      folders[item.FolderID],
 			})
 		}
 	}
@@ -0,0 +1,137 @@
+package bloom
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+var magic = [4]byte{'P', 'C', 'B', 'F'}
+
+const version = 1
+
+// Filter is a Bloom filter keyed by SHA-1 digests. Since the keys are already
+// uniformly distributed, the bit positions are derived directly from the
+// digest bytes with double hashing instead of rehashing.
+type Filter struct {
+	m    uint64
+	k    uint32
+	bits []uint64
+}
+
+// New sizes a filter for n items at the given false-positive rate.
+func New(n int, fpRate float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 1e-6
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) &^ 63
+	k := uint32(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &Filter{m: m, k: k, bits: make([]uint64, m/64)}
+}
+
+func (f *Filter) Add(digest [20]byte) {
+	h1, h2 := split(digest)
+	for i := uint32(0); i < f.k; i++ {
+		pos := (h1 + uint64(i)*h2) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+func (f *Filter) Test(digest [20]byte) bool {
+	h1, h2 := split(digest)
+	for i := uint32(0); i < f.k; i++ {
+		pos := (h1 + uint64(i)*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestHex reports whether a 40-character hex SHA-1 may be in the filter.
+// Anything that does not decode to a digest is reported as absent.
+func (f *Filter) TestHex(hash string) bool {
+	digest, ok := ParseHex(hash)
+	return ok && f.Test(digest)
+}
+
+func (f *Filter) SizeBytes() int {
+	return len(f.bits) * 8
+}
+
+func ParseHex(hash string) ([20]byte, bool) {
+	var digest [20]byte
+	if len(hash) != 40 {
+		return digest, false
+	}
+	if _, err := hex.Decode(digest[:], []byte(hash)); err != nil {
+		return digest, false
+	}
+	return digest, true
+}
+
+func split(d [20]byte) (uint64, uint64) {
+	h1 := binary.LittleEndian.Uint64(d[0:8])
+	h2 := binary.LittleEndian.Uint64(d[8:16]) | 1
+	return h1, h2
+}
+
+// WriteTo writes the filter as: magic, version byte, k (uint32), m (uint64),
+// then the bit array, all little-endian.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 0, 17)
+	header = append(header, magic[:]...)
+	header = append(header, version)
+	header = binary.LittleEndian.AppendUint32(header, f.k)
+	header = binary.LittleEndian.AppendUint64(header, f.m)
+	if _, err := bw.Write(header); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 8)
+	for _, word := range f.bits {
+		binary.LittleEndian.PutUint64(buf, word)
+		if _, err := bw.Write(buf); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(header) + len(f.bits)*8), bw.Flush()
+}
+
+func Unmarshal(data []byte) (*Filter, error) {
+	if len(data) < 17 || [4]byte(data[:4]) != magic {
+		return nil, errors.New("not a PwnedCheck bloom filter file")
+	}
+	if data[4] != version {
+		return nil, fmt.Errorf("unsupported bloom filter version %d", data[4])
+	}
+	k := binary.LittleEndian.Uint32(data[5:9])
+	m := binary.LittleEndian.Uint64(data[9:17])
+	body := data[17:]
+	if k == 0 || m == 0 || m%64 != 0 || uint64(len(body)) != m/8 {
+		return nil, errors.New("corrupted bloom filter file")
+	}
+
+	f := &Filter{m: m, k: k, bits: make([]uint64, m/64)}
+	for i := range f.bits {
+		f.bits[i] = binary.LittleEndian.Uint64(body[i*8:])
+	}
+	return f, nil
+}
+
+func Load(path string) (*Filter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
@@ -0,0 +1,88 @@
+package bloom
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"strconv"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	f := New(1000, 0.001)
+	for i := range 1000 {
+		f.Add(sha1.Sum([]byte(strconv.Itoa(i))))
+	}
+	var buf bytes.Buffer
+	if n, err := f.WriteTo(&buf); err != nil || n != int64(buf.Len()) || n != int64(17+f.SizeBytes()) {
+		t.Fatalf("WriteTo = %d, %v; want %d bytes written, nil", n, err, 17+f.SizeBytes())
+	}
+
+	loaded, err := Unmarshal(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 1000 {
+		if !loaded.Test(sha1.Sum([]byte(strconv.Itoa(i)))) {
+			t.Fatalf("loaded filter lost item %d", i)
+		}
+	}
+	fp := 0
+	for i := 1000; i < 11000; i++ {
+		if loaded.Test(sha1.Sum([]byte(strconv.Itoa(i)))) {
+			fp++
+		}
+	}
+	// 10 expected at 0.001; leave room for chance
+	if fp > 40 {
+		t.Errorf("%d false positives in 10000, want about 10", fp)
+	}
+}
+
+func TestTestHex(t *testing.T) {
+	f := New(10, 0.001)
+	digest := sha1.Sum([]byte("password"))
+	f.Add(digest)
+	for _, tc := range []struct {
+		hash string
+		want bool
+	}{
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", true},
+		{"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8", true},
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD", false},
+		{"ZBAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", false},
+		{"", false},
+	} {
+		if got := f.TestHex(tc.hash); got != tc.want {
+			t.Errorf("TestHex(%q) = %v, want %v", tc.hash, got, tc.want)
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	New(10, 0.01).WriteTo(&buf)
+	good := buf.Bytes()
+	with := func(i int, b byte) []byte {
+		data := bytes.Clone(good)
+		data[i] = b
+		return data
+	}
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", good[:16]},
+		{"bad magic", with(0, 'X')},
+		{"newer version", with(4, version+1)},
+		{"zero k", append([]byte{'P', 'C', 'B', 'F', version, 0, 0, 0, 0}, good[9:]...)},
+		{"truncated bits", good[:len(good)-8]},
+		{"trailing bytes", append(bytes.Clone(good), 0)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if f, err := Unmarshal(tc.data); err == nil {
+				t.Fatalf("Unmarshal = %v, nil; want an error", f)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2026 mohamedation
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// mkstarter builds the starter bloom filter embedded by the starterbloom
+// build tag. The source list holds one plaintext password, SHA-1 hash, or
+// HASH:COUNT line per entry, most common first; only the first -n are kept.
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+func main() {
+	var (
+		src    string
+		out    string
+		limit  int
+		fpRate float64
+	)
+	flag.StringVar(&src, "i", "", "source list, most common first")
+	flag.StringVar(&out, "o", "internal/bloom/starter.bloom", "output filter file")
+	flag.IntVar(&limit, "n", 1000000, "number of entries to keep")
+	flag.Float64Var(&fpRate, "fp", 1e-6, "target false-positive rate")
+	flag.Parse()
+
+	if src == "" {
+		fmt.Fprintln(os.Stderr, "mkstarter: -i is required")
+		os.Exit(2)
+	}
+
+	r, err := input.Open(src, input.Options{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mkstarter: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	var digests [][20]byte
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() && len(digests) < limit {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		digest, ok := bloom.ParseHex(hash)
+		if !ok {
+			digest = sha1.Sum([]byte(line))
+		}
+		digests = append(digests, digest)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "mkstarter: %v\n", err)
+		os.Exit(1)
+	}
+
+	f := bloom.New(len(digests), fpRate)
+	for _, d := range digests {
+		f.Add(d)
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mkstarter: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	if _, err := f.WriteTo(file); err != nil {
+		fmt.Fprintf(os.Stderr, "mkstarter: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d entries to %s (%d bytes)\n", len(digests), out, f.SizeBytes())
+}
@@ -0,0 +1,26 @@
+//go:build starterbloom
+
+package bloom
+
+import (
+	_ "embed"
+	"sync"
+)
+
+// starter.bloom is generated with `make starter-bloom` and is not committed,
+// so building or vetting with -tags starterbloom fails with "pattern
+// starter.bloom: no matching files found" until it exists; `make
+// build-starter STARTER_SRC=...` generates it first.
+//
+//go:embed starter.bloom
+var starterData []byte
+
+var starter = sync.OnceValues(func() (*Filter, error) {
+	return Unmarshal(starterData)
+})
+
+// Starter returns the filter of the most common pwned passwords embedded at
+// build time.
+func Starter() (*Filter, error) {
+	return starter()
+}
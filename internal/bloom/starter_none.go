@@ -0,0 +1,8 @@
+//go:build !starterbloom
+
+package bloom
+
+// Starter returns nil when the binary was built without the starterbloom tag.
+func Starter() (*Filter, error) {
+	return nil, nil
+}
@@ -0,0 +1,148 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// composition is the local analysis of one password for -analyze.
+type composition struct {
+	Length  int
+	Classes []string
+	// Entropy is the Shannon entropy of the password's own characters, in
+	// bits for the whole string.
+	Entropy float64
+}
+
+func analyze(password string) composition {
+	runes := []rune(password)
+	var lower, upper, digit, symbol bool
+	freq := make(map[rune]int, len(runes))
+	for _, r := range runes {
+		freq[r]++
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	c := composition{Length: len(runes)}
+	for _, cl := range []struct {
+		name string
+		ok   bool
+	}{{"lower", lower}, {"upper", upper}, {"digit", digit}, {"symbol", symbol}} {
+		if cl.ok {
+			c.Classes = append(c.Classes, cl.name)
+		}
+	}
+
+	var perChar float64
+	for _, n := range freq {
+		p := float64(n) / float64(len(runes))
+		perChar -= p * math.Log2(p)
+	}
+	c.Entropy = math.Round(perChar*float64(len(runes))*10) / 10
+	return c
+}
+
+// analysis aggregates compositions across a run.
+type analysis struct {
+	lengths   []int
+	entropies []float64
+	// classes counts passwords by how many character classes they use.
+	classes [5]int
+}
+
+func (a *analysis) add(c composition) {
+	a.lengths = append(a.lengths, c.Length)
+	a.entropies = append(a.entropies, c.Entropy)
+	a.classes[len(c.Classes)]++
+}
+
+type analysisSummary struct {
+	Analyzed      int            `json:"analyzed"`
+	LengthMin     int            `json:"length_min"`
+	LengthMedian  int            `json:"length_median"`
+	LengthMean    float64        `json:"length_mean"`
+	LengthMax     int            `json:"length_max"`
+	Lengths       map[string]int `json:"lengths"`
+	Classes       map[string]int `json:"classes"`
+	EntropyMedian float64        `json:"entropy_median"`
+	EntropyMean   float64        `json:"entropy_mean"`
+}
+
+var lengthBuckets = []struct {
+	label string
+	max   int
+}{{"0-7", 7}, {"8-11", 11}, {"12-15", 15}, {"16+", math.MaxInt}}
+
+func (a *analysis) summary() *analysisSummary {
+	n := len(a.lengths)
+	if n == 0 {
+		return nil
+	}
+	lengths := slices.Sorted(slices.Values(a.lengths))
+	entropies := slices.Sorted(slices.Values(a.entropies))
+
+	s := &analysisSummary{
+		Analyzed:      n,
+		LengthMin:     lengths[0],
+		LengthMedian:  lengths[n/2],
+		LengthMax:     lengths[n-1],
+		Lengths:       make(map[string]int, len(lengthBuckets)),
+		Classes:       make(map[string]int, len(a.classes)),
+		EntropyMedian: entropies[n/2],
+	}
+	var lengthSum int
+	var entropySum float64
+	for i := range n {
+		lengthSum += lengths[i]
+		entropySum += entropies[i]
+	}
+	s.LengthMean = math.Round(float64(lengthSum)/float64(n)*10) / 10
+	s.EntropyMean = math.Round(entropySum/float64(n)*10) / 10
+
+	for _, b := range lengthBuckets {
+		s.Lengths[b.label] = 0
+	}
+	for _, l := range lengths {
+		for _, b := range lengthBuckets {
+			if l <= b.max {
+				s.Lengths[b.label]++
+				break
+			}
+		}
+	}
+	for k := 1; k < len(a.classes); k++ {
+		s.Classes[fmt.Sprint(k)] = a.classes[k]
+	}
+	return s
+}
+
+func (s *analysisSummary) print(w io.Writer) {
+	fmt.Fprintf(w, "\nComposition of %d passwords:\n", s.Analyzed)
+	fmt.Fprintf(w, "  Length:  min %d, median %d, mean %.1f, max %d\n", s.LengthMin, s.LengthMedian, s.LengthMean, s.LengthMax)
+	for _, b := range lengthBuckets {
+		fmt.Fprintf(w, "    %-6s %s\n", b.label, distBar(s.Lengths[b.label], s.Analyzed))
+	}
+	fmt.Fprintf(w, "  Character classes used:\n")
+	for k := 1; k <= 4; k++ {
+		fmt.Fprintf(w, "    %-6d %s\n", k, distBar(s.Classes[fmt.Sprint(k)], s.Analyzed))
+	}
+	fmt.Fprintf(w, "  Entropy: median %.1f bits, mean %.1f bits\n", s.EntropyMedian, s.EntropyMean)
+}
+
+func distBar(n, total int) string {
+	pct := n * 100 / total
+	return fmt.Sprintf("%-20s %5d (%d%%)", strings.Repeat("#", pct/5), n, pct)
+}
@@ -0,0 +1,85 @@
+package checker
+
+import (
+	"cmp"
+	"context"
+	"regexp"
+	"slices"
+
+	"github.com/mohamedation/PwnedCheck/internal/awssecrets"
+)
+
+// AWSServices lists the values accepted for -service.
+var AWSServices = []string{"secretsmanager", "ssm"}
+
+// openAWS resolves the account before the outputs are opened, so every
+// report carries it and the region as tags.
+func openAWS(cfg *Config) (*awssecrets.Source, error) {
+	src, err := awssecrets.Open(context.Background(), cfg.AWSProfile, cfg.AWSRegion, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Tags = append(slices.Clone(cfg.Tags), Tag{Key: "aws_account", Value: src.Account}, Tag{Key: "aws_region", Value: src.Region})
+	return src, nil
+}
+
+// runAWS checks the string values of Secrets Manager secrets and SSM
+// SecureString parameters that look like passwords. Entries are named by
+// ARN, with #key for the fields of JSON secrets; values are never shown.
+func runAWS(r *runner, src *awssecrets.Source) int {
+	ctx := context.Background()
+	var secrets []awssecrets.Secret
+	if slices.Contains(r.cfg.AWSServices, "secretsmanager") {
+		list, err := src.Secrets(ctx)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		secrets = append(secrets, list...)
+	}
+	if slices.Contains(r.cfg.AWSServices, "ssm") {
+		list, err := src.Parameters(ctx, r.cfg.AWSPath)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		secrets = append(secrets, list...)
+	}
+
+	var entries []entry
+	unreadable := 0
+	for _, s := range secrets {
+		if s.Err != nil {
+			unreadable++
+			r.client.Logger().Warn("secret value unreadable", "name", s.Name, "err", s.Err)
+			continue
+		}
+		entries = append(entries, awsSecretEntries(s, r.secretKeys)...)
+	}
+	if unreadable > 0 {
+		r.notef("%sCould not read %d secrets; -v names them.%s\n", colorYellow, unreadable, colorReset)
+	}
+	if len(entries) == 0 {
+		r.notef("%sNo password-like values in %d secrets.%s\n", colorYellow, len(secrets), colorReset)
+		return r.finish()
+	}
+	r.notef("Found %d password-like values in %d secrets (account %s, %s).\n\n", len(entries), len(secrets), src.Account, src.Region)
+
+	r.cfg.IsHashed, r.cfg.HidePassword = false, true
+	r.source, r.style = "aws", styleList
+	if code := r.run(entries); code != exitOK {
+		return code
+	}
+	return r.finish()
+}
+
+// awsSecretEntries checks key/value secrets field by field and other
+// secrets whole.
+func awsSecretEntries(s awssecrets.Secret, keys *regexp.Regexp) []entry {
+	name := cmp.Or(s.ARN, s.Name)
+	if entries, ok := jsonSecretEntries(s.Value, name, keys); ok {
+		return entries
+	}
+	if !isPasswordValue([]byte(s.Value)) {
+		return nil
+	}
+	return []entry{{Password: s.Value, Account: name}}
+}
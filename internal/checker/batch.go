@@ -0,0 +1,82 @@
+package checker
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// Reasons a run stops before its last entry.
+const (
+	haltBudget    = "budget"
+	haltDeadline  = "deadline"
+	haltInterrupt = "interrupt"
+	haltErrors    = "errors"
+	haltOutage    = "outage"
+)
+
+// batchResult is the -batch answer for one entry; done is false for
+// entries the lookups never reached.
+type batchResult struct {
+	res  Result
+	err  error
+	done bool
+}
+
+// batchLookups hashes every entry first and answers what the index, the
+// filters and the cache can, as Check would. It then requests each
+// remaining range once, in prefix order, so entries sharing a prefix cost
+// one request however far apart they are and however small the cache is.
+// The results come back in entry order. It stops early when halt gives a
+// reason, which it returns.
+func (r *runner) batchLookups(entries []entry, halt func() string, bar *progress) ([]batchResult, string) {
+	results := make([]batchResult, len(entries))
+	hashes := make([]string, len(entries))
+	order := make([]int, 0, len(entries))
+	for i, e := range entries {
+		hash, err := r.client.Hash(e.Password, r.cfg.IsHashed)
+		if err != nil {
+			results[i] = batchResult{err: err, done: true}
+			continue
+		}
+		hashes[i] = hash
+		if res, ok, err := r.client.Known(hash); ok {
+			results[i] = batchResult{res: res, err: err, done: true}
+			continue
+		}
+		order = append(order, i)
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return strings.Compare(hashes[a][:5], hashes[b][:5]) })
+
+	resolved := len(entries) - len(order)
+	for len(order) > 0 {
+		if why := halt(); why != "" {
+			return results, why
+		}
+		prefix := hashes[order[0]][:5]
+		n := 1
+		for n < len(order) && hashes[order[n]][:5] == prefix {
+			n++
+		}
+		var suffixes map[string]int
+		var err error
+		if why := r.guard(halt, bar, func() error {
+			suffixes, err = r.client.Range(prefix, r.cfg.NTLM)
+			return err
+		}); why != "" {
+			return results, why
+		}
+		for _, i := range order[:n] {
+			res := Result{Hash: hashes[i]}
+			if err == nil {
+				res.Pwned, res.Count = hibp.Match(suffixes, hashes[i])
+			}
+			results[i] = batchResult{res: res, err: err, done: true}
+		}
+		order = order[n:]
+		resolved += n
+		bar.update(resolved, r.stats)
+	}
+	return results, ""
+}
@@ -0,0 +1,235 @@
+package checker
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/index"
+)
+
+// benchSpan is how long each local measurement runs.
+const benchSpan = time.Second
+
+// Bench measures what bounds a run on this machine: hashing, HIBP round
+// trips at increasing concurrency up to cfg.Workers, cache hits, and the
+// -bloom and -index lookups, then suggests -rps and download -workers
+// values. cfg.BenchRequests of 0 keeps it offline.
+func Bench(cfg Config) int {
+	w := os.Stdout
+	passwords := make([]string, 1024)
+	for i := range passwords {
+		passwords[i] = randomHex(6)
+	}
+
+	fmt.Fprintf(w, "Hashing (one core)\n")
+	fmt.Fprintf(w, "  SHA-1  %12.0f hashes/s\n", benchRate(func(i int) { hibp.HashPassword(passwords[i%len(passwords)]) }))
+	fmt.Fprintf(w, "  NTLM   %12.0f hashes/s\n", benchRate(func(i int) { hibp.HashNTLM(passwords[i%len(passwords)]) }))
+
+	var levels []benchLevel
+	var latency []time.Duration
+	if cfg.BenchRequests > 0 {
+		client := New(Options{Logger: newLogger(cfg.Verbosity), CacheTTL: time.Hour, Timeout: cfg.Timeout,
+			IdleConns: cfg.Workers, Pins: cfg.Pins, TLS: cfg.TLS})
+		defer client.Close()
+		prefixes := benchPrefixes(cfg.BenchRequests + benchSweepRequests(cfg))
+
+		// the sequential pass fills the cache for the hit measurement below
+		fmt.Fprintf(w, "\nHIBP round trips (%d sequential range requests)\n", cfg.BenchRequests)
+		var err error
+		if latency, err = benchFetch(client.Range, prefixes[:cfg.BenchRequests], 1); err != nil {
+			fmt.Fprintf(os.Stderr, "Range request failed: %v\n", err)
+			return exitError
+		}
+		slices.Sort(latency)
+		fmt.Fprintf(w, "  min %s  p50 %s  p90 %s  p99 %s  max %s\n", benchMs(latency[0]), benchMs(percentile(latency, 50)),
+			benchMs(percentile(latency, 90)), benchMs(percentile(latency, 99)), benchMs(latency[len(latency)-1]))
+
+		fmt.Fprintf(w, "\nHIBP throughput (uncached ranges)\n")
+		rest := prefixes[cfg.BenchRequests:]
+		for _, workers := range benchWorkerLevels(cfg.Workers) {
+			n := max(cfg.BenchRequests, 2*workers)
+			start := time.Now()
+			// past the cache, so every request is a real round trip
+			fetch := func(prefix string, ntlm bool) (map[string]int, error) {
+				suffixes, _, err := client.Fetch(prefix, ntlm, "")
+				return suffixes, err
+			}
+			if _, err := benchFetch(fetch, rest[:n], workers); err != nil {
+				fmt.Fprintf(os.Stderr, "Range request failed: %v\n", err)
+				return exitError
+			}
+			level := benchLevel{workers: workers, rate: float64(n) / time.Since(start).Seconds()}
+			fmt.Fprintf(w, "  %3d workers  %8.1f ranges/s\n", workers, level.rate)
+			levels = append(levels, level)
+			rest = rest[n:]
+		}
+
+		cached := prefixes[:cfg.BenchRequests]
+		suffixes := make([]string, len(passwords))
+		for i := range suffixes {
+			suffixes[i] = cached[i%len(cached)] + randomHex(18)[:35]
+		}
+		fmt.Fprintf(w, "\nCache hits\n")
+		fmt.Fprintf(w, "  %12.0f lookups/s\n", benchRate(func(i int) { client.Check(suffixes[i%len(suffixes)], true) }))
+	}
+
+	if cfg.BloomFile != "" || cfg.IndexFile != "" {
+		fmt.Fprintf(w, "\nOffline lookups\n")
+	}
+	if cfg.BloomFile != "" {
+		filter, err := bloom.Load(cfg.BloomFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load Bloom filter: %v\n", err)
+			return exitError
+		}
+		hashes := benchHashes(len(passwords), 20)
+		fmt.Fprintf(w, "  -bloom  %12.0f lookups/s\n", benchRate(func(i int) { filter.TestHex(hashes[i%len(hashes)]) }))
+	}
+	if cfg.IndexFile != "" {
+		idx, err := index.Open(cfg.IndexFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open index: %v\n", err)
+			return exitError
+		}
+		defer idx.Close()
+		size := 20
+		if idx.NTLM() {
+			size = 16
+		}
+		hashes := benchHashes(len(passwords), size)
+		fmt.Fprintf(w, "  -index  %12.0f lookups/s\n", benchRate(func(i int) { idx.LookupHex(hashes[i%len(hashes)]) }))
+	}
+
+	if cfg.BenchRequests > 0 {
+		p50, best := percentile(latency, 50), benchPick(levels)
+		fmt.Fprintf(w, "\nSuggestions\n")
+		fmt.Fprintf(w, "  Audits look up one uncached range at a time, about %.0f/s here; -rps only slows them below that.\n", float64(time.Second)/float64(p50))
+		corpus := time.Duration(float64(rangeCount) / best.rate * float64(time.Second)).Round(time.Minute)
+		fmt.Fprintf(w, "  download -workers %d fetched %.0f ranges/s, about %s for the whole corpus.\n", best.workers, best.rate, corpus)
+	}
+	return exitOK
+}
+
+type benchLevel struct {
+	workers int
+	rate    float64
+}
+
+// benchPick returns the fewest workers within 10% of the best throughput,
+// since more only add load.
+func benchPick(levels []benchLevel) benchLevel {
+	top := slices.MaxFunc(levels, func(a, b benchLevel) int { return cmp.Compare(a.rate, b.rate) })
+	for _, l := range levels {
+		if l.rate >= 0.9*top.rate {
+			return l
+		}
+	}
+	return top
+}
+
+// benchWorkerLevels doubles from one worker up to max.
+func benchWorkerLevels(maximum int) []int {
+	var levels []int
+	for n := 1; n < maximum; n *= 2 {
+		levels = append(levels, n)
+	}
+	return append(levels, maximum)
+}
+
+func benchSweepRequests(cfg Config) int {
+	n := 0
+	for _, workers := range benchWorkerLevels(cfg.Workers) {
+		n += max(cfg.BenchRequests, 2*workers)
+	}
+	return n
+}
+
+// benchFetch runs fetch over prefixes on the given number of workers and
+// returns each request's duration.
+func benchFetch(fetch func(string, bool) (map[string]int, error), prefixes []string, workers int) ([]time.Duration, error) {
+	took := make([]time.Duration, len(prefixes))
+	errs := make([]error, len(prefixes))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for i := range next {
+				start := time.Now()
+				_, errs[i] = fetch(prefixes[i], false)
+				took[i] = time.Since(start)
+			}
+		})
+	}
+	for i := range prefixes {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return took, nil
+}
+
+// benchRate calls op with increasing arguments for benchSpan and returns
+// the calls per second.
+func benchRate(op func(i int)) float64 {
+	start := time.Now()
+	n := 0
+	for time.Since(start) < benchSpan {
+		for range 1024 {
+			op(n)
+			n++
+		}
+	}
+	return float64(n) / time.Since(start).Seconds()
+}
+
+// benchPrefixes picks n distinct random range prefixes, so no request is
+// answered from a cache along the way.
+func benchPrefixes(n int) []string {
+	seen := make(map[int]bool, n)
+	prefixes := make([]string, 0, n)
+	for len(prefixes) < n {
+		p := rand.IntN(rangeCount)
+		if !seen[p] {
+			seen[p] = true
+			prefixes = append(prefixes, fmt.Sprintf("%05X", p))
+		}
+	}
+	return prefixes
+}
+
+func benchHashes(n, size int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = randomHex(size)
+	}
+	return hashes
+}
+
+// randomHex returns size random bytes as uppercase hex.
+func randomHex(size int) string {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte(rand.UintN(256))
+	}
+	return fmt.Sprintf("%X", b)
+}
+
+func percentile(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+func benchMs(d time.Duration) string {
+	return d.Round(100 * time.Microsecond).String()
+}
@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// Backoff of the circuit breaker: the first pause, and the longest.
+const (
+	breakerFirstPause = 5 * time.Second
+	breakerMaxPause   = 5 * time.Minute
+)
+
+// breaker counts lookups failing in a row for a cause that waiting may fix.
+// Once -breaker of them have failed it trips: the run stops dispatching and
+// retries the same lookup after a pause that doubles each time, instead of
+// turning every remaining line into an error while the network is down.
+// After -breaker-retries retries it gives up and the run ends.
+type breaker struct {
+	threshold int
+	retries   int
+	failures  int
+	retried   int
+	pause     time.Duration
+}
+
+// transient reports whether err is an outage rather than a bad entry.
+func transient(err error) bool {
+	return errors.Is(err, hibp.ErrOffline) || errors.Is(err, hibp.ErrTimeout) ||
+		errors.Is(err, hibp.ErrRateLimited) || errors.Is(err, hibp.ErrBadResponse)
+}
+
+// guard runs lookup under the breaker. It returns once lookup succeeded or
+// failed below the threshold, for the caller to record, or with the reason
+// halt gave while it was paused. Every failed attempt counts toward
+// -max-errors, retries included, so the budget still ends an outage the
+// breaker is riding out.
+func (r *runner) guard(halt func() string, bar *progress, lookup func() error) string {
+	b := &r.breaker
+	for {
+		err := lookup()
+		if transient(err) || errors.Is(err, hibp.ErrPinMismatch) {
+			r.apiErrors++
+		}
+		if b.threshold == 0 || !transient(err) {
+			if b.pause > 0 {
+				r.notef("%sThe API is answering again; resuming.%s\n", colorGreen, colorReset)
+			}
+			b.failures, b.retried, b.pause = 0, 0, 0
+			return ""
+		}
+		if b.failures++; b.failures < b.threshold {
+			return ""
+		}
+		if why := halt(); why != "" {
+			return why
+		}
+		if b.retries > 0 && b.retried == b.retries {
+			return haltOutage
+		}
+		b.retried++
+		b.pause = min(max(2*b.pause, breakerFirstPause), breakerMaxPause)
+		// wait at least as long as a 429 asked to
+		var status *hibp.StatusError
+		if errors.As(err, &status) && status.RetryAfter > b.pause {
+			b.pause = min(status.RetryAfter, breakerMaxPause)
+		}
+		r.stats.pauses++
+		bar.clear()
+		r.notef("%s%d lookups failed in a row (%v); pausing %s before retrying.%s\n", colorYellow, b.failures, err, b.pause, colorReset)
+		if why := waitOut(b.pause, halt); why != "" {
+			return why
+		}
+	}
+}
+
+// waitOut sleeps for d, returning early with the reason halt gives.
+func waitOut(d time.Duration, halt func() string) string {
+	end := time.Now().Add(d)
+	for time.Now().Before(end) {
+		if why := halt(); why != "" {
+			return why
+		}
+		time.Sleep(min(250*time.Millisecond, time.Until(end)))
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package checker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// bloomProgressEvery is how many lines pass between progress notes; the
+// full corpus has close to a billion.
+const bloomProgressEvery = 50_000_000
+
+// BuildBloom turns a downloaded SHA-1 corpus of HASH:COUNT lines into a
+// Bloom filter file for -bloom. Without cfg.BloomEntries the input is read
+// twice, once to size the filter. Hashes seen fewer than cfg.MinCount times
+// are left out.
+func BuildBloom(cfg Config) int {
+	n := cfg.BloomEntries
+	if n == 0 {
+		fmt.Fprintf(os.Stderr, "Counting hashes in %s...\n", cfg.InputFile)
+		var err error
+		if n, err = scanCorpus(cfg, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitError
+		}
+	}
+	if n == 0 {
+		fmt.Fprintf(os.Stderr, "No hashes to index in %s\n", cfg.InputFile)
+		return exitError
+	}
+
+	f := bloom.New(n, cfg.BloomFP)
+	fmt.Fprintf(os.Stderr, "Indexing up to %d hashes into %.1f MB at a false-positive rate of %g...\n", n, float64(f.SizeBytes())/(1<<20), cfg.BloomFP)
+	added, err := scanCorpus(cfg, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+
+	tmp := cfg.BloomFile + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	_, err = f.WriteTo(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, cfg.BloomFile)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", cfg.BloomFile, err)
+		return exitError
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d hashes to %s (%d bytes)\n", added, cfg.BloomFile, f.SizeBytes())
+	return exitOK
+}
+
+// scanCorpus counts the hashes that qualify, adding them to f when it is
+// not nil.
+func scanCorpus(cfg Config, f *bloom.Filter) (int, error) {
+	rc, err := input.Open(cfg.InputFile, input.Options{TLS: cfg.TLS, Timeout: cfg.Timeout})
+	if err != nil {
+		return 0, fmt.Errorf("Error opening file: %w", err)
+	}
+	defer rc.Close()
+
+	n, lineNo := 0, 0
+	scanner := bufio.NewScanner(bufio.NewReaderSize(rc, 1<<20))
+	for scanner.Scan() {
+		lineNo++
+		if lineNo%bloomProgressEvery == 0 {
+			fmt.Fprintf(os.Stderr, "  %d lines read\n", lineNo)
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, count, err := parseCorpusLine(line)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		digest, ok := bloom.ParseHex(hash)
+		if !ok {
+			return 0, fmt.Errorf("line %d: NTLM hashes cannot be indexed; the filter only covers SHA-1", lineNo)
+		}
+		if count < cfg.MinCount {
+			continue
+		}
+		if f != nil {
+			f.Add(digest)
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("Error reading file: %w", err)
+	}
+	return n, nil
+}
+
+// parseCorpusLine reads HASH or HASH:COUNT, where HASH is SHA-1 or NTLM
+// hex; a missing count counts as 1.
+func parseCorpusLine(line string) (string, int, error) {
+	hash, rawCount, hasCount := strings.Cut(line, ":")
+	if (len(hash) != 40 && len(hash) != 32) || !isHex(hash) {
+		return "", 0, fmt.Errorf("expected a SHA-1 or NTLM hash, got %q", hash)
+	}
+	count := 1
+	if hasCount {
+		c, err := strconv.Atoi(rawCount)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid count %q", rawCount)
+		}
+		count = c
+	}
+	return strings.ToUpper(hash), count, nil
+}
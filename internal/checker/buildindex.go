@@ -0,0 +1,132 @@
+package checker
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/index"
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// indexWriter is index.Writer or index.SQLiteWriter.
+type indexWriter interface {
+	Add(hash []byte, count int) error
+	HashLen() int
+	Len() int64
+	Flush() error
+}
+
+// BuildIndex packs a downloaded corpus of HASH:COUNT lines, SHA-1 or NTLM,
+// into the index cfg.IndexFile that -index searches, a binary file or an
+// SQLite database per cfg.IndexBackend. The corpus must be sorted by hash,
+// as HIBP's downloads are; hashes seen fewer than cfg.MinCount times are
+// left out.
+func BuildIndex(cfg Config) int {
+	backend := cmp.Or(cfg.IndexBackend, "binary")
+	if !slices.Contains(index.Backends, backend) {
+		fmt.Fprintf(os.Stderr, "unknown --backend %q (available: %s)\n", backend, strings.Join(index.Backends, ", "))
+		return exitUsage
+	}
+	cfg.IndexBackend = backend
+	tmp := cfg.IndexFile + ".tmp"
+	n, err := writeIndex(cfg, tmp)
+	if err == nil {
+		err = os.Rename(tmp, cfg.IndexFile)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d hashes to %s\n", n, cfg.IndexFile)
+	return exitOK
+}
+
+func writeIndex(cfg Config, path string) (int64, error) {
+	rc, err := input.Open(cfg.InputFile, input.Options{TLS: cfg.TLS, Timeout: cfg.Timeout})
+	if err != nil {
+		return 0, fmt.Errorf("Error opening file: %w", err)
+	}
+	defer rc.Close()
+
+	var file *os.File
+	var db *index.SQLiteWriter
+	if cfg.IndexBackend == "sqlite" {
+		// a leftover from a failed build would be opened, not replaced
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+		defer func() {
+			if db != nil {
+				db.Close()
+			}
+		}()
+	} else {
+		if file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644); err != nil {
+			return 0, err
+		}
+		defer file.Close()
+	}
+
+	var w indexWriter
+	lineNo := 0
+	scanner := bufio.NewScanner(bufio.NewReaderSize(rc, 1<<20))
+	for scanner.Scan() {
+		lineNo++
+		if lineNo%bloomProgressEvery == 0 {
+			fmt.Fprintf(os.Stderr, "  %d lines read\n", lineNo)
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, count, err := parseCorpusLine(line)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		digest, _ := hex.DecodeString(hash)
+		// the first hash decides between a SHA-1 and an NTLM index
+		if w == nil {
+			if file != nil {
+				w, err = index.NewWriter(file, len(digest))
+			} else {
+				db, err = index.NewSQLiteWriter(path, len(digest))
+				w = db
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+		if count < cfg.MinCount {
+			continue
+		}
+		if err := w.Add(digest, count); err != nil {
+			if len(digest) != w.HashLen() {
+				return 0, fmt.Errorf("line %d: SHA-1 and NTLM hashes cannot be mixed in one index", lineNo)
+			}
+			return 0, fmt.Errorf("line %d: %w; sort the corpus by hash first", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("Error reading file: %w", err)
+	}
+	if w == nil {
+		return 0, errors.New("no hashes to index in " + cfg.InputFile)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	if db != nil {
+		err := db.Close()
+		db = nil
+		return w.Len(), err
+	}
+	return w.Len(), file.Close()
+}
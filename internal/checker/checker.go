@@ -1,32 +1,223 @@
 package checker
 
 import (
-	"bufio"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/mohamedation/PwnedCheck/internal/awssecrets"
 	"github.com/mohamedation/PwnedCheck/internal/bitwarden"
-	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/index"
+	"github.com/mohamedation/PwnedCheck/internal/input"
 	"golang.org/x/term"
 )
 
+// Exit codes returned by Run.
 const (
-	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
-	colorYellow = "\033[33m"
-	colorReset  = "\033[0m"
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	// exitPwned means the run completed and found compromised passwords.
+	exitPwned = 3
+	// exitIncomplete means some lookups failed, so clean results can't be
+	// trusted to cover every entry.
+	exitIncomplete = 4
+	// exitInterrupted means SIGINT or SIGTERM stopped the run early; the
+	// results and summary cover the entries checked until then.
+	exitInterrupted = 130
 )
 
 type Config struct {
-	InputFile    string
-	IsHashed     bool
+	InputFile string
+	IsHashed  bool
+	// NTLM switches hashing, -hashed validation and lookups to NTLM.
+	NTLM         bool
 	HidePassword bool
+	// MaskPassword shows only the first and last character of passwords;
+	// HidePassword wins when both are set.
+	MaskPassword bool
+	// PrintHash shows the SHA-1 or NTLM hash wherever a password would be
+	// shown, so findings carry no plaintext; HidePassword still wins.
+	PrintHash bool
+	// SecureMemory hashes file and prompt input as soon as it is read and
+	// zeroes the buffers, so no plaintext strings are kept.
+	SecureMemory bool
 	ShowStats    bool
-	Bitwarden    bool
-	Verbose      bool
-	Args         []string
+	// Batch looks up every range once, in prefix order, before reporting.
+	Batch bool
+	// Reuse reports passwords shared by several accounts.
+	Reuse bool
+	// Top lists the most breached passwords found at the end of the run.
+	Top int
+	// MaxErrors aborts the run once this many lookups failed at the API,
+	// reporting the rest as skipped; 0 never aborts.
+	MaxErrors int
+	// Breaker is how many lookups may fail in a row from an outage before
+	// the run pauses and retries with backoff; 0 never pauses.
+	Breaker int
+	// BreakerRetries ends the run after this many retries of one outage;
+	// 0 retries until the API answers.
+	BreakerRetries int
+	// StatsFile receives the summary as JSON at the end of every run.
+	StatsFile string
+	Bitwarden bool
+	// Keychain checks the passwords saved in the system's credential store.
+	Keychain bool
+	// Verbosity is 0 by default, 1 for -v and 2 for -vv.
+	Verbosity  int
+	SampleSize int
+	SampleSeed int64
+	// Lang is the -lang language of console messages, one of Languages;
+	// empty picks it from the locale.
+	Lang string
+	// MaxLineLength is the longest input line checked, in bytes; longer
+	// lines are read past and reported. 0 means no limit.
+	MaxLineLength int
+	Stdio         bool
+	// StrictSingle checks one password from stdin and answers only with the
+	// exit code and, if open, a JSON line on fd 3.
+	StrictSingle bool
+	// TUI shows a live dashboard of the run instead of the progress bar,
+	// with pause and finding filters.
+	TUI          bool
+	Prompt       bool
+	InputHeaders http.Header
+	CacheTTL     time.Duration
+	// CacheEntries and Listen configure the proxy subcommand.
+	CacheEntries int
+	Listen       string
+	// BloomFile is the filter -bloom checks against, or the one build-bloom
+	// writes; BloomFP and BloomEntries size the latter.
+	BloomFile    string
+	BloomFP      float64
+	BloomEntries int
+	// IndexFile is the packed index -index searches, or the one the index
+	// subcommand writes, in IndexBackend format: "binary" or "sqlite".
+	IndexFile    string
+	IndexBackend string
+	// Update makes the download subcommand re-fetch only ranges whose ETag
+	// changed; Workers is how many ranges it fetches at once.
+	Update  bool
+	Workers int
+	// BenchRequests is how many range requests each bench measurement
+	// sends; 0 skips the network.
+	BenchRequests int
+	// RPS caps HIBP requests per second; 0 disables the limit.
+	RPS float64
+	// Timeout bounds each HIBP request; Deadline bounds the whole run, after
+	// which the remaining entries are reported as skipped.
+	Timeout  time.Duration
+	Deadline time.Duration
+	// Pins are decoded -pin-sha256 values.
+	Pins [][]byte
+	// TLS carries -ca-cert, the -client-cert pair and -insecure-skip-verify
+	// for HIBP requests and URL inputs.
+	TLS        *tls.Config
+	Budget     time.Duration
+	CursorFile string
+	Resume     bool
+	// Watch keeps checking lines added to the input file until interrupted.
+	Watch bool
+	// Every re-runs the audit on this interval, alerting only on new
+	// findings; StateFile keeps them between runs.
+	Every     time.Duration
+	StateFile string
+	Format    string
+	Fields    string
+	Template  string
+	Quiet     bool
+	OnlyBad   bool
+	OnlyGood  bool
+	// FailThreshold is how many pwned passwords still exit 0.
+	FailThreshold int
+	// MinCount is how often a password must have been seen to count as pwned.
+	MinCount   int
+	Dedupe     bool
+	IgnoreFile string
+	Strength   bool
+	Analyze    bool
+	PolicyFile string
+	Variants   bool
+	// Suggest prints a random replacement for pwned passwords: SuggestWords
+	// words when set, otherwise SuggestLength characters of SuggestCharset.
+	Suggest        bool
+	SuggestLength  int
+	SuggestCharset string
+	SuggestWords   int
+	// Normalize is the Unicode form applied before hashing: nfc, nfkc or none.
+	Normalize string
+	// Encoding is the text encoding of the input file, see input.Encodings.
+	Encoding string
+	// InputFormat is the layout of input file lines, see InputFormats.
+	InputFormat string
+	// KubeConfig switches the input to the Secrets of a cluster for
+	// k8s-audit: KubeContext picks the context, KubeNamespaces the namespaces
+	// (all with KubeAllNamespaces).
+	KubeConfig        string
+	KubeContext       string
+	KubeNamespaces    []string
+	KubeAllNamespaces bool
+	// AWS switches the input to the Secrets Manager secrets and SSM
+	// SecureString parameters of an account for aws-audit, read with
+	// AWSProfile in AWSRegion; AWSServices picks among "secretsmanager" and
+	// "ssm", and AWSPath limits the parameters to a hierarchy.
+	AWS         bool
+	AWSProfile  string
+	AWSRegion   string
+	AWSServices []string
+	AWSPath     string
+	// RepoPath switches the input to a git working tree for scan-repo, with
+	// its history when RepoHistory is set, or only its staged changes with
+	// RepoStaged; RulesFile replaces the default rules finding candidate
+	// passwords.
+	RepoPath    string
+	RepoHistory bool
+	RepoStaged  bool
+	RulesFile   string
+	// SecretKeys matches the keys whose values -input-format dotenv,
+	// k8s-audit and aws-audit check.
+	SecretKeys string
+	// GroupBy aggregates the summary by a dimension of GroupDimensions;
+	// GroupMap maps its values to larger groups.
+	GroupBy    string
+	GroupMap   string
+	Tags       []Tag
+	NoColor    bool
+	VerifyFrom string
+	OutputFile string
+	ReportFile string
+	// ExportBad writes the findings to this file as a CSV remediation list.
+	ExportBad string
+	// HistoryDB records every run's findings in this SQLite database. The
+	// history subcommand reads it, listing the HistoryRuns last runs of
+	// InputFile, or with HistoryDiff comparing HistoryRun, or the latest,
+	// to the run before.
+	HistoryDB   string
+	HistoryRuns int
+	HistoryRun  int64
+	HistoryDiff bool
+	Syslog      string
+	// SMTPServer, when set, mails findings with the HTML report from
+	// MailFrom to MailTo; SMTPUser and SMTPPassword authenticate.
+	SMTPServer   string
+	SMTPUser     string
+	SMTPPassword string
+	MailFrom     string
+	MailTo       []string
+	// Webhook receives findings and the summary as POSTs in WebhookFormat.
+	Webhook       string
+	WebhookFormat string
+	Args          []string
 }
 
 type statistics struct {
@@ -34,176 +225,575 @@ type statistics struct {
 	badPasswords  int
 	goodPasswords int
 	totalChecked  int
+	// errored counts entries whose lookup failed; they are neither bad nor good
+	errored    int
+	errorKinds map[string]int
+	// skipped counts entries left unchecked when -deadline passed, when
+	// -max-errors aborted the run, which sets aborted, or when the breaker
+	// ran out of -breaker-retries, which sets gaveUp
+	skipped int
+	aborted bool
+	gaveUp  bool
+	// stoppedAt is the first item left unchecked after an interrupt, and
+	// stoppedLine its input line when known
+	stoppedAt   int
+	stoppedLine int
+	// newFindings counts findings the previous -every run did not have
+	newFindings int
+	duplicates  int
+	ignored     int
+	// oversized counts input lines longer than -max-line-length
+	oversized int
+	// pauses counts the times the -breaker tripped
+	pauses int
+	// weak counts clean passwords with a low -strength score
+	weak int
+	// analysis is only used with -analyze
+	analysis       analysis
+	policyFailures int
+	variants       int
+	// groups tallies entries by the -group-by dimension, named by groupBy
+	groups  map[string]*groupTally
+	groupBy string
+	// ranked keeps the findings for the -stats list by breach count
+	ranked []rankedFinding
+	// reuse is only used with -reuse
+	reuse *reuseTracker
 }
 
-func (s *statistics) printSummary() {
-	fmt.Printf("\nTotal runtime: %s\n", time.Since(s.startTime))
-	fmt.Printf("Total passwords checked: %d\n", s.totalChecked)
-	fmt.Printf("%sBad passwords found: %d%s\n", colorRed, s.badPasswords, colorReset)
-	fmt.Printf("%sGood passwords: %d%s\n", colorGreen, s.goodPasswords, colorReset)
+type runSummary struct {
+	Total  int `json:"total"`
+	Bad    int `json:"bad"`
+	Good   int `json:"good"`
+	Errors int `json:"errors"`
+	// ErrorKinds breaks Errors down by errorKind.
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"`
+	Skipped    int            `json:"skipped,omitempty"`
+	// Aborted is set when -max-errors stopped the run.
+	Aborted bool `json:"aborted,omitempty"`
+	// GaveUp is set when the breaker ran out of -breaker-retries.
+	GaveUp      bool   `json:"gave_up,omitempty"`
+	StoppedAt   int    `json:"stopped_at,omitempty"`
+	StoppedLine int    `json:"stopped_at_line,omitempty"`
+	NewFindings int    `json:"new_findings,omitempty"`
+	Runtime     string `json:"runtime"`
+	Duplicates  int    `json:"duplicates,omitempty"`
+	Ignored     int    `json:"ignored,omitempty"`
+	Oversized   int    `json:"oversized,omitempty"`
+	// BreakerPauses counts the pauses -breaker made for an outage.
+	BreakerPauses int `json:"breaker_pauses,omitempty"`
+	// ReusedPasswords counts the passwords -reuse found shared by accounts.
+	ReusedPasswords int              `json:"reused_passwords,omitempty"`
+	Weak            int              `json:"weak,omitempty"`
+	Analysis        *analysisSummary `json:"analysis,omitempty"`
+	// PolicyFailures counts entries breaking -policy, pwned or not.
+	PolicyFailures int `json:"policy_failures,omitempty"`
+	Variants       int `json:"variants,omitempty"`
+	// GroupBy names the dimension of Groups, from -group-by.
+	GroupBy string            `json:"group_by,omitempty"`
+	Groups  []groupSummary    `json:"groups,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	// API is left out of fully offline runs.
+	API *apiSummary `json:"api,omitempty"`
 }
 
-func Run(cfg Config) int {
-	client := hibp.NewClient(cfg.Verbose)
-	stats := &statistics{startTime: time.Now()}
+// apiSummary accounts for HIBP traffic, for tuning -rps, -cache-ttl and
+// -timeout.
+type apiSummary struct {
+	Requests    int     `json:"requests"`
+	Errors      int     `json:"errors"`
+	RateLimited int     `json:"rate_limited"`
+	Retries     int     `json:"retries"`
+	CacheHits   int     `json:"cache_hits"`
+	CacheMisses int     `json:"cache_misses"`
+	P50Ms       float64 `json:"latency_p50_ms"`
+	P95Ms       float64 `json:"latency_p95_ms"`
+	P99Ms       float64 `json:"latency_p99_ms"`
+}
 
-	if len(cfg.Args) > 0 {
-		return runInline(client, cfg, stats)
+func newAPISummary(client *Checker) *apiSummary {
+	as, cs := client.APIStats(), client.CacheStats()
+	hits := cs.PositiveHits + cs.NegativeHits + cs.RangeHits
+	if as.Requests+hits == 0 {
+		return nil
+	}
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	return &apiSummary{
+		Requests:    as.Requests,
+		Errors:      as.Errors,
+		RateLimited: as.RateLimited,
+		Retries:     as.Retries,
+		CacheHits:   hits,
+		CacheMisses: cs.Misses,
+		P50Ms:       ms(as.P50),
+		P95Ms:       ms(as.P95),
+		P99Ms:       ms(as.P99),
 	}
+}
 
-	if cfg.Bitwarden {
-		return runBitwarden(client, cfg, stats)
+func (s *statistics) summary(client *Checker, tags []Tag) runSummary {
+	sum := runSummary{
+		Total:          s.totalChecked,
+		Bad:            s.badPasswords,
+		Good:           s.goodPasswords,
+		Errors:         s.errored,
+		ErrorKinds:     s.errorKinds,
+		Skipped:        s.skipped,
+		Aborted:        s.aborted,
+		GaveUp:         s.gaveUp,
+		StoppedAt:      s.stoppedAt,
+		StoppedLine:    s.stoppedLine,
+		NewFindings:    s.newFindings,
+		Runtime:        time.Since(s.startTime).String(),
+		Duplicates:     s.duplicates,
+		Ignored:        s.ignored,
+		Oversized:      s.oversized,
+		BreakerPauses:  s.pauses,
+		Weak:           s.weak,
+		Analysis:       s.analysis.summary(),
+		PolicyFailures: s.policyFailures,
+		Variants:       s.variants,
+		GroupBy:        s.groupBy,
+		Groups:         s.groupSummaries(),
+		API:            newAPISummary(client),
 	}
+	if s.reuse != nil {
+		sum.ReusedPasswords = len(s.reuse.clusters())
+	}
+	if len(tags) > 0 {
+		sum.Tags = make(map[string]string, len(tags))
+		for _, t := range tags {
+			sum.Tags[t.Key] = t.Value
+		}
+	}
+	return sum
+}
 
-	return runFile(client, cfg, stats)
+func (s *statistics) printSummary(w io.Writer, l lang, client *Checker, tags []Tag) {
+	l.fprintf(w, "\nTotal runtime: %s\n", time.Since(s.startTime))
+	l.fprintf(w, "Total passwords checked: %d\n", s.totalChecked)
+	if elapsed := time.Since(s.startTime).Seconds(); s.totalChecked > 0 && elapsed > 0 {
+		l.fprintf(w, "Throughput: %.1f checks/s\n", float64(s.totalChecked)/elapsed)
+	}
+	l.fprintf(w, "%sBad passwords found: %d%s\n", colorRed, s.badPasswords, colorReset)
+	l.fprintf(w, "%sGood passwords: %d%s\n", colorGreen, s.goodPasswords, colorReset)
+	if s.errored > 0 {
+		l.fprintf(w, "%sLookup errors: %d%s\n", colorYellow, s.errored, colorReset)
+	}
+	switch {
+	case s.skipped > 0 && s.aborted:
+		l.fprintf(w, "%sSkipped after -max-errors: %d%s\n", colorRed, s.skipped, colorReset)
+	case s.skipped > 0 && s.gaveUp:
+		l.fprintf(w, "%sSkipped after -breaker-retries: %d%s\n", colorRed, s.skipped, colorReset)
+	case s.skipped > 0:
+		l.fprintf(w, "%sSkipped at the deadline: %d%s\n", colorYellow, s.skipped, colorReset)
+	}
+	if s.newFindings > 0 {
+		l.fprintf(w, "%sNew since the last run: %d%s\n", colorRed, s.newFindings, colorReset)
+	}
+	if s.stoppedAt > 0 {
+		l.fprintf(w, "%sInterrupted before item #%d%s\n", colorYellow, s.stoppedAt, colorReset)
+	}
+	if s.duplicates > 0 {
+		l.fprintf(w, "Duplicates skipped: %d\n", s.duplicates)
+	}
+	if s.variants > 0 {
+		l.fprintf(w, "%sClean passwords with a breached variant: %d%s\n", colorYellow, s.variants, colorReset)
+	}
+	if s.policyFailures > 0 {
+		l.fprintf(w, "%sPolicy violations: %d%s\n", colorYellow, s.policyFailures, colorReset)
+	}
+	if s.weak > 0 {
+		l.fprintf(w, "%sWeak passwords not yet breached: %d%s\n", colorYellow, s.weak, colorReset)
+	}
+	if s.ignored > 0 {
+		l.fprintf(w, "Ignored by ignore file: %d\n", s.ignored)
+	}
+	if s.oversized > 0 {
+		l.fprintf(w, "%sLines too long to check: %d%s\n", colorYellow, s.oversized, colorReset)
+	}
+	if s.pauses > 0 {
+		l.fprintf(w, "%sPaused for API outages: %d times%s\n", colorYellow, s.pauses, colorReset)
+	}
+	if groups := s.groupSummaries(); len(groups) > 0 {
+		printGroups(w, s.groupBy, groups)
+	}
+	s.printHistogram(w, l)
+	s.printRanking(w, l)
+
+	if cs := client.CacheStats(); cs.PositiveHits+cs.NegativeHits+cs.Misses > 0 {
+		l.fprintf(w, "Cache: %d positive hits, %d negative hits, %d misses, %d revalidated\n", cs.PositiveHits, cs.NegativeHits, cs.Misses, cs.Revalidated)
+	}
+	if as := client.APIStats(); as.Requests > 0 {
+		c := ""
+		if as.Errors > 0 {
+			c = colorYellow
+		}
+		l.fprintf(w, "%sAPI: %d requests, %d errors, %d rate limited (429), %d retries%s\n", c, as.Requests, as.Errors, as.RateLimited, as.Retries, colorReset)
+		if as.P50 > 0 {
+			l.fprintf(w, "API latency: p50 %s, p95 %s, p99 %s\n", as.P50.Round(time.Millisecond/10), as.P95.Round(time.Millisecond/10), as.P99.Round(time.Millisecond/10))
+		}
+	}
+	if cs := client.ConnStats(); cs.New+cs.Reused > 0 {
+		l.fprintf(w, "Connections: %d new, %d reused, %d responses over HTTP/2\n", cs.New, cs.Reused, cs.HTTP2)
+	}
+	if len(tags) > 0 {
+		l.fprintf(w, "Tags: %s\n", formatTags(tags))
+	}
 }
 
-func runInline(client *hibp.Client, cfg Config, stats *statistics) int {
-	total := len(cfg.Args)
-	for i, password := range cfg.Args {
-		fmt.Printf("\nChecking password %d of %d...\n", i+1, total)
+func formatTags(tags []Tag) string {
+	pairs := make([]string, len(tags))
+	for i, t := range tags {
+		pairs[i] = t.Key + "=" + t.Value
+	}
+	return strings.Join(pairs, " ")
+}
 
-		found, err := client.CheckPassword(password, cfg.IsHashed)
-		if err != nil {
-			fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
-		} else if found {
-			fmt.Printf("%sBAD PASSWORD FOUND%s\n", colorRed, colorReset)
-			if !cfg.HidePassword {
-				fmt.Printf("  Password: %s\n", password)
+// newLogger writes to stderr so diagnostics never mix with results on stdout.
+// Without -v only errors are logged; -v adds requests and warnings, -vv
+// adds cache and matching details.
+func newLogger(verbosity int) *slog.Logger {
+	level := slog.LevelError
+	switch {
+	case verbosity >= 2:
+		level = slog.LevelDebug
+	case verbosity == 1:
+		level = slog.LevelInfo
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// indexKindError is an -index whose hash kind doesn't match -ntlm, a usage
+// error.
+type indexKindError struct {
+	path, kind string
+}
+
+func (e *indexKindError) Error() string {
+	return fmt.Sprintf("%s holds %s hashes; -ntlm must match the index", e.path, e.kind)
+}
+
+// newClient builds the Checker every subcommand looks passwords up with,
+// from the cache, rate limit, timeout, pins and TLS settings of cfg, loading
+// its -bloom filter and opening its -index. Workers sizes the connection
+// pool.
+func newClient(cfg Config, logger *slog.Logger) (*Checker, error) {
+	opts := Options{Logger: logger, CacheTTL: cfg.CacheTTL, CacheEntries: cfg.CacheEntries, RPS: cfg.RPS, Timeout: cfg.Timeout,
+		IdleConns: cfg.Workers, Pins: cfg.Pins, TLS: cfg.TLS, NTLM: cfg.NTLM}
+	var err error
+	if cfg.BloomFile != "" {
+		if opts.Offline, err = bloom.Load(cfg.BloomFile); err != nil {
+			return nil, fmt.Errorf("Failed to load Bloom filter: %w", err)
+		}
+	}
+	if cfg.IndexFile != "" {
+		if opts.Index, err = index.Open(cfg.IndexFile); err != nil {
+			return nil, fmt.Errorf("Failed to open index: %w", err)
+		}
+		if opts.Index.NTLM() != cfg.NTLM {
+			opts.Index.Close()
+			return nil, &indexKindError{path: cfg.IndexFile, kind: opts.Index.Kind()}
+		}
+	}
+	return New(opts), nil
+}
+
+// clientExit is the exit code for a newClient error.
+func clientExit(err error) int {
+	var kind *indexKindError
+	if errors.As(err, &kind) {
+		return exitUsage
+	}
+	return exitError
+}
+
+func Run(cfg Config) int {
+	if cfg.StrictSingle {
+		return runStrictSingle(cfg, os.Stdin)
+	}
+	if cfg.InputFormat == "auto" {
+		cfg.InputFormat = "lines"
+		if !cfg.Stdio && !cfg.Prompt && !cfg.Bitwarden && !cfg.Keychain && len(cfg.Args) == 0 {
+			cfg.InputFormat = detectInputFormat(cfg)
+		}
+		if isHashDump(cfg.InputFormat) {
+			if cfg.Strength || cfg.Analyze || cfg.PolicyFile != "" || cfg.Variants || cfg.BloomFile != "" {
+				fmt.Fprintf(os.Stderr, "%s looks like a pwdump-style dump of NT hashes, which --strength, --analyze, --policy, --variants and --bloom cannot use; pass --input-format lines to read it line by line\n", cfg.InputFile)
+				return exitUsage
+			}
+			cfg.NTLM, cfg.IsHashed = true, true
+			if !cfg.Quiet {
+				fmt.Fprintf(os.Stderr, "Reading %s as a pwdump-style dump of NT hashes\n", cfg.InputFile)
+			}
+		}
+		if cfg.InputFormat == "dotenv" {
+			if cfg.SecureMemory || cfg.IsHashed {
+				fmt.Fprintf(os.Stderr, "%s looks like a .env file, which --secure-memory and --hashed cannot read; pass --input-format lines to read it line by line\n", cfg.InputFile)
+				return exitUsage
 			}
-			stats.badPasswords++
-		} else {
-			fmt.Printf("%sGood password%s\n", colorGreen, colorReset)
-			if !cfg.HidePassword {
-				fmt.Printf("  Password: %s\n", password)
+			if !cfg.Quiet {
+				fmt.Fprintf(os.Stderr, "Reading %s as a .env file\n", cfg.InputFile)
 			}
-			stats.goodPasswords++
 		}
-		stats.totalChecked++
-		client.Wait()
 	}
+	var awsSource *awssecrets.Source
+	if cfg.AWS {
+		var err error
+		if awsSource, err = openAWS(&cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitError
+		}
+	}
+	client, err := newClient(cfg, newLogger(cfg.Verbosity))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return clientExit(err)
+	}
+	// closing the client closes the index too
+	defer client.Close()
+	stats := &statistics{startTime: time.Now()}
 
-	if cfg.ShowStats {
-		stats.printSummary()
+	if cfg.Stdio {
+		return runStdio(client, cfg, os.Stdin, os.Stdout)
 	}
-	return 0
-}
 
-func runBitwarden(client *hibp.Client, cfg Config, stats *statistics) int {
-	fmt.Print("Enter Bitwarden Export Encryption Password: ")
-	passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
-	fmt.Println()
+	if cfg.Every > 0 {
+		return runDaemon(client, cfg)
+	}
+
+	r, err := newRunner(client, cfg, stats)
 	if err != nil {
-		fmt.Printf("%sFailed to read password: %v%s\n", colorRed, err, colorReset)
-		return 1
+		setColors(colorEnabled(cfg, os.Stderr))
+		fmt.Fprintf(os.Stderr, "%s%v%s\n", colorRed, err, colorReset)
+		return exitUsage
+	}
+	// a no-op once finish has closed them
+	defer r.discard()
+	setColors(colorEnabled(cfg, r.msg))
+
+	if cfg.Prompt {
+		return runPrompt(r)
+	}
+
+	if len(cfg.Args) > 0 {
+		return runInline(r, cfg.Args)
+	}
+
+	if cfg.Bitwarden {
+		return runBitwarden(r)
+	}
+
+	if cfg.Keychain {
+		return runKeychain(r)
+	}
+
+	if cfg.KubeConfig != "" {
+		return runKube(r)
+	}
+
+	if awsSource != nil {
+		return runAWS(r, awsSource)
 	}
-	vaultPassword := strings.TrimSpace(string(passwordBytes))
 
-	fmt.Println("Decrypting vault file in-memory...")
-	entries, err := bitwarden.ExtractEntries(cfg.InputFile, vaultPassword)
+	if cfg.RepoPath != "" {
+		return runRepo(r)
+	}
+
+	if cfg.Watch {
+		return runWatch(r)
+	}
+
+	return runFile(r)
+}
+
+func runInline(r *runner, passwords []string) int {
+	r.source, r.style = "argument", styleInline
+	entries := make([]entry, len(passwords))
+	for i, p := range passwords {
+		entries[i] = entry{Password: p}
+	}
+	if code := r.run(entries); code != exitOK {
+		return code
+	}
+	return r.finish()
+}
+
+// runPrompt reads a single password with echo disabled, keeping it out of
+// shell history and the process list. It is never echoed back in the output.
+func runPrompt(r *runner) int {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return r.fail("-prompt requires an interactive terminal")
+	}
+
+	r.printf("Password to check: ")
+	passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	r.printf("\n")
 	if err != nil {
-		fmt.Printf("%sBitwarden decryption error: %v%s\n", colorRed, err, colorReset)
-		return 1
+		return r.fail("Failed to read password: %v", err)
+	}
+	if len(passwordBytes) == 0 {
+		r.notef("%sNo password entered.%s\n", colorYellow, colorReset)
+		return exitOK
 	}
 
-	total := len(entries)
-	if total == 0 {
-		fmt.Printf("%sNo login entries found in vault.%s\n", colorYellow, colorReset)
-		return 0
+	r.cfg.HidePassword = true
+	if r.cfg.SecureMemory && !r.cfg.IsHashed {
+		hash := secureText(r.cfg)(passwordBytes)
+		r.cfg.IsHashed = true
+		return runInline(r, []string{hash})
 	}
-	fmt.Printf("Found %d login entries in vault.\n\n", total)
+	defer clear(passwordBytes)
+	return runInline(r, []string{string(passwordBytes)})
+}
 
-	for i, entry := range entries {
-		fmt.Printf("[%d/%d] Checking %s...\r", i+1, total, entry.AccountName)
+func runBitwarden(r *runner) int {
+	entries, err := loadVault(r)
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	if len(entries) == 0 {
+		r.notef("%sNo login entries found in vault.%s\n", colorYellow, colorReset)
+		return r.finish()
+	}
+	r.notef("Found %d login entries in vault.\n\n", len(entries))
 
-		found, err := client.CheckPassword(entry.Password, false)
-		if err != nil {
-			fmt.Printf("%sError checking %s: %v%s\n", colorRed, entry.AccountName, err, colorReset)
-			stats.totalChecked++
-			client.Wait()
-			continue
-		}
+	// vault passwords are always plaintext
+	r.cfg.IsHashed = false
+	r.source, r.style = "bitwarden", styleList
+	if code := r.run(entries); code != exitOK {
+		return code
+	}
+	return r.finish()
+}
 
-		if found {
-			// fmt.Printf("%sBAD PASSWORD — BREACH DETECTED%s\n", colorRed, colorReset)
-			fmt.Printf("\r\033[K%sBAD PASSWORD — BREACH DETECTED%s\n", colorRed, colorReset)
-			fmt.Printf("  Account:  %s\n", entry.AccountName)
-			if entry.Username != "" {
-				fmt.Printf("  Username: %s\n", entry.Username)
-			}
-			if !cfg.HidePassword {
-				fmt.Printf("  Password: %s\n", entry.Password)
-			}
-			stats.badPasswords++
-		} else {
-			stats.goodPasswords++
-		}
-		stats.totalChecked++
-		client.Wait()
+// loadVault prompts for the export password and decrypts the vault in memory.
+func loadVault(r *runner) ([]entry, error) {
+	r.printf("Enter Bitwarden Export Encryption Password: ")
+	passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	r.printf("\n")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read password: %w", err)
 	}
+	vaultPassword := strings.TrimSpace(string(passwordBytes))
+	clear(passwordBytes)
 
-	fmt.Print("\r\033[K")
+	r.notef("Decrypting vault file in-memory...\n")
+	vault, err := bitwarden.ExtractEntries(r.cfg.InputFile, vaultPassword)
+	if err != nil {
+		return nil, fmt.Errorf("Bitwarden decryption error: %w", err)
+	}
 
-	if cfg.ShowStats {
-		stats.printSummary()
+	entries := make([]entry, len(vault))
+	for i, v := range vault {
+		entries[i] = entry{Password: v.Password, Account: v.AccountName, Username: v.Username, Folder: v.Folder}
 	}
-	return 0
+	return entries, nil
 }
 
-func runFile(client *hibp.Client, cfg Config, stats *statistics) int {
-	file, err := os.Open(cfg.InputFile)
+// openInput opens the configured input file or URL; the default file
+// missing gets a friendlier message than the raw error.
+func openInput(r *runner) (io.ReadCloser, error) {
+	file, err := input.Open(r.cfg.InputFile, input.Options{Headers: r.cfg.InputHeaders, Encoding: r.cfg.Encoding, TLS: r.cfg.TLS, Timeout: r.cfg.Timeout})
 	if err != nil {
-		if os.IsNotExist(err) && cfg.InputFile == "passwords.txt" {
-			fmt.Printf("%sDefault passwords file not found.%s\n", colorYellow, colorReset)
-			return 1
+		if errors.Is(err, fs.ErrNotExist) && r.cfg.InputFile == "passwords.txt" {
+			return nil, errors.New("Default passwords file not found.")
 		}
-		fmt.Printf("%sError opening file: %v%s\n", colorRed, err, colorReset)
-		return 1
+		return nil, fmt.Errorf("Error opening file: %w", err)
 	}
-	defer file.Close()
+	return file, nil
+}
 
-	var passwords []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		if line := strings.TrimSpace(scanner.Text()); line != "" {
-			passwords = append(passwords, line)
+// readLines returns every non-empty line with its 1-based line number,
+// and the numbers of the lines longer than maxLine, which it skips.
+func readLines(rd io.Reader, text lineText, maxLine int) ([]entry, []int, error) {
+	var entries []entry
+	var oversized []int
+	lines := input.NewLines(rd, maxLine)
+	lineNo := 0
+	for lines.Next() {
+		lineNo++
+		if lines.TooLong() {
+			oversized = append(oversized, lineNo)
+		} else if line := trimLine(lines.Bytes()); len(line) > 0 {
+			entries = append(entries, entry{Password: text(line), Line: lineNo})
 		}
 	}
+	if err := lines.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Error reading file: %w", err)
+	}
+	return entries, oversized, nil
+}
 
-	total := len(passwords)
-	if total == 0 {
-		fmt.Printf("%sNo passwords to check.%s\n", colorYellow, colorReset)
-		return 0
+// noteOversized counts the lines readLines skipped as too long and names
+// the first few, so a corrupt or binary input doesn't pass silently.
+func (r *runner) noteOversized(lines []int) {
+	if len(lines) == 0 {
+		return
+	}
+	r.stats.oversized += len(lines)
+	const shown = 5
+	var names []string
+	for _, n := range lines[:min(len(lines), shown)] {
+		names = append(names, strconv.Itoa(n))
+	}
+	list := strings.Join(names, ", ")
+	if len(lines) > shown {
+		list += fmt.Sprintf(" and %d more", len(lines)-shown)
 	}
+	r.notef("%sSkipped %d lines longer than %d bytes (line %s)%s\n", colorYellow, len(lines), r.cfg.MaxLineLength, list, colorReset)
+}
 
-	for i, password := range passwords {
-		fmt.Printf("[%d/%d] Checking...\r", i+1, total)
+func runFile(r *runner) int {
+	cfg := r.cfg
+	file, err := openInput(r)
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	defer file.Close()
 
-		found, err := client.CheckPassword(password, cfg.IsHashed)
+	var entries []entry
+	population := 0
+	if cfg.SampleSize > 0 {
+		seed := cfg.SampleSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		var oversized []int
+		entries, population, oversized, err = reservoirSample(file, cfg.SampleSize, cfg.MaxLineLength, rand.New(rand.NewSource(seed)), textFor(cfg))
 		if err != nil {
-			fmt.Printf("%sError (item #%d): %v%s\n", colorRed, i+1, err, colorReset)
-			stats.totalChecked++
-			client.Wait()
-			continue
+			return r.fail("Error reading file: %v", err)
 		}
-
-		if found {
-			fmt.Printf("%sBAD PASSWORD — BREACH DETECTED (item #%d)%s\n", colorRed, i+1, colorReset)
-			if !cfg.HidePassword {
-				fmt.Printf("  Password: %s\n", password)
-			}
-			stats.badPasswords++
-		} else {
-			stats.goodPasswords++
+		r.noteOversized(oversized)
+		r.notef("Sampling %d of %d passwords (seed %d)\n", len(entries), population, seed)
+	} else {
+		var oversized []int
+		if entries, oversized, err = readLines(file, textFor(cfg), cfg.MaxLineLength); err != nil {
+			return r.fail("%v", err)
 		}
-		stats.totalChecked++
-		client.Wait()
+		r.noteOversized(oversized)
+	}
+	entries, skipped := parseEntries(cfg.InputFormat, r.secretKeys, entries)
+	if skipped > 0 {
+		r.notef("Skipped %d lines without %s credentials\n", skipped, cfg.InputFormat)
+	}
+	if cfg.SecureMemory && !cfg.IsHashed {
+		// the entries now hold hashes
+		r.cfg.IsHashed, r.cfg.HidePassword = true, true
+	}
+
+	if len(entries) == 0 {
+		r.notef("%sNo passwords to check.%s\n", colorYellow, colorReset)
+		return r.finish()
 	}
 
-	fmt.Print("\r\033[K")
+	r.source, r.style = cfg.InputFile, styleList
+	if code := r.run(entries); code != exitOK {
+		return code
+	}
 
-	if cfg.ShowStats {
-		stats.printSummary()
+	if cfg.SampleSize > 0 {
+		r.printEstimate(estimateRate(r.stats.badPasswords, r.stats.badPasswords+r.stats.goodPasswords, population))
 	}
-	return 0
+	return r.finish()
 }
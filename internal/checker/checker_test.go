@@ -0,0 +1,382 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/hibp/hibptest"
+)
+
+func testRunner(t *testing.T, srv *hibptest.Server, cfg Config) *runner {
+	t.Helper()
+	client := New(Options{BaseURL: srv.URL, Transport: srv.Client().Transport, CacheTTL: time.Hour, NTLM: cfg.NTLM})
+	t.Cleanup(func() { client.Close() })
+	return &runner{
+		cfg:        cfg,
+		client:     client,
+		stats:      &statistics{startTime: time.Now()},
+		msg:        io.Discard,
+		lang:       langFor("en"),
+		source:     "passwords.txt",
+		secretKeys: regexp.MustCompile(DefaultSecretKeys),
+	}
+}
+
+func TestRecord(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name     string
+		minCount int
+		e        entry
+		res      Result
+		err      error
+		status   string
+		source   string
+	}{
+		{"pwned", 1, entry{Password: "password", Line: 3}, Result{Pwned: true, Count: 10}, nil, statusPwned, "passwords.txt"},
+		{"below min count", 100, entry{Password: "password"}, Result{Pwned: true, Count: 10}, nil, statusClean, "passwords.txt"},
+		// starter filter hits have no count and always count as pwned
+		{"no count", 100, entry{Password: "password"}, Result{Pwned: true}, nil, statusPwned, "passwords.txt"},
+		{"clean", 1, entry{Password: "correct horse"}, Result{}, nil, statusClean, "passwords.txt"},
+		{"lookup error", 1, entry{Password: "password"}, Result{}, hibp.ErrOffline, statusError, "passwords.txt"},
+		{"entry source", 1, entry{Password: "password", Source: "a/.env"}, Result{}, nil, statusClean, "a/.env"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testRunner(t, srv, Config{MinCount: tc.minCount})
+			rec := r.record(7, tc.e, tc.res, tc.err)
+			if rec.Status != tc.status || rec.Source != tc.source || rec.Item != 7 || rec.Line != tc.e.Line {
+				t.Fatalf("record = %+v; want status %s, source %s, item 7, line %d", rec, tc.status, tc.source, tc.e.Line)
+			}
+			if tc.err != nil && rec.Error != tc.err.Error() {
+				t.Errorf("error = %q, want %q", rec.Error, tc.err.Error())
+			}
+		})
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	r := testRunner(t, srv, Config{HidePassword: true})
+
+	for _, tc := range []struct {
+		e      entry
+		source string
+	}{
+		{entry{Password: "password", Line: 2, Username: "alice"}, "passwords.txt"},
+		{entry{Password: "password", Source: "b/.env"}, "b/.env"},
+	} {
+		rec := r.skipped(4, tc.e, haltDeadline)
+		if rec.Status != statusSkipped || rec.Error != haltDeadline || rec.Source != tc.source ||
+			rec.Line != tc.e.Line || rec.Username != tc.e.Username || rec.Password != "" {
+			t.Errorf("skipped(%+v) = %+v; want a hidden skipped record from %s", tc.e, rec, tc.source)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	outage := fmt.Errorf("dial: %w", hibp.ErrOffline)
+
+	for _, tc := range []struct {
+		name      string
+		breaker   breaker
+		err       error
+		halt      string
+		why       string
+		failures  int
+		apiErrors int
+	}{
+		{"disabled", breaker{}, outage, "", "", 0, 1},
+		{"below threshold", breaker{threshold: 3, failures: 1}, outage, "", "", 2, 1},
+		{"halted at threshold", breaker{threshold: 2, failures: 1}, outage, haltErrors, haltErrors, 2, 1},
+		{"out of retries", breaker{threshold: 1, retries: 2, retried: 2}, outage, "", haltOutage, 1, 1},
+		{"success resets", breaker{threshold: 2, failures: 1}, nil, "", "", 0, 0},
+		{"bad entry", breaker{threshold: 1}, &InvalidHashError{Want: "SHA-1"}, "", "", 0, 0},
+		{"pin mismatch", breaker{threshold: 1}, hibp.ErrPinMismatch, "", "", 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testRunner(t, srv, Config{})
+			r.breaker = tc.breaker
+			calls := 0
+			why := r.guard(func() string { return tc.halt }, &progress{}, func() error {
+				calls++
+				return tc.err
+			})
+			if why != tc.why || calls != 1 || r.breaker.failures != tc.failures || r.apiErrors != tc.apiErrors || r.stats.pauses != 0 {
+				t.Fatalf("guard = %q after %d calls, failures %d, API errors %d, pauses %d; want %q after 1, %d, %d, 0",
+					why, calls, r.breaker.failures, r.apiErrors, r.stats.pauses, tc.why, tc.failures, tc.apiErrors)
+			}
+		})
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	const nt = "31D6CFE0D16AE931B73C59D7E0C089C0"
+	for _, tc := range []struct {
+		format  string
+		lines   []string
+		want    []entry
+		skipped int
+	}{
+		{"lines", []string{"password", "alice:pw"}, []entry{{Password: "password", Line: 1}, {Password: "alice:pw", Line: 2}}, 0},
+		{"userpass", []string{"alice:pw1", "bob\tp:w", "nocolon", ":nouser", "carol:"},
+			[]entry{{Username: "alice", Password: "pw1", Line: 1}, {Username: "bob", Password: "p:w", Line: 2}}, 3},
+		{"pwdump", []string{
+			"Administrator:500:aad3b435b51404eeaad3b435b51404ee:" + nt + ":::",
+			"[*] Dumping local SAM hashes",
+			"DC01$:1000:aad3b435b51404eeaad3b435b51404ee:" + nt + ":::",
+			"CORP\\bob:1104:aad3b435b51404eeaad3b435b51404ee:" + nt + ":::",
+		}, []entry{{Account: "Administrator", Password: nt, Line: 1}, {Account: "CORP\\bob", Password: nt, Line: 4}}, 2},
+		{"potfile", []string{"5f4dcc3b5aa765d61d8327deb882cf99:password", "8a24367a1f46c141048752f2d5bbd14b:$HEX[70613a7373]", "nocolon"},
+			[]entry{{Account: "5f4dcc3b5aa765d61d8327deb882cf99", Password: "password", Line: 1}, {Account: "8a24367a1f46c141048752f2d5bbd14b", Password: "pa:ss", Line: 2}}, 1},
+		{"dotenv", []string{"DB_PASSWORD=hunter2", `export API_TOKEN="abc def"`, "NAME=bob", "# SECRET=x", "SECRET=${OTHER}", "PWD='s3cr3t # kept'"},
+			[]entry{{Account: "DB_PASSWORD", Password: "hunter2", Line: 1}, {Account: "API_TOKEN", Password: "abc def", Line: 2}, {Account: "PWD", Password: "s3cr3t # kept", Line: 6}}, 3},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			var lines []entry
+			for i, l := range tc.lines {
+				lines = append(lines, entry{Password: l, Line: i + 1})
+			}
+			got, skipped := parseEntries(tc.format, regexp.MustCompile(DefaultSecretKeys), lines)
+			if !slices.Equal(got, tc.want) || skipped != tc.skipped {
+				t.Fatalf("parseEntries = %+v, %d; want %+v, %d", got, skipped, tc.want, tc.skipped)
+			}
+		})
+	}
+}
+
+func TestDetectInputFormat(t *testing.T) {
+	dir := t.TempDir()
+	dump := "[*] Dumping local SAM hashes\nAdministrator:500:aad3b435b51404eeaad3b435b51404ee:31d6cfe0d16ae931b73c59d7e0c089c0:::\n" +
+		"Guest:501:aad3b435b51404eeaad3b435b51404ee:31d6cfe0d16ae931b73c59d7e0c089c0:::\n"
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{".env", "PASSWORD=x\n", "dotenv"},
+		{"prod.env", "PASSWORD=x\n", "dotenv"},
+		{"sam.txt", dump, "pwdump"},
+		{"mostly.txt", dump + "password\n", "pwdump"},
+		{"list.txt", "password\n123456\nqwerty\n" + dump, "lines"},
+		{"empty.txt", "", "lines"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if got := detectInputFormat(Config{InputFile: path}); got != tc.want {
+				t.Fatalf("detectInputFormat(%s) = %s, want %s", tc.name, got, tc.want)
+			}
+		})
+	}
+	if got := detectInputFormat(Config{InputFile: filepath.Join(dir, "missing.txt")}); got != "lines" {
+		t.Errorf("detectInputFormat(missing file) = %s, want lines for the read to report", got)
+	}
+}
+
+func TestReservoirSample(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&b, "pw%d\n", i)
+		if i%10 == 0 {
+			b.WriteString("\n")
+		}
+	}
+	corpus := b.String()
+
+	for _, tc := range []struct {
+		name       string
+		input      string
+		n, maxLine int
+		size       int
+		population int
+		oversized  []int
+	}{
+		{"whole corpus", "a\n\nb\nc\n", 10, 64, 3, 3, nil},
+		{"sample", corpus, 10, 64, 10, 100, nil},
+		{"oversized lines", "short\nmuch too long\nok\n", 10, 6, 2, 2, []int{2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sample, population, oversized, err := reservoirSample(strings.NewReader(tc.input), tc.n, tc.maxLine, rand.New(rand.NewSource(1)), plainText)
+			if err != nil || len(sample) != tc.size || population != tc.population || !slices.Equal(oversized, tc.oversized) {
+				t.Fatalf("reservoirSample = %d entries, %d, %v, %v; want %d, %d, %v, nil", len(sample), population, oversized, err, tc.size, tc.population, tc.oversized)
+			}
+			lines := strings.Split(tc.input, "\n")
+			for _, e := range sample {
+				if e.Line < 1 || lines[e.Line-1] != e.Password {
+					t.Errorf("sampled %q at line %d, which holds %q", e.Password, e.Line, lines[e.Line-1])
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateRate(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		bad, checked, total int
+	}{
+		{"nothing checked", 0, 0, 100},
+		{"census", 1, 10, 10},
+		{"none pwned", 0, 100, 1000},
+		{"small corpus", 20, 100, 200},
+		{"large corpus", 20, 100, 1_000_000_000},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := estimateRate(tc.bad, tc.checked, tc.total)
+			var want float64
+			if tc.checked > 0 {
+				want = float64(tc.bad) / float64(tc.checked)
+			}
+			if e.rate != want || e.low > e.rate || e.high < e.rate || e.low < 0 || e.high > 1 {
+				t.Fatalf("estimateRate = %+v; want rate %v inside [low, high] within [0, 1]", e, want)
+			}
+			if tc.checked >= tc.total && (e.low != e.rate || e.high != e.rate) {
+				t.Errorf("census interval = [%v, %v], want exactly %v", e.low, e.high, e.rate)
+			}
+		})
+	}
+
+	// sampling half the corpus narrows the interval
+	small, large := estimateRate(20, 100, 200), estimateRate(20, 100, 1_000_000_000)
+	if small.high-small.low >= large.high-large.low {
+		t.Errorf("interval from half the corpus = %v wide, not narrower than %v", small.high-small.low, large.high-large.low)
+	}
+	if e := estimateRate(0, 100, 1000); e.low != 0 || math.Abs(e.high-0.033) > 0.001 {
+		t.Errorf("no hits in 100 of 1000 = [%v, %v], want [0, ~0.033]", e.low, e.high)
+	}
+}
+
+func TestBatchLookups(t *testing.T) {
+	hash := hibp.HashPassword("password")
+	for _, tc := range []struct {
+		name  string
+		body  string
+		pwned bool
+		count int
+	}{
+		{"listed", hash[5:] + ":10\r\n", true, 10},
+		{"padding", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":0\r\n", false, 0},
+		{"absent", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n", false, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := hibptest.NewServer()
+			defer srv.Close()
+			srv.Serve(tc.body)
+			r := testRunner(t, srv, Config{Batch: true})
+
+			entries := []entry{{Password: "password", Line: 1}, {Password: "password", Line: 2}}
+			results, why := r.batchLookups(entries, func() string { return "" }, &progress{})
+			if why != "" || len(results) != 2 {
+				t.Fatalf("batchLookups = %d results, %q; want 2, no halt", len(results), why)
+			}
+			for i, b := range results {
+				if !b.done || b.err != nil || b.res.Pwned != tc.pwned || b.res.Count != tc.count || b.res.Hash != hash {
+					t.Errorf("result %d = %+v; want pwned %v, count %d", i+1, b, tc.pwned, tc.count)
+				}
+			}
+			// Check answers the same from the cached range
+			if res, err := r.client.Check("password", false); err != nil || res.Pwned != tc.pwned || res.Count != tc.count {
+				t.Errorf("Check after the batch = %+v, %v; want pwned %v, count %d", res, err, tc.pwned, tc.count)
+			}
+			// and a second batch is answered from the cache alone
+			if _, why := r.batchLookups(entries, func() string { return "" }, &progress{}); why != "" || srv.Requests() != 1 {
+				t.Errorf("requests = %d, want 1 shared by both batches and Check", srv.Requests())
+			}
+		})
+	}
+}
+
+func TestMatchEntry(t *testing.T) {
+	current := []entry{
+		{Username: "alice", Password: "a", Line: 1},
+		{Account: "github", Username: "alice", Password: "b", Line: 2},
+		{Password: "c", Line: 3},
+	}
+	for _, tc := range []struct {
+		name     string
+		f        reportResult
+		password string
+		ok       bool
+	}{
+		{"username", reportResult{Username: "alice", Line: 9}, "a", true},
+		{"account and username", reportResult{Account: "github", Username: "alice"}, "b", true},
+		{"line", reportResult{Line: 3}, "c", true},
+		{"renamed", reportResult{Username: "bob", Line: 1}, "", false},
+		{"line gone", reportResult{Line: 4}, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e, ok := matchEntry(current, tc.f)
+			if ok != tc.ok || e.Password != tc.password {
+				t.Fatalf("matchEntry = %+v, %v; want password %q, %v", e, ok, tc.password, tc.ok)
+			}
+		})
+	}
+	if matchable(reportResult{Item: 1, Hash: "AB"}) {
+		t.Error("matchable(finding with neither line nor name) = true, want false")
+	}
+}
+
+func TestVerifyFinding(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 10)
+	path := filepath.Join(t.TempDir(), "users.txt")
+	if err := os.WriteFile(path, []byte("alice:password\nbob:correct horse battery staple\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	flagged := []reportResult{
+		{Item: 1, Line: 1, Username: "alice", Status: statusPwned, Count: 10},
+		{Item: 2, Line: 2, Username: "bob", Status: statusPwned, Count: 3},
+		{Item: 3, Line: 3, Username: "carol", Status: statusPwned, Count: 3},
+		{Item: 4, Status: statusPwned, Hash: hibp.HashPassword("password")},
+	}
+
+	for _, tc := range []struct {
+		format   string
+		verdicts []string
+	}{
+		{"userpass", []string{verdictStillPwned, verdictFixed, verdictRemoved, statusError}},
+		// read as plain lines nothing has a name, and every named finding
+		// would pass for removed; VerifyFix refuses before getting here
+		{"lines", []string{verdictRemoved, verdictRemoved, verdictRemoved, statusError}},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			r := testRunner(t, srv, Config{InputFile: path, InputFormat: tc.format})
+			current, err := loadCurrent(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := slices.ContainsFunc(current, named), tc.format == "userpass"; got != want {
+				t.Fatalf("current entries named = %v, want %v", got, want)
+			}
+			for i, f := range flagged {
+				if ce := r.verifyFinding(f, current, true); ce.Verdict != tc.verdicts[i] {
+					t.Errorf("finding %d = %+v; want %s", f.Item, ce, tc.verdicts[i])
+				}
+			}
+		})
+	}
+
+	// without the input, the report's own hash is checked again
+	r := testRunner(t, srv, Config{})
+	if ce := r.verifyFinding(flagged[3], nil, false); ce.Verdict != verdictStillPwned || ce.Count != 10 {
+		t.Errorf("finding by hash = %+v; want still pwned, 10", ce)
+	}
+}
@@ -0,0 +1,46 @@
+package checker
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+// Colors are empty strings when disabled, so call sites can interpolate
+// them unconditionally.
+var (
+	colorRed    = ansiRed
+	colorGreen  = ansiGreen
+	colorYellow = ansiYellow
+	colorReset  = ansiReset
+)
+
+func setColors(enabled bool) {
+	if enabled {
+		colorRed, colorGreen, colorYellow, colorReset = ansiRed, ansiGreen, ansiYellow, ansiReset
+		return
+	}
+	colorRed, colorGreen, colorYellow, colorReset = "", "", "", ""
+}
+
+// colorEnabled honours --no-color and NO_COLOR (https://no-color.org), and
+// otherwise only colors output that goes to a terminal able to render ANSI
+// escapes.
+func colorEnabled(cfg Config, w io.Writer) bool {
+	if cfg.NoColor {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) && enableVirtualTerminal(f)
+}
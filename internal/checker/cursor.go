@@ -0,0 +1,88 @@
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// how often a long run rewrites its checkpoint
+const checkpointInterval = 30 * time.Second
+
+// cursor records where a run stopped, or how far it got before being
+// interrupted, so the next run can pick up from there. Item is the 0-based
+// index of the next non-empty input line; the counters are the partial
+// statistics up to that point.
+type cursor struct {
+	Input     string    `json:"input"`
+	Item      int       `json:"item"`
+	Checked   int       `json:"checked"`
+	Bad       int       `json:"bad"`
+	Good      int       `json:"good"`
+	Errors    int       `json:"errors,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newCursor(input string, item int, stats *statistics) cursor {
+	return cursor{
+		Input:   input,
+		Item:    item,
+		Checked: stats.totalChecked,
+		Bad:     stats.badPasswords,
+		Good:    stats.goodPasswords,
+		Errors:  stats.errored,
+	}
+}
+
+func (c cursor) restore(stats *statistics) {
+	stats.totalChecked = c.Checked
+	stats.badPasswords = c.Bad
+	stats.goodPasswords = c.Good
+	stats.errored = c.Errors
+}
+
+func cursorPath(cfg Config) (string, error) {
+	if cfg.CursorFile != "" {
+		return cfg.CursorFile, nil
+	}
+	if input.IsURL(cfg.InputFile) {
+		return "", errors.New("-cursor is required when the input is a URL")
+	}
+	return cfg.InputFile + ".cursor", nil
+}
+
+// loadCursor returns a zero cursor when none has been saved yet.
+func loadCursor(path string) (cursor, error) {
+	var c cursor
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("failed to parse cursor file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// saveCursor writes through a temporary file so an interrupted write never
+// leaves a truncated cursor behind.
+func saveCursor(path string, c cursor) error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,171 @@
+package checker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// auditState is what -every remembers between runs: the findings of the
+// last completed run, so the next one only alerts on what is new.
+type auditState struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	// Findings are fingerprints of source, account, username and hash; the
+	// hashes themselves are not stored.
+	Findings []string `json:"findings"`
+}
+
+func statePath(cfg Config) (string, error) {
+	if cfg.StateFile != "" {
+		return cfg.StateFile, nil
+	}
+	if input.IsURL(cfg.InputFile) {
+		return "", errors.New("-state is required when the input is a URL")
+	}
+	return cfg.InputFile + ".state", nil
+}
+
+func loadState(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s auditState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	known := make(map[string]bool, len(s.Findings))
+	for _, f := range s.Findings {
+		known[f] = true
+	}
+	return known, nil
+}
+
+// saveState writes through a temporary file, like saveCursor.
+func saveState(path string, findings map[string]bool) error {
+	s := auditState{UpdatedAt: time.Now().UTC(), Findings: make([]string, 0, len(findings))}
+	for f := range findings {
+		s.Findings = append(s.Findings, f)
+	}
+	slices.Sort(s.Findings)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func findingKey(rec record) string {
+	sum := sha256.Sum256([]byte(rec.Source + "\x00" + rec.Account + "\x00" + rec.Username + "\x00" + rec.Hash))
+	return hex.EncodeToString(sum[:])
+}
+
+// alerting decides whether a result is worth an alert. Outside -every every
+// result is; with it, only findings the previous run did not have.
+func (r *runner) alerting(rec record) bool {
+	if r.known == nil {
+		return true
+	}
+	if rec.Status != statusPwned {
+		return false
+	}
+	key := findingKey(rec)
+	r.findings[key] = true
+	if r.known[key] {
+		return false
+	}
+	r.stats.newFindings++
+	return true
+}
+
+// discard closes the outputs and the history of a run that ends without
+// finish, such as a failed one. It does nothing after conclude.
+func (r *runner) discard() {
+	if r.concluded {
+		return
+	}
+	for _, s := range r.sinks {
+		if s.closer != nil {
+			s.closer.Close()
+		}
+	}
+	if r.history != nil {
+		r.history.Close()
+	}
+}
+
+// runDaemon repeats the file audit every cfg.Every, measured from the start
+// of each run, until SIGINT or SIGTERM. Outputs are rewritten by every run;
+// the console and syslog only hear about new findings.
+func runDaemon(client *Checker, cfg Config) int {
+	path, err := statePath(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+	known, err := loadState(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	for {
+		started := time.Now()
+		r, err := newRunner(client, cfg, &statistics{startTime: started})
+		if err != nil {
+			setColors(colorEnabled(cfg, os.Stderr))
+			fmt.Fprintf(os.Stderr, "%s%v%s\n", colorRed, err, colorReset)
+			return exitUsage
+		}
+		setColors(colorEnabled(cfg, r.msg))
+		r.known, r.findings = known, map[string]bool{}
+
+		code := runFile(r)
+		r.discard()
+		if r.stats.stoppedAt > 0 {
+			return exitInterrupted
+		}
+		// a failed or partial run must not forget findings it never got to
+		if code == exitError || code == exitIncomplete {
+			for key := range known {
+				r.findings[key] = true
+			}
+		}
+		if code != exitError {
+			known = r.findings
+			if err := saveState(path, known); err != nil {
+				r.printf("%sFailed to save state: %v%s\n", colorRed, err, colorReset)
+			}
+		}
+
+		next := started.Add(cfg.Every)
+		r.notef("%s: %d new findings, %d known; next run at %s\n",
+			time.Now().Format(time.DateTime), r.stats.newFindings, len(known), next.Format(time.DateTime))
+		select {
+		case <-time.After(time.Until(next)):
+		case <-sigs:
+			return exitOK
+		}
+	}
+}
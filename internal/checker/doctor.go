@@ -0,0 +1,263 @@
+package checker
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/index"
+)
+
+const (
+	apiHost = "api.pwnedpasswords.com"
+	// probePassword is in every corpus, so its range proves the lookups
+	// return real data.
+	probePassword = "password"
+	// clockSkewMax is what doctor tolerates before warning.
+	clockSkewMax = time.Minute
+)
+
+// doctor prints one line per check, with a hint under the failed ones.
+type doctor struct {
+	failed bool
+}
+
+func (d *doctor) ok(name, format string, args ...any) {
+	fmt.Printf("%s  OK  %s %s: %s\n", colorGreen, colorReset, name, fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) warn(name, detail, hint string) {
+	fmt.Printf("%sWARN %s %s: %s\n", colorYellow, colorReset, name, detail)
+	if hint != "" {
+		fmt.Printf("       %s\n", hint)
+	}
+}
+
+func (d *doctor) fail(name, detail, hint string) {
+	d.failed = true
+	fmt.Printf("%sFAIL %s %s: %s\n", colorRed, colorReset, name, detail)
+	if hint != "" {
+		fmt.Printf("       %s\n", hint)
+	}
+}
+
+// Doctor checks the network path to HIBP, the clock, the places state files
+// are written and the offline datasets, and says how to fix what fails. It
+// exits 1 when a check failed; warnings keep it at 0.
+func Doctor(cfg Config) int {
+	setColors(colorEnabled(cfg, os.Stdout))
+	d := &doctor{}
+
+	d.ok("API key", "none needed; the range API is anonymous and only hash prefixes are sent")
+	if d.network() {
+		client := New(Options{Logger: newLogger(cfg.Verbosity), Timeout: cfg.Timeout, Pins: cfg.Pins, TLS: cfg.TLS})
+		defer client.Close()
+		if probe, ok := d.api(client, "SHA-1 API", false); ok {
+			d.clock(probe)
+		}
+		d.api(client, "NTLM API", true)
+	}
+	if cfg.TLS != nil && cfg.TLS.InsecureSkipVerify {
+		d.warn("TLS", "certificate verification is off (-insecure-skip-verify)", "trust an inspecting proxy with -ca-cert instead")
+	}
+	d.stateDir()
+	d.starter()
+	if cfg.BloomFile != "" {
+		d.bloom(cfg.BloomFile)
+	}
+	if cfg.IndexFile != "" {
+		d.index(cfg.IndexFile)
+	}
+
+	if d.failed {
+		return exitError
+	}
+	return exitOK
+}
+
+// network checks the proxy from the environment, or DNS for a direct
+// connection, and reports whether the API is worth trying.
+func (d *doctor) network() bool {
+	req, _ := http.NewRequest(http.MethodGet, "https://"+apiHost+"/", nil)
+	proxy, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		d.fail("Proxy", err.Error(), "fix HTTPS_PROXY, or unset it for a direct connection")
+		return false
+	}
+	if proxy == nil {
+		d.ok("Proxy", "none configured (HTTPS_PROXY, NO_PROXY); connecting directly")
+		addrs, err := net.LookupHost(apiHost)
+		if err != nil {
+			d.fail("DNS", err.Error(), "check the resolver, or set HTTPS_PROXY if the network only allows a proxy")
+			return false
+		}
+		d.ok("DNS", "%s resolves to %s", apiHost, strings.Join(addrs, ", "))
+		return true
+	}
+	addr := proxyAddr(proxy)
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		d.fail("Proxy", fmt.Sprintf("%s is unreachable: %v", proxy.Redacted(), err), "check HTTPS_PROXY, or add "+apiHost+" to NO_PROXY")
+		return false
+	}
+	conn.Close()
+	d.ok("Proxy", "%s from the environment accepts connections", proxy.Redacted())
+	return true
+}
+
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	switch u.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// api fetches the range of probePassword and checks that it lists it.
+func (d *doctor) api(client *Checker, name string, ntlm bool) (hibp.Probe, bool) {
+	hash := hibp.HashPassword(probePassword)
+	if ntlm {
+		hash = hibp.HashNTLM(probePassword)
+	}
+	probe, err := client.Probe(hash[:5], ntlm)
+	if err != nil {
+		d.fail(name, err.Error(), apiHint(err))
+		return probe, false
+	}
+	count, found := probe.Suffixes[hash[5:]]
+	if !found {
+		d.fail(name, fmt.Sprintf("the range of %q came back without it (%d entries)", probePassword, len(probe.Suffixes)),
+			"something between here and HIBP rewrites responses; check the proxy")
+		return probe, false
+	}
+	d.ok(name, "%s in %s over %s, %q seen %d times", apiHost, probe.Latency.Round(time.Millisecond), probe.Proto, probePassword, count)
+	return probe, true
+}
+
+// apiHint suggests a fix for the usual ways a request fails.
+func apiHint(err error) string {
+	var unknown x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknown):
+		return "a TLS-inspecting proxy? trust its CA with -ca-cert"
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return "the certificate looks expired, which usually means the local clock is wrong"
+	case errors.Is(err, hibp.ErrPinMismatch):
+		return "HIBP or the proxy presented another key; update -pin-sha256"
+	case errors.Is(err, hibp.ErrTimeout):
+		return "raise -timeout, or check that a firewall allows HTTPS to " + apiHost
+	case errors.Is(err, hibp.ErrRateLimited):
+		return "rate limited; lower -rps"
+	case errors.Is(err, hibp.ErrMalformed):
+		return "the answer is not a range listing; a captive portal or proxy may be rewriting it"
+	case errors.Is(err, hibp.ErrBadResponse):
+		return "a proxy or firewall may be blocking " + apiHost
+	}
+	return "check the network path to " + apiHost
+}
+
+// clock compares the local time with the server's Date header, allowing for
+// the round trip.
+func (d *doctor) clock(probe hibp.Probe) {
+	if probe.Date.IsZero() {
+		d.warn("Clock", "the API response has no Date header to compare with", "")
+		return
+	}
+	skew := time.Until(probe.Date) + probe.Latency/2
+	if skew.Abs() > clockSkewMax {
+		d.warn("Clock", fmt.Sprintf("local time is %s off the server's", skew.Abs().Round(time.Second)),
+			"enable NTP; a wrong clock breaks certificate checks and skews -every, -deadline and report timestamps")
+		return
+	}
+	d.ok("Clock", "within %s of the server's", clockSkewMax)
+}
+
+// stateDir checks that cursors, -every state and outputs, which default to
+// paths next to the input, can be written to the working directory.
+func (d *doctor) stateDir() {
+	dir, err := os.Getwd()
+	if err != nil {
+		d.fail("State files", err.Error(), "")
+		return
+	}
+	f, err := os.CreateTemp(dir, ".pwnedcheck-doctor-*")
+	if err != nil {
+		d.fail("State files", fmt.Sprintf("cannot write to %s: %v", dir, err),
+			"run from a writable directory, or point -o, -cursor and -state elsewhere")
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.ok("State files", "%s is writable; the range cache is in memory only (-cache-ttl)", dir)
+}
+
+func (d *doctor) starter() {
+	filter, err := bloom.Starter()
+	switch {
+	case err != nil:
+		d.warn("Starter filter", err.Error(), "rebuild the binary with make build-starter")
+	case filter != nil && !filter.TestHex(hibp.HashPassword(probePassword)):
+		d.warn("Starter filter", fmt.Sprintf("embedded, but it does not hold %q", probePassword), "rebuild it from a most-common-first list")
+	case filter != nil:
+		d.ok("Starter filter", "embedded, %.1f MB", float64(filter.SizeBytes())/(1<<20))
+	default:
+		d.ok("Starter filter", "not built in; every lookup goes to the API or -bloom/-index")
+	}
+}
+
+func (d *doctor) bloom(path string) {
+	filter, err := bloom.Load(path)
+	if err != nil {
+		d.fail("Bloom filter", fmt.Sprintf("%s: %v", path, err), "rebuild it with pwnedcheck build-bloom")
+		return
+	}
+	if !filter.TestHex(hibp.HashPassword(probePassword)) {
+		d.warn("Bloom filter", fmt.Sprintf("%s does not hold %q", path, probePassword),
+			"it was built from a partial corpus or with a high -min-count")
+		return
+	}
+	d.ok("Bloom filter", "%s loads, %.1f MB", path, float64(filter.SizeBytes())/(1<<20))
+}
+
+// index reads the whole index once to check its order.
+func (d *doctor) index(path string) {
+	idx, err := index.Open(path)
+	if err != nil {
+		d.fail("Index", fmt.Sprintf("%s: %v", path, err), "rebuild it with pwnedcheck index")
+		return
+	}
+	defer idx.Close()
+	if err := idx.Verify(); err != nil {
+		d.fail("Index", fmt.Sprintf("%s: %v", path, err), "rebuild it with pwnedcheck index")
+		return
+	}
+	hash := hibp.HashPassword(probePassword)
+	if idx.NTLM() {
+		hash = hibp.HashNTLM(probePassword)
+	}
+	count, found, err := idx.LookupHex(hash)
+	switch {
+	case err != nil:
+		d.fail("Index", fmt.Sprintf("%s: %v", path, err), "")
+	case !found:
+		d.warn("Index", fmt.Sprintf("%s is sorted, but does not hold %q", path, probePassword),
+			"it was built from a partial corpus or with a high -min-count")
+	default:
+		d.ok("Index", "%s holds %d %s hashes in order, %q seen %d times", path, idx.Len(), idx.Kind(), probePassword, count)
+	}
+}
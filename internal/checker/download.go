@@ -0,0 +1,290 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// rangeCount is the number of 5-hex-digit prefixes the corpus is split into.
+const rangeCount = 1 << 20
+
+const (
+	downloadAttempts = 3
+	// etagsHeader starts the sidecar; the mode keeps an NTLM update from
+	// patching a SHA-1 dataset.
+	etagsHeader = "# pwnedcheck etags "
+)
+
+type rangeResult struct {
+	// suffixes is nil when the range is unchanged since its ETag.
+	suffixes map[string]int
+	etag     string
+	err      error
+}
+
+// Download fetches every range of the corpus into cfg.OutputFile as sorted
+// HASH:COUNT lines, recording each range's ETag in a sidecar next to it.
+// With cfg.Update, ranges whose ETag still matches are copied from the
+// existing file instead of downloaded again. The new dataset replaces the
+// old one only once it is complete.
+func Download(cfg Config) int {
+	mode := "sha1"
+	if cfg.NTLM {
+		mode = "ntlm"
+	}
+	etagsPath := cfg.OutputFile + ".etags"
+	etags := make([]string, rangeCount)
+	var old *oldRanges
+	if cfg.Update {
+		var err error
+		if etags, err = loadETags(etagsPath, mode); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitError
+		}
+		f, err := os.Open(cfg.OutputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitError
+		}
+		defer f.Close()
+		old = newOldRanges(f)
+	}
+
+	client, err := newClient(cfg, newLogger(cfg.Verbosity))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return clientExit(err)
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	tmp, tmpETags := cfg.OutputFile+".tmp", etagsPath+".tmp"
+	changed, err := downloadRanges(ctx, client, cfg, etags, old, tmp, tmpETags, mode)
+	if err == nil {
+		if err = os.Rename(tmp, cfg.OutputFile); err == nil {
+			err = os.Rename(tmpETags, etagsPath)
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+		os.Remove(tmpETags)
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "\nInterrupted; %s was left unchanged\n", cfg.OutputFile)
+			return exitInterrupted
+		}
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	if cfg.Update {
+		fmt.Fprintf(os.Stderr, "Updated %s: %d of %d ranges changed\n", cfg.OutputFile, changed, rangeCount)
+	} else {
+		fmt.Fprintf(os.Stderr, "Downloaded %d ranges to %s\n", rangeCount, cfg.OutputFile)
+	}
+	return exitOK
+}
+
+// downloadRanges fetches ranges with cfg.Workers goroutines and writes them
+// in prefix order, keeping at most a few ranges per worker in flight.
+func downloadRanges(ctx context.Context, client *Checker, cfg Config, etags []string, old *oldRanges, path, etagsPath, mode string) (int, error) {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+	w := bufio.NewWriterSize(out, 1<<20)
+
+	window := cfg.Workers * 4
+	slots := make([]chan rangeResult, window)
+	for i := range slots {
+		slots[i] = make(chan rangeResult, 1)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				slots[i%window] <- fetchRangeRetry(ctx, client, fmt.Sprintf("%05X", i), cfg.NTLM, etags[i])
+			}
+		}()
+	}
+	// every in-flight job has a slot of its own, so workers never block on
+	// a send and can always be waited for
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	changed, next := 0, 0
+	for i := range rangeCount {
+		for ; next < rangeCount && next < i+window; next++ {
+			select {
+			case jobs <- next:
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+		r := <-slots[i%window]
+		if r.err != nil {
+			return 0, r.err
+		}
+		prefix := fmt.Sprintf("%05X", i)
+		if r.suffixes == nil {
+			if err := old.copyRange(prefix, w); err != nil {
+				return 0, err
+			}
+		} else {
+			if old != nil {
+				if err := old.copyRange(prefix, io.Discard); err != nil {
+					return 0, err
+				}
+				changed++
+			}
+			keys := make([]string, 0, len(r.suffixes))
+			for s := range r.suffixes {
+				keys = append(keys, s)
+			}
+			slices.Sort(keys)
+			for _, s := range keys {
+				fmt.Fprintf(w, "%s%s:%d\n", prefix, s, r.suffixes[s])
+			}
+		}
+		etags[i] = r.etag
+		if (i+1)%(rangeCount/100) == 0 {
+			fmt.Fprintf(os.Stderr, "\r  %d%% (%d ranges)", (i+1)*100/rangeCount, i+1)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+	return changed, saveETags(etagsPath, mode, etags)
+}
+
+// fetchRangeRetry retries transient failures with a short backoff; one range
+// failing after that aborts the download.
+func fetchRangeRetry(ctx context.Context, client *Checker, prefix string, ntlm bool, etag string) rangeResult {
+	var err error
+	for attempt := range downloadAttempts {
+		if attempt > 0 {
+			client.Retried()
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return rangeResult{err: ctx.Err()}
+			}
+		}
+		var r rangeResult
+		r.suffixes, r.etag, err = client.Fetch(prefix, ntlm, etag)
+		if err == nil {
+			return r
+		}
+		client.Logger().Warn("range download failed", "prefix", prefix, "attempt", attempt+1, "err", err)
+		// another key will not go away by asking again
+		if errors.Is(err, hibp.ErrPinMismatch) {
+			break
+		}
+	}
+	return rangeResult{err: fmt.Errorf("range %s: %w", prefix, err)}
+}
+
+// oldRanges walks the previous dataset alongside the download, which visits
+// prefixes in the same sorted order.
+type oldRanges struct {
+	sc      *bufio.Scanner
+	line    string
+	pending bool
+}
+
+func newOldRanges(r io.Reader) *oldRanges {
+	return &oldRanges{sc: bufio.NewScanner(bufio.NewReaderSize(r, 1<<20))}
+}
+
+// copyRange writes the old lines of prefix to w and moves past them.
+func (o *oldRanges) copyRange(prefix string, w io.Writer) error {
+	if o == nil {
+		return errors.New("the server reported an unchanged range without a previous download")
+	}
+	for {
+		if !o.pending {
+			if !o.sc.Scan() {
+				return o.sc.Err()
+			}
+			o.line, o.pending = strings.TrimSpace(o.sc.Text()), true
+		}
+		if o.line == "" {
+			o.pending = false
+			continue
+		}
+		if len(o.line) < 5 || strings.ToUpper(o.line[:5]) > prefix {
+			return nil
+		}
+		if strings.ToUpper(o.line[:5]) < prefix {
+			return fmt.Errorf("the existing dataset is not sorted near %q; download it again without -update", o.line)
+		}
+		if _, err := io.WriteString(w, o.line+"\n"); err != nil {
+			return err
+		}
+		o.pending = false
+	}
+}
+
+func loadETags(path, mode string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no ETags from a previous download (%w); run it without -update first", err)
+	}
+	defer f.Close()
+	etags := make([]string, rangeCount)
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() || sc.Text() != etagsHeader+mode {
+		return nil, fmt.Errorf("%s does not hold %s ETags; match -ntlm to the original download", path, mode)
+	}
+	for sc.Scan() {
+		prefix, etag, ok := strings.Cut(sc.Text(), " ")
+		i, err := strconv.ParseUint(prefix, 16, 32)
+		if !ok || err != nil || len(prefix) != 5 {
+			return nil, fmt.Errorf("corrupted ETag file %s", path)
+		}
+		etags[i] = etag
+	}
+	return etags, sc.Err()
+}
+
+func saveETags(path, mode string, etags []string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "%s%s\n", etagsHeader, mode)
+	for i, etag := range etags {
+		if etag != "" {
+			fmt.Fprintf(w, "%05X %s\n", i, etag)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
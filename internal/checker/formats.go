@@ -0,0 +1,272 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// InputFormats lists the values accepted for -input-format. "lines" is one
+// password or hash per line; the others are tool outputs whose lines also
+// name the account. "auto" picks dotenv from the file name, and pwdump or
+// lines from the first lines.
+var InputFormats = []string{"auto", "lines", "userpass", "secretsdump", "pwdump", "potfile", "dotenv"}
+
+// detectLines is how many credential lines auto detection looks at.
+const detectLines = 50
+
+func checkInputFormat(name string) error {
+	if name == "" || slices.Contains(InputFormats, name) {
+		return nil
+	}
+	return fmt.Errorf("unknown -input-format %q (available: %s)", name, strings.Join(InputFormats, ", "))
+}
+
+// isHashDump reports whether the format carries NT hashes, which implies
+// -ntlm and -hashed.
+func isHashDump(format string) bool {
+	return format == "secretsdump" || format == "pwdump"
+}
+
+// parseEntries turns raw input lines into entries for the format, returning
+// how many lines held no credential to check. keys selects the dotenv
+// variables to check.
+func parseEntries(format string, keys *regexp.Regexp, lines []entry) ([]entry, int) {
+	var parse func(string) (string, string, bool)
+	switch {
+	case isHashDump(format):
+		parse = parseDumpLine
+	case format == "potfile":
+		parse = parsePotLine
+	case format == "userpass":
+		parse = parseUserPass
+	case format == "dotenv":
+		parse = func(line string) (string, string, bool) {
+			key, value, ok := parseEnvLine(line)
+			return key, value, ok && keys.MatchString(key)
+		}
+	default:
+		return lines, 0
+	}
+	entries := lines[:0]
+	skipped := 0
+	for _, e := range lines {
+		name, password, ok := parse(e.Password)
+		if !ok {
+			skipped++
+			continue
+		}
+		ne := entry{Password: password, Account: name, Line: e.Line}
+		if format == "userpass" {
+			ne.Account, ne.Username = "", name
+		}
+		entries = append(entries, ne)
+	}
+	return entries, skipped
+}
+
+// parseUserPass reads user:password or user<TAB>password. A tab wins when
+// present, so passwords may contain colons; with a colon, the username ends
+// at the first one.
+func parseUserPass(line string) (string, string, bool) {
+	user, password, ok := strings.Cut(line, "\t")
+	if !ok {
+		user, password, ok = strings.Cut(line, ":")
+	}
+	user = strings.TrimSpace(user)
+	if !ok || user == "" || password == "" {
+		return "", "", false
+	}
+	return user, password, true
+}
+
+// parseEnvLine reads a KEY=value line of a .env file, with an optional
+// export prefix. Quoted values end at the closing quote; unquoted ones at a
+// " #" comment. Comments, empty values and bare ${VAR} references, which
+// hold no password of their own, are not credentials to check.
+func parseEnvLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	key, value, ok := strings.Cut(line, "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok || !isEnvKey(key) {
+		return "", "", false
+	}
+	switch {
+	case value == "":
+		return "", "", false
+	case value[0] == '"' || value[0] == '\'':
+		end := closingQuote(value)
+		if end < 0 {
+			// multi-line values are not supported
+			return "", "", false
+		}
+		quoted := value[1:end]
+		if value[0] == '"' {
+			quoted = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(quoted)
+		}
+		value = quoted
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	if value == "" || isEnvReference(value) {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// closingQuote finds the quote ending the value quoted by value[0];
+// double-quoted values may escape it with a backslash.
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && value[0] == '"':
+			i++
+		case value[i] == value[0]:
+			return i
+		}
+	}
+	return -1
+}
+
+func isEnvKey(s string) bool {
+	if s == "" || '0' <= s[0] && s[0] <= '9' {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c == '.' || c == '-' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isEnvReference reports whether value is only $VAR or ${VAR...}.
+func isEnvReference(value string) bool {
+	if inner, ok := strings.CutPrefix(value, "${"); ok {
+		return strings.HasSuffix(inner, "}") && !strings.Contains(inner[:len(inner)-1], "}")
+	}
+	name, ok := strings.CutPrefix(value, "$")
+	return ok && isEnvKey(name)
+}
+
+// isDotenvName reports whether a file is named like .env, .env.local or
+// prod.env.
+func isDotenvName(path string) bool {
+	base := path
+	if i := strings.LastIndexAny(base, `/\`); i >= 0 {
+		base = base[i+1:]
+	}
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// parsePotLine reads a hashcat potfile hash:plaintext line, returning the
+// cracked hash as the account and the plaintext, decoding $HEX[...]. The
+// plaintext starts after the first colon, so it may contain colons itself,
+// but salted hashes written as hash:salt are not told apart.
+func parsePotLine(line string) (string, string, bool) {
+	hash, plain, ok := strings.Cut(line, ":")
+	if !ok || hash == "" || plain == "" {
+		return "", "", false
+	}
+	if rest, isHex := strings.CutPrefix(plain, "$HEX["); isHex && strings.HasSuffix(rest, "]") {
+		decoded, err := hex.DecodeString(strings.TrimSuffix(rest, "]"))
+		if err != nil || len(decoded) == 0 {
+			return "", "", false
+		}
+		plain = string(decoded)
+	}
+	return hash, plain, true
+}
+
+// parseDumpLine reads the user:rid:lmhash:nthash::: layout that pwdump,
+// fgdump and impacket-secretsdump share, returning the account, domain
+// included, and the NT hash. Status lines, Kerberos keys, cleartext secrets,
+// accounts pwdump shows as NO PASSWORD and computer accounts, whose
+// passwords are random, are not credentials to check.
+func parseDumpLine(line string) (string, string, bool) {
+	// secretsdump -user-status appends " (status=Enabled)" after the hashes
+	fields := strings.Split(strings.TrimSpace(line), ":")
+	if len(fields) < 7 || !isDigits(fields[1]) || len(fields[3]) != 32 || !isHex(fields[3]) ||
+		fields[0] == "" || strings.HasSuffix(fields[0], "$") {
+		return "", "", false
+	}
+	return fields[0], fields[3], true
+}
+
+// detectInputFormat settles -input-format auto. Files named like .env are
+// dotenv files; otherwise it peeks at the first lines of a local input file:
+// when at least half the credential-looking lines are
+// user:rid:lm:nt:::, it is a pwdump-style dump. Anything unreadable is left
+// to the normal read to report.
+func detectInputFormat(cfg Config) string {
+	if isDotenvName(cfg.InputFile) {
+		return "dotenv"
+	}
+	if input.IsURL(cfg.InputFile) {
+		return "lines"
+	}
+	rc, err := input.Open(cfg.InputFile, input.Options{Encoding: cfg.Encoding})
+	if err != nil {
+		return "lines"
+	}
+	defer rc.Close()
+
+	seen, matched := 0, 0
+	scanner := bufio.NewScanner(rc)
+	for seen < detectLines && scanner.Scan() {
+		line := trimLine(scanner.Bytes())
+		// skip blanks and secretsdump's "[*] ..." progress lines
+		if len(line) == 0 || line[0] == '[' {
+			continue
+		}
+		seen++
+		// plaintext passwords are only turned into strings when they
+		// could be a dump line, which keeps -secure-memory's promise
+		if bytes.Count(line, []byte(":")) < 6 {
+			continue
+		}
+		if _, _, ok := parseDumpLine(string(line)); ok {
+			matched++
+		}
+	}
+	if matched > 0 && matched*2 >= seen {
+		return "pwdump"
+	}
+	return "lines"
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package checker
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// GroupDimensions lists the values accepted for -group-by.
+var GroupDimensions = []string{"username", "account", "domain", "folder", "source"}
+
+// groupsShown caps the -stats breakdown; the JSON summary has every group.
+const groupsShown = 20
+
+// groupTally counts the checked entries of one group.
+type groupTally struct {
+	bad, total int
+}
+
+type groupSummary struct {
+	Name  string `json:"name"`
+	Bad   int    `json:"bad"`
+	Total int    `json:"total"`
+}
+
+// groupKey is the value of the -group-by dimension for rec, translated
+// through the -group-map when there is one.
+func (r *runner) groupKey(rec record) string {
+	var key string
+	switch r.cfg.GroupBy {
+	case "username":
+		key = rec.Username
+	case "account":
+		key = rec.Account
+	case "domain":
+		key = domainOf(cmp.Or(rec.Account, rec.Username))
+	case "folder":
+		key = rec.Folder
+	case "source":
+		key = rec.Source
+	}
+	if key == "" {
+		return "(none)"
+	}
+	if r.groupMap != nil {
+		if group, ok := r.groupMap[strings.ToLower(key)]; ok {
+			return group
+		}
+		return "(unmapped)"
+	}
+	return key
+}
+
+// domainOf takes the domain from DOMAIN\user or user@domain names.
+func domainOf(name string) string {
+	if domain, _, ok := strings.Cut(name, `\`); ok {
+		return domain
+	}
+	if _, domain, ok := strings.Cut(name, "@"); ok {
+		return domain
+	}
+	return ""
+}
+
+// countGroup adds a checked entry to its group; errors and skipped entries
+// say nothing about exposure.
+func (r *runner) countGroup(rec record) {
+	if r.cfg.GroupBy == "" || rec.Status != statusPwned && rec.Status != statusClean {
+		return
+	}
+	if r.stats.groups == nil {
+		r.stats.groups = map[string]*groupTally{}
+	}
+	key := r.groupKey(rec)
+	g := r.stats.groups[key]
+	if g == nil {
+		g = &groupTally{}
+		r.stats.groups[key] = g
+	}
+	g.total++
+	if rec.Status == statusPwned {
+		g.bad++
+	}
+}
+
+// groupSummaries sorts groups by findings, most first.
+func (s *statistics) groupSummaries() []groupSummary {
+	if len(s.groups) == 0 {
+		return nil
+	}
+	groups := make([]groupSummary, 0, len(s.groups))
+	for name, g := range s.groups {
+		groups = append(groups, groupSummary{Name: name, Bad: g.bad, Total: g.total})
+	}
+	slices.SortFunc(groups, func(a, b groupSummary) int {
+		return cmp.Or(cmp.Compare(b.Bad, a.Bad), cmp.Compare(b.Total, a.Total), strings.Compare(a.Name, b.Name))
+	})
+	return groups
+}
+
+func printGroups(w io.Writer, groupBy string, groups []groupSummary) {
+	fmt.Fprintf(w, "By %s:\n", groupBy)
+	for i, g := range groups {
+		if i == groupsShown {
+			fmt.Fprintf(w, "  ... and %d more\n", len(groups)-groupsShown)
+			break
+		}
+		c := colorGreen
+		if g.Bad > 0 {
+			c = colorRed
+		}
+		fmt.Fprintf(w, "  %s: %s%d/%d compromised%s\n", g.Name, c, g.Bad, g.Total, colorReset)
+	}
+}
+
+// loadGroupMap reads name,group lines (a tab works too) that put -group-by
+// values into larger groups, such as usernames into OUs or departments.
+// Names match case-insensitively; blank lines and # comments are skipped.
+func loadGroupMap(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open group map: %w", err)
+	}
+	defer file.Close()
+
+	groups := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, group, ok := strings.Cut(line, "\t")
+		if !ok {
+			name, group, ok = strings.Cut(line, ",")
+		}
+		name, group = strings.TrimSpace(name), strings.TrimSpace(group)
+		if !ok || name == "" || group == "" {
+			return nil, fmt.Errorf("group map line %d: expected name,group", lineNo)
+		}
+		groups[strings.ToLower(name)] = group
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read group map: %w", err)
+	}
+	return groups, nil
+}
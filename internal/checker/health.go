@@ -0,0 +1,88 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// readyInterval is how long the proxy trusts an upstream probe, so frequent
+// readiness checks cost HIBP one request per interval at most.
+const readyInterval = 30 * time.Second
+
+var errNotProbed = errors.New("no probe has completed yet")
+
+// readiness answers /readyz: the proxy is ready when HIBP answered the
+// latest probe.
+type readiness struct {
+	client *Checker
+	// mu guards the last probe, never the probe itself, so a slow HIBP
+	// doesn't queue up readiness checks behind it
+	mu      sync.Mutex
+	probing bool
+	probed  time.Time
+	probe   hibp.Probe
+	err     error
+}
+
+func newReadiness(client *Checker) *readiness {
+	return &readiness{client: client, err: errNotProbed}
+}
+
+// upstream probes HIBP past the cache unless the last probe is recent or
+// another request is already probing, and says how the last one went.
+func (rd *readiness) upstream() (hibp.Probe, error) {
+	rd.mu.Lock()
+	if rd.probing || time.Since(rd.probed) < readyInterval {
+		defer rd.mu.Unlock()
+		return rd.probe, rd.err
+	}
+	rd.probing = true
+	rd.mu.Unlock()
+
+	hash := hibp.HashPassword(probePassword)
+	probe, err := rd.client.Probe(hash[:5], false)
+
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	rd.probing, rd.probed, rd.probe, rd.err = false, time.Now(), probe, err
+	return probe, err
+}
+
+// serveHealth answers /healthz: the process is up and serving.
+func serveHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok\n"))
+}
+
+// serveReady answers /readyz with one line per check, and 503 when one
+// fails, so a load balancer or Kubernetes stops routing lookups to a proxy
+// that cannot answer them.
+func (rd *readiness) serveReady(w http.ResponseWriter, _ *http.Request) {
+	var b strings.Builder
+	ready := true
+	if probe, err := rd.upstream(); err != nil {
+		ready = false
+		fmt.Fprintf(&b, "upstream: FAIL %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "upstream: ok, %s in %s\n", probe.Proto, probe.Latency.Round(time.Millisecond))
+	}
+	// lookups work without the cache, so it is only reported
+	if ranges, capacity := rd.client.CacheUsage(); capacity == 0 {
+		b.WriteString("cache: disabled\n")
+	} else {
+		fmt.Fprintf(&b, "cache: ok, %d of %d ranges\n", ranges, capacity)
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write([]byte(b.String()))
+}
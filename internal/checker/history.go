@@ -0,0 +1,228 @@
+package checker
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/history"
+)
+
+// see looks rec up in -history-db, filling in when it was first seen and
+// the latest earlier run that had it, and keeps it for this run's record.
+// A new finding is first seen now and has no last sighting.
+func (r *runner) see(rec *record) {
+	key := findingKey(*rec)
+	rec.FirstSeen = r.stats.startTime
+	switch seen, ok, err := r.history.Seen(key); {
+	case err != nil:
+		r.historyErr = cmp.Or(r.historyErr, err)
+	case ok:
+		rec.FirstSeen, rec.LastSeen = seen.First, seen.Last
+	}
+	r.sightings = append(r.sightings, history.Finding{
+		Key: key, Account: rec.Account, Username: rec.Username, Line: rec.Line, Count: rec.Count,
+	})
+}
+
+// historySource names the input a run is recorded under: the file for file
+// and vault audits, the kind of source for the others.
+func (r *runner) historySource() string {
+	if r.source == "bitwarden" {
+		return r.cfg.InputFile
+	}
+	return r.source
+}
+
+// saveHistory records the run in -history-db and says how it compares to
+// the previous run of the same source. Runs that left entries unchecked are
+// not recorded, since their missing findings would look fixed.
+func (r *runner) saveHistory() {
+	defer r.history.Close()
+	switch {
+	case r.historyErr != nil:
+		r.printf("%sFailed to read the history: %v%s\n", colorRed, r.historyErr, colorReset)
+		return
+	case r.stats.stoppedAt > 0 || r.stats.errored > 0 || r.stats.skipped > 0:
+		r.notef("%sRun not recorded in %s, since it left entries unchecked.%s\n", colorYellow, r.cfg.HistoryDB, colorReset)
+		return
+	case r.stats.totalChecked == 0:
+		// an emptied input would otherwise close every finding as fixed
+		r.notef("%sRun not recorded in %s, since it checked nothing.%s\n", colorYellow, r.cfg.HistoryDB, colorReset)
+		return
+	}
+	run := history.Run{
+		Started: r.stats.startTime, Finished: time.Now(), Source: r.historySource(),
+		Checked: r.stats.totalChecked, Pwned: r.stats.badPasswords, Clean: r.stats.goodPasswords,
+	}
+	var err error
+	if run.ID, err = r.history.Add(run, r.sightings); err != nil {
+		r.printf("%sFailed to record the run in %s: %v%s\n", colorRed, r.cfg.HistoryDB, err, colorReset)
+		return
+	}
+	prev, ok, err := r.history.Previous(run)
+	if err == nil && ok {
+		var added, fixed []history.Finding
+		if added, fixed, err = r.history.Diff(prev.ID, run.ID); err == nil {
+			r.notef("Recorded as run %d in %s: %d new and %d fixed findings since run %d\n",
+				run.ID, r.cfg.HistoryDB, len(added), len(fixed), prev.ID)
+			return
+		}
+	}
+	if err != nil {
+		r.printf("%sFailed to compare with the previous run: %v%s\n", colorRed, err, colorReset)
+		return
+	}
+	r.notef("Recorded as run %d in %s, the first of %s\n", run.ID, r.cfg.HistoryDB, run.Source)
+}
+
+// History prints the runs in cfg.HistoryDB with their findings trend, or
+// with cfg.HistoryDiff the findings a run gained and lost against the
+// previous run of its source.
+func History(cfg Config) int {
+	setColors(colorEnabled(cfg, os.Stdout))
+	store, err := history.Open(cfg.HistoryDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	defer store.Close()
+	if cfg.HistoryDiff {
+		err = printHistoryDiff(store, cfg)
+	} else {
+		err = printHistoryRuns(store, cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runTrend is a recorded run with the findings it gained and lost since the
+// previous run of its source; Compared is false for the first one.
+type runTrend struct {
+	history.Run
+	New, Fixed int
+	Compared   bool
+}
+
+// trend returns the last n runs of source, or of all sources when it is
+// empty, oldest first, each compared with the run before it.
+func trend(store *history.Store, source string, n int) ([]runTrend, error) {
+	runs, err := store.Runs(source, n)
+	if err != nil {
+		return nil, err
+	}
+	trends := make([]runTrend, len(runs))
+	for i, run := range runs {
+		t := runTrend{Run: run}
+		prev, ok, err := store.Previous(run)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			added, fixed, err := store.Diff(prev.ID, run.ID)
+			if err != nil {
+				return nil, err
+			}
+			t.New, t.Fixed, t.Compared = len(added), len(fixed), true
+		}
+		trends[len(runs)-1-i] = t
+	}
+	return trends, nil
+}
+
+func printHistoryRuns(store *history.Store, cfg Config) error {
+	runs, err := trend(store, cfg.InputFile, cfg.HistoryRuns)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 && cfg.InputFile != "" {
+		fmt.Printf("No runs of %s recorded in %s.\n", cfg.InputFile, cfg.HistoryDB)
+		return nil
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No runs recorded in %s yet.\n", cfg.HistoryDB)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tSTARTED\tCHECKED\tPWNED\tNEW\tFIXED\tSOURCE")
+	for _, run := range runs {
+		added, fixed := "-", "-"
+		if run.Compared {
+			added, fixed = strconv.Itoa(run.New), strconv.Itoa(run.Fixed)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\t%s\n", run.ID, run.Started.Local().Format(time.DateTime),
+			run.Checked, run.Pwned, added, fixed, run.Source)
+	}
+	return tw.Flush()
+}
+
+func printHistoryDiff(store *history.Store, cfg Config) error {
+	var run history.Run
+	if cfg.HistoryRun > 0 {
+		var err error
+		if run, err = store.Run(cfg.HistoryRun); err != nil {
+			return err
+		}
+	} else {
+		runs, err := store.Runs(cfg.InputFile, 1)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("no runs recorded in %s yet", cfg.HistoryDB)
+		}
+		run = runs[0]
+	}
+	prev, ok, err := store.Previous(run)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("run %d is the first of %s; there is nothing to compare it with", run.ID, run.Source)
+	}
+	added, fixed, err := store.Diff(prev.ID, run.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: run %d (%s) against run %d (%s)\n", run.Source, run.ID, run.Started.Local().Format(time.DateTime),
+		prev.ID, prev.Started.Local().Format(time.DateTime))
+	fmt.Printf("\n%sNew findings: %d%s\n", colorRed, len(added), colorReset)
+	for _, f := range added {
+		fmt.Printf("  %s, seen %s times in breaches\n", historyLabel(f), countText(f.Count))
+	}
+	fmt.Printf("\n%sFixed findings: %d%s\n", colorGreen, len(fixed), colorReset)
+	for _, f := range fixed {
+		fmt.Printf("  %s\n", historyLabel(f))
+	}
+	return nil
+}
+
+// historyLabel names a recorded finding like the -stats ranking does, by
+// account and username or by line.
+func historyLabel(f history.Finding) string {
+	switch {
+	case f.Account != "" && f.Username != "":
+		return f.Account + " (" + f.Username + ")"
+	case f.Account != "" || f.Username != "":
+		return f.Account + f.Username
+	case f.Line > 0:
+		return "line " + strconv.Itoa(f.Line)
+	}
+	return "an entry without line or account"
+}
+
+// seenText formats a -history-db sighting for the outputs, empty when there
+// is none.
+func seenText(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
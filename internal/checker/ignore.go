@@ -0,0 +1,69 @@
+package checker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// loadIgnoreFile reads accepted passwords, one per line, as SHA-1 (or NTLM)
+// hashes or plaintext; plaintext lines are normalized like the input and
+// hashed on load. Blank lines and lines starting with # are skipped.
+func loadIgnoreFile(path string, normalize func(string) string, ntlm bool) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer file.Close()
+
+	ignore := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if validateHash(line, ntlm) == nil {
+			ignore[strings.ToUpper(line)] = struct{}{}
+			continue
+		}
+		if normalize != nil {
+			line = normalize(line)
+		}
+		ignore[hashKind(ntlm)(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	return ignore, nil
+}
+
+// hashKind returns the function entries are hashed with, SHA-1 or NTLM.
+func hashKind(ntlm bool) func(string) string {
+	if ntlm {
+		return hibp.HashNTLM
+	}
+	return hibp.HashPassword
+}
+
+// entryHash is the uppercase hash an entry is looked up by.
+func entryHash(e entry, hashed, ntlm bool) string {
+	if hashed {
+		return strings.ToUpper(e.Password)
+	}
+	return hashKind(ntlm)(e.Password)
+}
+
+// skipIgnored drops entries whose hash is in the ignore set.
+func skipIgnored(entries []entry, ignore map[string]struct{}, hashed, ntlm bool) ([]entry, int) {
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if _, ok := ignore[entryHash(e, hashed, ntlm)]; !ok {
+			kept = append(kept, e)
+		}
+	}
+	return kept, len(entries) - len(kept)
+}
@@ -0,0 +1,35 @@
+package checker
+
+import "github.com/mohamedation/PwnedCheck/internal/keystore"
+
+// runKeychain checks the passwords saved in the system's credential store.
+// Entries are named by service and account and the values are never shown;
+// the item kind doubles as the folder for -group-by.
+func runKeychain(r *runner) int {
+	items, denied, err := keystore.Items()
+	if err != nil {
+		return r.fail("Failed to read the %s: %v", keystore.Name, err)
+	}
+	if denied > 0 {
+		r.notef("%sAccess to %d items was denied; they were not checked.%s\n", colorYellow, denied, colorReset)
+	}
+	var entries []entry
+	for _, it := range items {
+		if isPasswordValue(it.Secret) {
+			entries = append(entries, entry{Password: string(it.Secret), Account: it.Service, Username: it.Account, Folder: it.Kind})
+		}
+		clear(it.Secret)
+	}
+	if len(entries) == 0 {
+		r.notef("%sNo passwords found in the %s.%s\n", colorYellow, keystore.Name, colorReset)
+		return r.finish()
+	}
+	r.notef("Found %d passwords in the %s.\n\n", len(entries), keystore.Name)
+
+	r.cfg.IsHashed, r.cfg.HidePassword = false, true
+	r.source, r.style = "keychain", styleList
+	if code := r.run(entries); code != exitOK {
+		return code
+	}
+	return r.finish()
+}
@@ -0,0 +1,126 @@
+package checker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/kube"
+)
+
+// kubeSkipTypes hold generated tokens, keys or blobs rather than passwords.
+var kubeSkipTypes = []string{
+	"kubernetes.io/service-account-token",
+	"kubernetes.io/tls",
+	"kubernetes.io/ssh-auth",
+	"bootstrap.kubernetes.io/token",
+	"helm.sh/release.v1",
+}
+
+// runKube checks the password-like values of the Secrets in the selected
+// namespaces. Entries are named namespace/secret/key and the values are
+// never shown.
+func runKube(r *runner) int {
+	client, err := kube.NewClient(r.cfg.KubeConfig, r.cfg.KubeContext, r.cfg.Timeout)
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	namespaces := r.cfg.KubeNamespaces
+	switch {
+	case r.cfg.KubeAllNamespaces:
+		namespaces = []string{""}
+	case len(namespaces) == 0:
+		namespaces = []string{client.Namespace}
+	}
+
+	var entries []entry
+	secrets := 0
+	for _, ns := range namespaces {
+		list, err := client.Secrets(ns)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		secrets += len(list)
+		for _, s := range list {
+			entries = append(entries, secretEntries(s, r.secretKeys)...)
+		}
+	}
+	if len(entries) == 0 {
+		r.notef("%sNo password-like values in %d secrets.%s\n", colorYellow, secrets, colorReset)
+		return r.finish()
+	}
+	r.notef("Found %d password-like values in %d secrets (context %s).\n\n", len(entries), secrets, client.Context)
+
+	r.cfg.IsHashed, r.cfg.HidePassword = false, true
+	r.source, r.style = "kubernetes", styleList
+	if code := r.run(entries); code != exitOK {
+		return code
+	}
+	return r.finish()
+}
+
+// secretEntries picks the values of s under keys matching the pattern, plus
+// the registry passwords of image pull secrets. The namespace doubles as the
+// folder, so -group-by folder groups findings by namespace.
+func secretEntries(s kube.Secret, keys *regexp.Regexp) []entry {
+	if slices.Contains(kubeSkipTypes, s.Type) {
+		return nil
+	}
+	name := s.Namespace + "/" + s.Name + "/"
+	if s.Type == "kubernetes.io/dockerconfigjson" || s.Type == "kubernetes.io/dockercfg" {
+		return dockerEntries(s, name)
+	}
+	username := ""
+	for _, k := range []string{"username", "user"} {
+		if v, ok := s.Data[k]; ok && isPasswordValue(v) {
+			username = string(v)
+			break
+		}
+	}
+	var entries []entry
+	for _, k := range slices.Sorted(maps.Keys(s.Data)) {
+		if v := s.Data[k]; keys.MatchString(k) && isPasswordValue(v) {
+			entries = append(entries, entry{Password: string(v), Account: name + k, Username: username, Folder: s.Namespace})
+		}
+	}
+	return entries
+}
+
+// dockerEntries reads the registry credentials of an image pull secret,
+// named namespace/secret/key:registry.
+func dockerEntries(s kube.Secret, name string) []entry {
+	var entries []entry
+	for _, k := range slices.Sorted(maps.Keys(s.Data)) {
+		var cfg struct {
+			Auths map[string]dockerAuth `json:"auths"`
+		}
+		if k == ".dockerconfigjson" {
+			if json.Unmarshal(s.Data[k], &cfg) != nil {
+				continue
+			}
+		} else if k != ".dockercfg" || json.Unmarshal(s.Data[k], &cfg.Auths) != nil {
+			continue
+		}
+		for _, registry := range slices.Sorted(maps.Keys(cfg.Auths)) {
+			a := cfg.Auths[registry]
+			if a.Password == "" && a.Auth != "" {
+				if decoded, err := base64.StdEncoding.DecodeString(a.Auth); err == nil {
+					a.Username, a.Password, _ = strings.Cut(string(decoded), ":")
+				}
+			}
+			if isPasswordValue([]byte(a.Password)) {
+				entries = append(entries, entry{Password: a.Password, Account: name + k + ":" + registry, Username: a.Username, Folder: s.Namespace})
+			}
+		}
+	}
+	return entries
+}
+
+type dockerAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
@@ -0,0 +1,51 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Languages are the -lang values. English is the text in the code; the
+// others translate the console output a helpdesk reads: findings, notes,
+// prompts and the -stats summary. Structured output, logs and usage errors
+// stay in English so scripts and bug reports don't depend on the locale.
+var Languages = []string{"en", "de", "es"}
+
+var translations = map[string]lang{
+	"de": langDE,
+	"es": langES,
+}
+
+// lang maps English format strings to their translation; nil is English.
+// A translation takes the same verbs in the same order, and any string it
+// lacks falls back to English.
+type lang map[string]string
+
+func (l lang) tr(s string) string {
+	if t, ok := l[s]; ok {
+		return t
+	}
+	return s
+}
+
+func (l lang) fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, l.tr(format), args...)
+}
+
+// langFor returns the translation for name, or when it is empty for the
+// first of LC_ALL, LC_MESSAGES and LANG that is set, so "de_DE.UTF-8"
+// selects German. Unknown locales get English.
+func langFor(name string) lang {
+	if name == "" {
+		for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if name = os.Getenv(v); name != "" {
+				break
+			}
+		}
+	}
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "_")
+	return translations[strings.ToLower(name)]
+}
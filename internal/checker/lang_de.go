@@ -0,0 +1,114 @@
+package checker
+
+// langDE is the German console text.
+var langDE = lang{
+	// findings
+	"BAD PASSWORD — BREACH DETECTED":                    "KOMPROMITTIERTES PASSWORT — IN DATENLECKS GEFUNDEN",
+	"BREACHED VARIANT — ":                               "KOMPROMITTIERTE VARIANTE — ",
+	"POLICY VIOLATION":                                  "RICHTLINIENVERSTOSS",
+	"WEAK PASSWORD — NOT BREACHED":                      "SCHWACHES PASSWORT — NICHT IN DATENLECKS",
+	"%s%s (item #%d)%s\n":                               "%s%s (Eintrag #%d)%s\n",
+	"%sBAD PASSWORD FOUND%s\n":                          "%sKOMPROMITTIERTES PASSWORT GEFUNDEN%s\n",
+	"%sGood password%s\n":                               "%sSicheres Passwort%s\n",
+	"%sGood password%s  %s\n":                           "%sSicheres Passwort%s  %s\n",
+	"%sGood password (item #%d)%s\n":                    "%sSicheres Passwort (Eintrag #%d)%s\n",
+	"%sError: %s%s\n":                                   "%sFehler: %s%s\n",
+	"%sError checking %s: %s%s\n":                       "%sFehler bei der Prüfung von %s: %s%s\n",
+	"%sError (item #%d): %s%s\n":                        "%sFehler (Eintrag #%d): %s%s\n",
+	"  Password: %s\n":                                  "  Passwort:       %s\n",
+	"  Password: %s (%d chars)\n":                       "  Passwort:       %s (%d Zeichen)\n",
+	"  Hash:     %s\n":                                  "  Hash:           %s\n",
+	"  File:     %s:%d\n":                               "  Datei:          %s:%d\n",
+	"  Account:  %s\n":                                  "  Konto:          %s\n",
+	"  Username: %s\n":                                  "  Benutzername:   %s\n",
+	"  Strength: ":                                      "  Stärke:         ",
+	"  Makeup:   ":                                      "  Aufbau:         ",
+	"  Policy:   ":                                      "  Richtlinie:     ",
+	"  Replace:  ":                                      "  Ersetzen durch: ",
+	"  Replace with: ":                                  "  Ersetzen durch: ",
+	"  Tags:     %s\n":                                  "  Tags:           %s\n",
+	"  Tags: %s\n":                                      "  Tags: %s\n",
+	"  Seen:     %d times in breaches\n":                "  Gesehen:        %d-mal in Datenlecks\n",
+	"  %sA %s variant appears %d times in breaches%s\n": "  %sEine Variante (%s) kommt %d-mal in Datenlecks vor%s\n",
+	"%s%s%d/4%s (crack time: %s)\n":                     "%s%s%d/4%s (Knackzeit: %s)\n",
+	"%s%spass%s\n":                                      "%s%serfüllt%s\n",
+	"%s%sfail%s (%s)\n":                                 "%s%snicht erfüllt%s (%s)\n",
+	"%s%d chars, %s, %.1f bits of entropy\n":            "%s%d Zeichen, %s, %.1f Bit Entropie\n",
+
+	// prompts and notes
+	"Password to check: ":                                                              "Zu prüfendes Passwort: ",
+	"%sNo password entered.%s\n":                                                       "%sKein Passwort eingegeben.%s\n",
+	"Enter Bitwarden Export Encryption Password: ":                                     "Passwort des verschlüsselten Bitwarden-Exports: ",
+	"Decrypting vault file in-memory...\n":                                             "Tresordatei wird im Arbeitsspeicher entschlüsselt...\n",
+	"%sAccess to %d items was denied; they were not checked.%s\n":                      "%sAuf %d Einträge wurde der Zugriff verweigert; sie wurden nicht geprüft.%s\n",
+	"%sNo passwords found in the %s.%s\n":                                              "%sKeine Passwörter im %s gefunden.%s\n",
+	"Found %d passwords in the %s.\n\n":                                                "%d Passwörter im %s gefunden.\n\n",
+	"%sNo accounts or usernames in the input; -reuse has nothing to compare.%s\n":      "%sKeine Konten oder Benutzernamen in der Eingabe; -reuse hat nichts zu vergleichen.%s\n",
+	"\nPasswords shared by several accounts: %d\n":                                     "\nVon mehreren Konten genutzte Passwörter: %d\n",
+	"  %s%d accounts, seen %d times in breaches:%s %s\n":                               "  %s%d Konten, %d-mal in Datenlecks gesehen:%s %s\n",
+	"  %s%d accounts, found in breaches:%s %s\n":                                       "  %s%d Konten, in Datenlecks gefunden:%s %s\n",
+	"  %s%d accounts, not found in breaches:%s %s\n":                                   "  %s%d Konten, nicht in Datenlecks gefunden:%s %s\n",
+	"%sNo login entries found in vault.%s\n":                                           "%sKeine Anmeldeeinträge im Tresor gefunden.%s\n",
+	"Found %d login entries in vault.\n\n":                                             "%d Anmeldeeinträge im Tresor gefunden.\n\n",
+	"%sNo passwords to check.%s\n":                                                     "%sKeine Passwörter zu prüfen.%s\n",
+	"\nChecking password %d of %d...\n":                                                "\nPrüfe Passwort %d von %d...\n",
+	"Sampling %d of %d passwords (seed %d)\n":                                          "Stichprobe von %d aus %d Passwörtern (Seed %d)\n",
+	"Skipped %d lines without %s credentials\n":                                        "%d Zeilen ohne %s-Zugangsdaten übersprungen\n",
+	"Skipped %d passwords listed in %s\n":                                              "%d in %s aufgeführte Passwörter übersprungen\n",
+	"Collapsed %d duplicate passwords, %d left to check\n":                             "%d doppelte Passwörter zusammengefasst, %d bleiben zu prüfen\n",
+	"%sSkipped %d lines longer than %d bytes (line %s)%s\n":                            "%s%d Zeilen länger als %d Byte übersprungen (Zeile %s)%s\n",
+	"Resuming at item #%d of %d\n":                                                     "Fortsetzung bei Eintrag #%d von %d\n",
+	"item #%d":                                                                         "Eintrag #%d",
+	" (line %d)":                                                                       " (Zeile %d)",
+	"%sInterrupted: stopped at %s of %d; %d items were not checked.%s\n":               "%sUnterbrochen: angehalten bei %s von %d; %d Einträge wurden nicht geprüft.%s\n",
+	"%sDeadline of %s reached: %d of %d items were skipped.%s\n":                       "%sFrist von %s erreicht: %d von %d Einträgen wurden übersprungen.%s\n",
+	"The next run resumes at item #%d.\n":                                              "Der nächste Lauf setzt bei Eintrag #%d fort.\n",
+	"%sBudget of %s used up: %d of %d items remain, next run resumes at item #%d.%s\n": "%sZeitbudget von %s aufgebraucht: %d von %d Einträgen verbleiben, der nächste Lauf setzt bei Eintrag #%d fort.%s\n",
+	"Reached the end of the input; the next run starts over from item #1.\n":           "Ende der Eingabe erreicht; der nächste Lauf beginnt wieder bei Eintrag #1.\n",
+	"Results written to %s\n":                                                          "Ergebnisse nach %s geschrieben\n",
+	"Exposure: %d of %d cracked passwords (%.1f%%) are in public breach corpora\n":     "Gefährdung: %d von %d geknackten Passwörtern (%.1f%%) sind in öffentlichen Datenleck-Sammlungen\n",
+	"%s%d compromised passwords are within the -fail-threshold of %d.%s\n":             "%s%d kompromittierte Passwörter liegen innerhalb der -fail-threshold von %d.%s\n",
+	"%s%d lookups failed; those entries were not checked.%s\n":                         "%s%d Abfragen sind fehlgeschlagen; diese Einträge wurden nicht geprüft.%s\n",
+	"%s%d lookups failed in a row (%v); pausing %s before retrying.%s\n":               "%s%d Abfragen in Folge fehlgeschlagen (%v); neuer Versuch in %s.%s\n",
+	"%sAborted after %d API errors: %d of %d items were checked, %d were skipped.%s\n": "%sAbbruch nach %d API-Fehlern: %d von %d Einträgen wurden geprüft, %d übersprungen.%s\n",
+	"%sThe API is answering again; resuming.%s\n":                                      "%sDie API antwortet wieder; es geht weiter.%s\n",
+
+	// -stats summary
+	"\nTotal runtime: %s\n":                                                 "\nGesamtlaufzeit: %s\n",
+	"Total passwords checked: %d\n":                                         "Geprüfte Passwörter: %d\n",
+	"Throughput: %.1f checks/s\n":                                           "Durchsatz: %.1f Prüfungen/s\n",
+	"%sBad passwords found: %d%s\n":                                         "%sKompromittierte Passwörter: %d%s\n",
+	"%sGood passwords: %d%s\n":                                              "%sSichere Passwörter: %d%s\n",
+	"%sLookup errors: %d%s\n":                                               "%sFehlgeschlagene Abfragen: %d%s\n",
+	"%sSkipped after -max-errors: %d%s\n":                                   "%sNach -max-errors übersprungen: %d%s\n",
+	"%sSkipped at the deadline: %d%s\n":                                     "%sWegen der Frist übersprungen: %d%s\n",
+	"%sNew since the last run: %d%s\n":                                      "%sNeu seit dem letzten Lauf: %d%s\n",
+	"%sInterrupted before item #%d%s\n":                                     "%sUnterbrochen vor Eintrag #%d%s\n",
+	"Duplicates skipped: %d\n":                                              "Übersprungene Duplikate: %d\n",
+	"%sClean passwords with a breached variant: %d%s\n":                     "%sSichere Passwörter mit kompromittierter Variante: %d%s\n",
+	"%sPolicy violations: %d%s\n":                                           "%sRichtlinienverstöße: %d%s\n",
+	"%sWeak passwords not yet breached: %d%s\n":                             "%sSchwache, noch nicht kompromittierte Passwörter: %d%s\n",
+	"Ignored by ignore file: %d\n":                                          "Durch die Ignorierliste ausgelassen: %d\n",
+	"%sLines too long to check: %d%s\n":                                     "%sZu lange Zeilen: %d%s\n",
+	"%sPaused for API outages: %d times%s\n":                                "%sPausen wegen API-Ausfällen: %d%s\n",
+	"\nTop %d most exposed passwords:\n":                                    "\nDie %d am häufigsten kompromittierten Passwörter:\n",
+	" and %d more":                                                          " und %d weitere",
+	"Findings by times seen in breaches:\n":                                 "Verteilung der Funde nach Häufigkeit in Datenlecks:\n",
+	"unknown":                                                               "unbekannt",
+	"Findings by breach count:\n":                                           "Funde nach Häufigkeit in Datenlecks:\n",
+	"Tags: %s\n":                                                            "Tags: %s\n",
+	"%sRun not recorded in %s, since it left entries unchecked.%s\n":        "%sLauf nicht in %s gespeichert, da Einträge ungeprüft blieben.%s\n",
+	"Recorded as run %d in %s: %d new and %d fixed findings since run %d\n": "Als Lauf %d in %s gespeichert: %d neue und %d behobene Funde seit Lauf %d\n",
+	"Recorded as run %d in %s, the first of %s\n":                           "Als Lauf %d in %s gespeichert, der erste für %s\n",
+	"%sFailed to read the history: %v%s\n":                                  "%sVerlauf konnte nicht gelesen werden: %v%s\n",
+	"%sFailed to record the run in %s: %v%s\n":                              "%sLauf konnte nicht in %s gespeichert werden: %v%s\n",
+	"%sFailed to compare with the previous run: %v%s\n":                     "%sVergleich mit dem vorigen Lauf fehlgeschlagen: %v%s\n",
+	"%sGave up after %d retries with the API still unavailable: %d of %d items were checked, %d were skipped.%s\n": "%sAufgegeben nach %d Wiederholungen, die API ist weiter nicht erreichbar: %d von %d Einträgen wurden geprüft, %d übersprungen.%s\n",
+	"%sSkipped after -breaker-retries: %d%s\n":                                   "%sNach -breaker-retries übersprungen: %d%s\n",
+	"%sRun not recorded in %s, since it checked nothing.%s\n":                    "%sLauf nicht in %s gespeichert, da nichts geprüft wurde.%s\n",
+	"\n%sESTIMATE (random sample, not a full check)%s\n":                         "\n%sSCHÄTZUNG (Zufallsstichprobe, keine vollständige Prüfung)%s\n",
+	"Checked %d sampled passwords out of %d\n":                                   "%d Passwörter der Stichprobe aus %d geprüft\n",
+	"%sNo sampled password was checked successfully; no estimate available.%s\n": "%sKein Passwort der Stichprobe wurde erfolgreich geprüft; keine Schätzung möglich.%s\n",
+	"Estimated pwned rate: %.2f%% (95%% CI %.2f%% – %.2f%%)\n":                   "Geschätzter Anteil kompromittierter Passwörter: %.2f%% (95%%-KI %.2f%% – %.2f%%)\n",
+	"Estimated pwned passwords: %d – %d\n":                                       "Geschätzte kompromittierte Passwörter: %d – %d\n",
+}
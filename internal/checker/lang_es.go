@@ -0,0 +1,114 @@
+package checker
+
+// langES is the Spanish console text.
+var langES = lang{
+	// findings
+	"BAD PASSWORD — BREACH DETECTED":                    "CONTRASEÑA COMPROMETIDA — ENCONTRADA EN FILTRACIONES",
+	"BREACHED VARIANT — ":                               "VARIANTE COMPROMETIDA — ",
+	"POLICY VIOLATION":                                  "INCUMPLE LA POLÍTICA",
+	"WEAK PASSWORD — NOT BREACHED":                      "CONTRASEÑA DÉBIL — NO FILTRADA",
+	"%s%s (item #%d)%s\n":                               "%s%s (elemento #%d)%s\n",
+	"%sBAD PASSWORD FOUND%s\n":                          "%sCONTRASEÑA COMPROMETIDA%s\n",
+	"%sGood password%s\n":                               "%sContraseña segura%s\n",
+	"%sGood password%s  %s\n":                           "%sContraseña segura%s  %s\n",
+	"%sGood password (item #%d)%s\n":                    "%sContraseña segura (elemento #%d)%s\n",
+	"%sError: %s%s\n":                                   "%sError: %s%s\n",
+	"%sError checking %s: %s%s\n":                       "%sError al comprobar %s: %s%s\n",
+	"%sError (item #%d): %s%s\n":                        "%sError (elemento #%d): %s%s\n",
+	"  Password: %s\n":                                  "  Contraseña:     %s\n",
+	"  Password: %s (%d chars)\n":                       "  Contraseña:     %s (%d caracteres)\n",
+	"  Hash:     %s\n":                                  "  Hash:           %s\n",
+	"  File:     %s:%d\n":                               "  Archivo:        %s:%d\n",
+	"  Account:  %s\n":                                  "  Cuenta:         %s\n",
+	"  Username: %s\n":                                  "  Usuario:        %s\n",
+	"  Strength: ":                                      "  Robustez:       ",
+	"  Makeup:   ":                                      "  Composición:    ",
+	"  Policy:   ":                                      "  Política:       ",
+	"  Replace:  ":                                      "  Sustituir por:  ",
+	"  Replace with: ":                                  "  Sustituir por:  ",
+	"  Tags:     %s\n":                                  "  Etiquetas:      %s\n",
+	"  Tags: %s\n":                                      "  Etiquetas: %s\n",
+	"  Seen:     %d times in breaches\n":                "  Vista:          %d veces en filtraciones\n",
+	"  %sA %s variant appears %d times in breaches%s\n": "  %sUna variante (%s) aparece %d veces en filtraciones%s\n",
+	"%s%s%d/4%s (crack time: %s)\n":                     "%s%s%d/4%s (tiempo para descifrarla: %s)\n",
+	"%s%spass%s\n":                                      "%s%scumple%s\n",
+	"%s%sfail%s (%s)\n":                                 "%s%sno cumple%s (%s)\n",
+	"%s%d chars, %s, %.1f bits of entropy\n":            "%s%d caracteres, %s, %.1f bits de entropía\n",
+
+	// prompts and notes
+	"Password to check: ":                                                              "Contraseña a comprobar: ",
+	"%sNo password entered.%s\n":                                                       "%sNo se introdujo ninguna contraseña.%s\n",
+	"Enter Bitwarden Export Encryption Password: ":                                     "Contraseña de la exportación cifrada de Bitwarden: ",
+	"Decrypting vault file in-memory...\n":                                             "Descifrando la bóveda en memoria...\n",
+	"%sAccess to %d items was denied; they were not checked.%s\n":                      "%sSe denegó el acceso a %d elementos; no se comprobaron.%s\n",
+	"%sNo passwords found in the %s.%s\n":                                              "%sNo hay contraseñas en %s.%s\n",
+	"Found %d passwords in the %s.\n\n":                                                "%d contraseñas encontradas en %s.\n\n",
+	"%sNo accounts or usernames in the input; -reuse has nothing to compare.%s\n":      "%sNo hay cuentas ni usuarios en la entrada; -reuse no tiene nada que comparar.%s\n",
+	"\nPasswords shared by several accounts: %d\n":                                     "\nContraseñas compartidas por varias cuentas: %d\n",
+	"  %s%d accounts, seen %d times in breaches:%s %s\n":                               "  %s%d cuentas, vista %d veces en filtraciones:%s %s\n",
+	"  %s%d accounts, found in breaches:%s %s\n":                                       "  %s%d cuentas, encontrada en filtraciones:%s %s\n",
+	"  %s%d accounts, not found in breaches:%s %s\n":                                   "  %s%d cuentas, no encontrada en filtraciones:%s %s\n",
+	"%sNo login entries found in vault.%s\n":                                           "%sNo hay credenciales en la bóveda.%s\n",
+	"Found %d login entries in vault.\n\n":                                             "%d credenciales encontradas en la bóveda.\n\n",
+	"%sNo passwords to check.%s\n":                                                     "%sNo hay contraseñas que comprobar.%s\n",
+	"\nChecking password %d of %d...\n":                                                "\nComprobando la contraseña %d de %d...\n",
+	"Sampling %d of %d passwords (seed %d)\n":                                          "Muestra de %d de %d contraseñas (semilla %d)\n",
+	"Skipped %d lines without %s credentials\n":                                        "Omitidas %d líneas sin credenciales %s\n",
+	"Skipped %d passwords listed in %s\n":                                              "Omitidas %d contraseñas incluidas en %s\n",
+	"Collapsed %d duplicate passwords, %d left to check\n":                             "Agrupadas %d contraseñas duplicadas, quedan %d por comprobar\n",
+	"%sSkipped %d lines longer than %d bytes (line %s)%s\n":                            "%sOmitidas %d líneas de más de %d bytes (línea %s)%s\n",
+	"Resuming at item #%d of %d\n":                                                     "Reanudando en el elemento #%d de %d\n",
+	"item #%d":                                                                         "el elemento #%d",
+	" (line %d)":                                                                       " (línea %d)",
+	"%sInterrupted: stopped at %s of %d; %d items were not checked.%s\n":               "%sInterrumpido: detenido en %s de %d; %d elementos no se comprobaron.%s\n",
+	"%sDeadline of %s reached: %d of %d items were skipped.%s\n":                       "%sSe alcanzó el plazo de %s: se omitieron %d de %d elementos.%s\n",
+	"The next run resumes at item #%d.\n":                                              "La próxima ejecución se reanudará en el elemento #%d.\n",
+	"%sBudget of %s used up: %d of %d items remain, next run resumes at item #%d.%s\n": "%sSe agotó el tiempo de %s: quedan %d de %d elementos, la próxima ejecución se reanudará en el elemento #%d.%s\n",
+	"Reached the end of the input; the next run starts over from item #1.\n":           "Fin de la entrada; la próxima ejecución empezará de nuevo en el elemento #1.\n",
+	"Results written to %s\n":                                                          "Resultados guardados en %s\n",
+	"Exposure: %d of %d cracked passwords (%.1f%%) are in public breach corpora\n":     "Exposición: %d de %d contraseñas descifradas (%.1f%%) están en filtraciones públicas\n",
+	"%s%d compromised passwords are within the -fail-threshold of %d.%s\n":             "%s%d contraseñas comprometidas están dentro del -fail-threshold de %d.%s\n",
+	"%s%d lookups failed; those entries were not checked.%s\n":                         "%sFallaron %d consultas; esos elementos no se comprobaron.%s\n",
+	"%s%d lookups failed in a row (%v); pausing %s before retrying.%s\n":               "%sFallaron %d consultas seguidas (%v); nuevo intento dentro de %s.%s\n",
+	"%sAborted after %d API errors: %d of %d items were checked, %d were skipped.%s\n": "%sCancelado tras %d errores de la API: se comprobaron %d de %d elementos y se omitieron %d.%s\n",
+	"%sThe API is answering again; resuming.%s\n":                                      "%sLa API vuelve a responder; se reanuda la comprobación.%s\n",
+
+	// -stats summary
+	"\nTotal runtime: %s\n":                                                 "\nDuración total: %s\n",
+	"Total passwords checked: %d\n":                                         "Contraseñas comprobadas: %d\n",
+	"Throughput: %.1f checks/s\n":                                           "Rendimiento: %.1f comprobaciones/s\n",
+	"%sBad passwords found: %d%s\n":                                         "%sContraseñas comprometidas: %d%s\n",
+	"%sGood passwords: %d%s\n":                                              "%sContraseñas seguras: %d%s\n",
+	"%sLookup errors: %d%s\n":                                               "%sConsultas fallidas: %d%s\n",
+	"%sSkipped after -max-errors: %d%s\n":                                   "%sOmitidas tras -max-errors: %d%s\n",
+	"%sSkipped at the deadline: %d%s\n":                                     "%sOmitidas por el plazo: %d%s\n",
+	"%sNew since the last run: %d%s\n":                                      "%sNuevas desde la última ejecución: %d%s\n",
+	"%sInterrupted before item #%d%s\n":                                     "%sInterrumpido antes del elemento #%d%s\n",
+	"Duplicates skipped: %d\n":                                              "Duplicados omitidos: %d\n",
+	"%sClean passwords with a breached variant: %d%s\n":                     "%sContraseñas seguras con una variante comprometida: %d%s\n",
+	"%sPolicy violations: %d%s\n":                                           "%sIncumplimientos de la política: %d%s\n",
+	"%sWeak passwords not yet breached: %d%s\n":                             "%sContraseñas débiles aún no filtradas: %d%s\n",
+	"Ignored by ignore file: %d\n":                                          "Excluidas por el archivo de exclusión: %d\n",
+	"%sLines too long to check: %d%s\n":                                     "%sLíneas demasiado largas: %d%s\n",
+	"%sPaused for API outages: %d times%s\n":                                "%sPausas por caídas de la API: %d%s\n",
+	"\nTop %d most exposed passwords:\n":                                    "\nLas %d contraseñas más expuestas:\n",
+	" and %d more":                                                          " y %d más",
+	"Findings by times seen in breaches:\n":                                 "Hallazgos por veces vistos en filtraciones:\n",
+	"unknown":                                                               "desconocido",
+	"Findings by breach count:\n":                                           "Hallazgos por número de filtraciones:\n",
+	"Tags: %s\n":                                                            "Etiquetas: %s\n",
+	"%sRun not recorded in %s, since it left entries unchecked.%s\n":        "%sEjecución no registrada en %s, ya que quedaron entradas sin comprobar.%s\n",
+	"Recorded as run %d in %s: %d new and %d fixed findings since run %d\n": "Registrada como ejecución %d en %s: %d hallazgos nuevos y %d corregidos desde la ejecución %d\n",
+	"Recorded as run %d in %s, the first of %s\n":                           "Registrada como ejecución %d en %s, la primera de %s\n",
+	"%sFailed to read the history: %v%s\n":                                  "%sNo se pudo leer el historial: %v%s\n",
+	"%sFailed to record the run in %s: %v%s\n":                              "%sNo se pudo registrar la ejecución en %s: %v%s\n",
+	"%sFailed to compare with the previous run: %v%s\n":                     "%sNo se pudo comparar con la ejecución anterior: %v%s\n",
+	"%sGave up after %d retries with the API still unavailable: %d of %d items were checked, %d were skipped.%s\n": "%sAbandonado tras %d reintentos con la API aún inaccesible: se comprobaron %d de %d elementos y se omitieron %d.%s\n",
+	"%sSkipped after -breaker-retries: %d%s\n":                                   "%sOmitidas tras -breaker-retries: %d%s\n",
+	"%sRun not recorded in %s, since it checked nothing.%s\n":                    "%sEjecución no registrada en %s, ya que no se comprobó nada.%s\n",
+	"\n%sESTIMATE (random sample, not a full check)%s\n":                         "\n%sESTIMACIÓN (muestra aleatoria, no una comprobación completa)%s\n",
+	"Checked %d sampled passwords out of %d\n":                                   "Comprobadas %d contraseñas de la muestra de un total de %d\n",
+	"%sNo sampled password was checked successfully; no estimate available.%s\n": "%sNo se comprobó correctamente ninguna contraseña de la muestra; no hay estimación.%s\n",
+	"Estimated pwned rate: %.2f%% (95%% CI %.2f%% – %.2f%%)\n":                   "Tasa estimada de contraseñas comprometidas: %.2f%% (IC 95%% %.2f%% – %.2f%%)\n",
+	"Estimated pwned passwords: %d – %d\n":                                       "Contraseñas comprometidas estimadas: %d – %d\n",
+}
@@ -0,0 +1,20 @@
+package checker
+
+import "github.com/mohamedation/PwnedCheck/internal/lookup"
+
+// The Checker behind the public pwnedcheck package lives in internal/lookup;
+// the command builds on it under these names.
+type (
+	Checker          = lookup.Checker
+	Options          = lookup.Options
+	Result           = lookup.Result
+	InvalidHashError = lookup.InvalidHashError
+)
+
+func New(opts Options) *Checker {
+	return lookup.New(opts)
+}
+
+func validateHash(s string, ntlm bool) error {
+	return lookup.ValidateHash(s, ntlm)
+}
@@ -0,0 +1,230 @@
+package checker
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// mailTimeout bounds the whole SMTP conversation.
+const mailTimeout = 30 * time.Second
+
+// mailWriter collects the findings of a run and, when there are any, mails
+// a summary with the HTML report attached. As an alerts sink it only sees
+// new findings with -every, so an unchanged audit sends nothing.
+type mailWriter struct {
+	cfg Config
+	// from and to are the bare envelope addresses.
+	from   string
+	to     []string
+	report *htmlReport
+	html   bytes.Buffer
+}
+
+func newMailWriter(cfg Config) (*mailWriter, error) {
+	if _, _, err := parseSMTP(cfg.SMTPServer); err != nil {
+		return nil, err
+	}
+	if cfg.MailFrom == "" || len(cfg.MailTo) == 0 {
+		return nil, fmt.Errorf("-smtp needs -mail-from and -mail-to")
+	}
+	m := &mailWriter{cfg: cfg}
+	for i, a := range append([]string{cfg.MailFrom}, cfg.MailTo...) {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", a, err)
+		}
+		if i == 0 {
+			m.from = addr.Address
+		} else {
+			m.to = append(m.to, addr.Address)
+		}
+	}
+	m.report = newHTMLReport(&m.html, cfg)
+	return m, nil
+}
+
+// parseSMTP accepts smtp://host:port, which upgrades with STARTTLS when the
+// server offers it, and smtps://host:port for TLS from the start.
+func parseSMTP(server string) (addr string, implicitTLS bool, err error) {
+	u, err := url.Parse(server)
+	if err != nil || (u.Scheme != "smtp" && u.Scheme != "smtps") || u.Hostname() == "" {
+		return "", false, fmt.Errorf("invalid -smtp %q, expected smtp://host:port or smtps://host:port", server)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "587"
+		if u.Scheme == "smtps" {
+			port = "465"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), u.Scheme == "smtps", nil
+}
+
+func (m *mailWriter) write(rec record) error {
+	return m.report.write(rec)
+}
+
+func (m *mailWriter) close(summary runSummary) error {
+	findings := m.report.findings
+	if len(findings) == 0 {
+		return nil
+	}
+	if err := m.report.close(summary); err != nil {
+		return err
+	}
+	msg, err := m.message(summary, findings)
+	if err != nil {
+		return err
+	}
+	if err := sendMail(m.cfg.SMTPServer, m.cfg.SMTPUser, m.cfg.SMTPPassword, m.from, m.to, msg); err != nil {
+		return fmt.Errorf("failed to send the findings email: %w", err)
+	}
+	return nil
+}
+
+// message builds the email. It names the affected entries but, like the
+// report, never carries a password or its hash.
+func (m *mailWriter) message(summary runSummary, findings []record) ([]byte, error) {
+	host, _ := os.Hostname()
+	source := m.report.source
+	kind := "compromised passwords"
+	if m.cfg.Every > 0 {
+		kind = "new compromised passwords"
+	}
+	subject := fmt.Sprintf("PwnedCheck: %d %s in %s", len(findings), kind, source)
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "PwnedCheck found %d %s in %s on %s.\n\n", len(findings), kind, source, cmp.Or(host, "an unknown host"))
+	fmt.Fprintf(&text, "Checked: %d, bad: %d, good: %d, errors: %d, runtime: %s\n", summary.Total, summary.Bad, summary.Good, summary.Errors, summary.Runtime)
+	if len(m.cfg.Tags) > 0 {
+		fmt.Fprintf(&text, "Tags: %s\n", formatTags(m.cfg.Tags))
+	}
+	fmt.Fprintf(&text, "\n")
+	for _, f := range findings {
+		name := strings.TrimSpace(f.Account + " " + f.Username)
+		if f.Line > 0 {
+			name = strings.TrimSpace(fmt.Sprintf("%s:%d %s", f.Source, f.Line, name))
+		}
+		fmt.Fprintf(&text, "  #%d %s, seen %d times\n", f.Item, cmp.Or(name, "(unnamed)"), f.Count)
+	}
+	fmt.Fprintf(&text, "\nThe attached report has the details. Rotate these credentials.\n")
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "From: %s\r\n", m.cfg.MailFrom)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.cfg.MailTo, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", rand.Text(), cmp.Or(host, "pwnedcheck"))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(part, []byte(text.String()))
+	part, err = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {`text/html; charset=utf-8; name="pwnedcheck-report.html"`},
+		"Content-Disposition":       {`attachment; filename="pwnedcheck-report.html"`},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(part, m.html.Bytes())
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeBase64 wraps the encoding at 76 columns, as RFC 2045 asks.
+func writeBase64(w io.Writer, data []byte) {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		fmt.Fprintf(w, "%s\r\n", enc[:76])
+		enc = enc[76:]
+	}
+	fmt.Fprintf(w, "%s\r\n", enc)
+}
+
+// sendMail delivers msg from one address to the others. Credentials are
+// only sent over TLS; net/smtp refuses plain authentication otherwise,
+// except to localhost.
+func sendMail(server, user, password, from string, to []string, msg []byte) error {
+	addr, implicitTLS, err := parseSMTP(server)
+	if err != nil {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	tlsConfig := &tls.Config{ServerName: host}
+
+	dialer := &net.Dialer{Timeout: mailTimeout}
+	var conn net.Conn
+	if implicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(mailTimeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if user != "" {
+		if err := c.Auth(smtp.PlainAuth("", user, password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,22 @@
+package checker
+
+import (
+	"fmt"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizer returns the Unicode normalization for -normalize, or nil when
+// passwords are hashed as they are. Export tools disagree on the form, so
+// the same visual password can otherwise hash differently.
+func normalizer(form string) (func(string) string, error) {
+	switch form {
+	case "", "none":
+		return nil, nil
+	case "nfc":
+		return norm.NFC.String, nil
+	case "nfkc":
+		return norm.NFKC.String, nil
+	}
+	return nil, fmt.Errorf("unknown -normalize %q (available: nfc, nfkc)", form)
+}
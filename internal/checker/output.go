@@ -0,0 +1,376 @@
+package checker
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	statusPwned = "pwned"
+	statusClean = "clean"
+	statusError = "error"
+	// statusSkipped marks entries never looked up because -deadline passed.
+	statusSkipped = "skipped"
+)
+
+const (
+	formatText     = "text"
+	formatTable    = "table"
+	formatCSV      = "csv"
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+	formatSARIF    = "sarif"
+	formatJUnit    = "junit"
+)
+
+var formats = []string{formatText, formatTable, formatCSV, formatJSON, formatMarkdown, formatSARIF, formatJUnit}
+
+var formatExtensions = map[string]string{
+	".json":  formatJSON,
+	".csv":   formatCSV,
+	".txt":   formatTable,
+	".md":    formatMarkdown,
+	".sarif": formatSARIF,
+	".xml":   formatJUnit,
+}
+
+func formatFromExtension(path string) (string, error) {
+	if f, ok := formatExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return f, nil
+	}
+	return "", fmt.Errorf("cannot infer the output format of %s; set it with -format", path)
+}
+
+// allFields lists every column a structured output can carry, in the order
+// they are documented.
+var allFields = []string{"item", "line", "source", "account", "username", "folder", "password", "hash", "status", "count", "error",
+	"strength", "crack_time", "length", "classes", "entropy", "policy", "violations", "variant", "variant_count",
+	"first_seen", "last_seen"}
+
+var (
+	// strengthFields need -strength; they join the defaults when it is set.
+	strengthFields = []string{"strength", "crack_time"}
+	// analysisFields need -analyze, likewise.
+	analysisFields = []string{"length", "classes", "entropy"}
+	policyFields   = []string{"policy", "violations"}
+	variantFields  = []string{"variant", "variant_count"}
+	historyFields  = []string{"first_seen", "last_seen"}
+)
+
+var defaultFields = []string{"item", "source", "account", "username", "status", "count"}
+
+// parseFields validates a comma-separated field list. An empty spec selects
+// the default fields.
+func parseFields(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return defaultFields, nil
+	}
+
+	var fields []string
+	for _, f := range strings.Split(spec, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if !slices.Contains(allFields, f) {
+			return nil, fmt.Errorf("unknown field %q (available: %s)", f, strings.Join(allFields, ","))
+		}
+		if !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// record is one checked entry as seen by the structured outputs.
+type record struct {
+	Item      int
+	Line      int
+	Source    string
+	Account   string
+	Username  string
+	Folder    string
+	Password  string
+	Hash      string
+	Status    string
+	Count     int
+	Error     string
+	Strength  int
+	CrackTime string
+	Length    int
+	Classes   string
+	Entropy   float64
+	// Policy is pass or fail with -policy; Violations lists the broken rules.
+	Policy     string
+	Violations []string
+	// Variant names the kind of transformation found pwned with -variants.
+	Variant      string
+	VariantCount int
+	// Masked is the password as -top and -export-bad show it, empty when it
+	// may not be shown at all.
+	Masked string
+	// FirstSeen and LastSeen date a finding's earlier runs in -history-db.
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+func (r record) value(field string) any {
+	switch field {
+	case "item":
+		return r.Item
+	case "line":
+		return r.Line
+	case "source":
+		return r.Source
+	case "account":
+		return r.Account
+	case "username":
+		return r.Username
+	case "folder":
+		return r.Folder
+	case "password":
+		return r.Password
+	case "hash":
+		return r.Hash
+	case "status":
+		return r.Status
+	case "count":
+		return r.Count
+	case "error":
+		return r.Error
+	case "strength":
+		return r.Strength
+	case "crack_time":
+		return r.CrackTime
+	case "length":
+		return r.Length
+	case "classes":
+		return r.Classes
+	case "entropy":
+		return r.Entropy
+	case "policy":
+		return r.Policy
+	case "violations":
+		return r.Violations
+	case "variant":
+		return r.Variant
+	case "variant_count":
+		return r.VariantCount
+	case "first_seen":
+		return seenText(r.FirstSeen)
+	case "last_seen":
+		return seenText(r.LastSeen)
+	}
+	return nil
+}
+
+func (r record) strings(fields []string) []string {
+	row := make([]string, len(fields))
+	for i, f := range fields {
+		switch v := r.value(f).(type) {
+		case int:
+			row[i] = strconv.Itoa(v)
+		case float64:
+			row[i] = strconv.FormatFloat(v, 'f', 1, 64)
+		case []string:
+			row[i] = strings.Join(v, "; ")
+		case string:
+			row[i] = v
+		}
+	}
+	return row
+}
+
+type resultWriter interface {
+	write(rec record) error
+	// close finishes the document; summary is only used by formats that
+	// embed it.
+	close(summary runSummary) error
+}
+
+// Tag is a run-level key=value label attached to every result and summary.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+func tagKeys(tags []Tag) []string {
+	keys := make([]string, len(tags))
+	for i, t := range tags {
+		keys[i] = t.Key
+	}
+	return keys
+}
+
+func tagValues(tags []Tag) []string {
+	values := make([]string, len(tags))
+	for i, t := range tags {
+		values[i] = t.Value
+	}
+	return values
+}
+
+// tagObject renders tags as a JSON object, keeping their command-line order.
+func tagObject(tags []Tag) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, t := range tags {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		k, _ := json.Marshal(t.Key)
+		v, _ := json.Marshal(t.Value)
+		b.Write(k)
+		b.WriteString(": ")
+		b.Write(v)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// newResultWriter builds the writer for format. Tags become trailing
+// columns in table and CSV output, a "tags" object in JSON and a list under
+// the Markdown totals. hashes says the password column holds -print-hash
+// hashes, which the Markdown findings need not mask.
+func newResultWriter(format string, fields []string, tags []Tag, hashes bool, w io.Writer) (resultWriter, error) {
+	switch format {
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := append(slices.Clone(fields), tagKeys(tags)...)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		return &tableWriter{tw: tw, fields: fields, tags: tagValues(tags)}, nil
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(append(slices.Clone(fields), tagKeys(tags)...)); err != nil {
+			return nil, err
+		}
+		return &csvWriter{cw: cw, fields: fields, tags: tagValues(tags)}, nil
+	case formatJSON:
+		return &jsonWriter{w: w, fields: fields, tags: tags}, nil
+	case formatMarkdown:
+		return &markdownWriter{w: w, fields: fields, tags: tags, hashes: hashes}, nil
+	case formatSARIF:
+		return &sarifWriter{w: w}, nil
+	case formatJUnit:
+		return &junitWriter{w: w, tags: tags}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(formats, ", "))
+}
+
+type tableWriter struct {
+	tw     *tabwriter.Writer
+	fields []string
+	tags   []string
+}
+
+func (t *tableWriter) write(rec record) error {
+	row := append(rec.strings(t.fields), t.tags...)
+	_, err := fmt.Fprintln(t.tw, strings.Join(row, "\t"))
+	return err
+}
+
+func (t *tableWriter) close(runSummary) error {
+	return t.tw.Flush()
+}
+
+type csvWriter struct {
+	cw     *csv.Writer
+	fields []string
+	tags   []string
+}
+
+func (c *csvWriter) write(rec record) error {
+	if err := c.cw.Write(append(rec.strings(c.fields), c.tags...)); err != nil {
+		return err
+	}
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+func (c *csvWriter) close(runSummary) error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// jsonWriter streams {"results": [...], "summary": {...}} so results never
+// have to be held in memory.
+type jsonWriter struct {
+	w      io.Writer
+	fields []string
+	tags   []Tag
+	n      int
+}
+
+func (j *jsonWriter) write(rec record) error {
+	sep := ",\n    "
+	if j.n == 0 {
+		sep = "{\n  \"results\": [\n    "
+	}
+	j.n++
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i, f := range j.fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		key, _ := json.Marshal(f)
+		val, err := json.Marshal(rec.value(f))
+		if err != nil {
+			return err
+		}
+		b.Write(key)
+		b.WriteString(": ")
+		b.Write(val)
+	}
+	if len(j.tags) > 0 {
+		b.WriteString(", \"tags\": ")
+		b.WriteString(tagObject(j.tags))
+	}
+	b.WriteString("}")
+
+	_, err := io.WriteString(j.w, sep+b.String())
+	return err
+}
+
+func (j *jsonWriter) close(summary runSummary) error {
+	head := "\n  ],\n"
+	if j.n == 0 {
+		head = "{\n  \"results\": [],\n"
+	}
+	s, err := json.MarshalIndent(summary, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(j.w, "%s  \"summary\": %s\n}\n", head, s)
+	return err
+}
+
+// syslogMessage renders a result as key=value pairs without the password or
+// hash, since syslog usually ends up in a shared aggregator.
+func syslogMessage(rec record, tags string) string {
+	parts := []string{rec.Status, "item=" + strconv.Itoa(rec.Item), "source=" + strconv.Quote(rec.Source)}
+	if rec.Line > 0 {
+		parts = append(parts, "line="+strconv.Itoa(rec.Line))
+	}
+	if rec.Account != "" {
+		parts = append(parts, "account="+strconv.Quote(rec.Account))
+	}
+	if rec.Username != "" {
+		parts = append(parts, "username="+strconv.Quote(rec.Username))
+	}
+	if rec.Status == statusError {
+		parts = append(parts, "error="+strconv.Quote(rec.Error))
+	} else {
+		parts = append(parts, "count="+strconv.Itoa(rec.Count))
+	}
+	if tags != "" {
+		parts = append(parts, tags)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	policyPass = "pass"
+	policyFail = "fail"
+)
+
+var characterClasses = []string{"lower", "upper", "digit", "symbol"}
+
+// policy is the -policy file. Every rule is optional.
+type policy struct {
+	MinLength int `yaml:"min_length"`
+	// Require lists classes every password must use.
+	Require []string `yaml:"require"`
+	// MinClasses is how many distinct classes a password must use.
+	MinClasses int `yaml:"min_classes"`
+	// Banned substrings are matched case-insensitively.
+	Banned []string `yaml:"banned_substrings"`
+}
+
+func loadPolicy(path string) (*policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var p policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	for _, c := range p.Require {
+		if !slices.Contains(characterClasses, c) {
+			return nil, fmt.Errorf("invalid policy %s: unknown class %q (available: %s)", path, c, strings.Join(characterClasses, ", "))
+		}
+	}
+	if p.MinLength < 0 || p.MinClasses < 0 || p.MinClasses > len(characterClasses) {
+		return nil, fmt.Errorf("invalid policy %s: min_length and min_classes must be between 0 and the possible maximum", path)
+	}
+	for i, b := range p.Banned {
+		p.Banned[i] = strings.ToLower(b)
+	}
+	return &p, nil
+}
+
+// evaluate returns every rule the password breaks.
+func (p *policy) evaluate(password string) []string {
+	c := analyze(password)
+	var violations []string
+	if c.Length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("shorter than %d characters", p.MinLength))
+	}
+	for _, want := range p.Require {
+		if !slices.Contains(c.Classes, want) {
+			violations = append(violations, "missing "+want)
+		}
+	}
+	if len(c.Classes) < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("uses fewer than %d character classes", p.MinClasses))
+	}
+	lower := strings.ToLower(password)
+	for _, b := range p.Banned {
+		if b != "" && strings.Contains(lower, b) {
+			violations = append(violations, fmt.Sprintf("contains banned %q", b))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,126 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const progressWidth = 30
+
+// rateWindow is how far back the current rate looks, so the rate and ETA
+// follow cache hits and rate limiting instead of the run's average.
+const rateWindow = 10 * time.Second
+
+// throughput estimates the current checks per second from the progress
+// of the last rateWindow.
+type throughput struct {
+	samples []rateSample
+}
+
+type rateSample struct {
+	at   time.Time
+	done int
+}
+
+func newThroughput(start time.Time) *throughput {
+	return &throughput{samples: []rateSample{{at: start}}}
+}
+
+// add records done checks at now. Samples are kept a quarter second
+// apart, dropping those older than the window except the newest of them.
+func (t *throughput) add(done int, now time.Time) {
+	if last := t.samples[len(t.samples)-1]; now.Sub(last.at) < rateWindow/40 && len(t.samples) > 1 {
+		t.samples[len(t.samples)-1] = rateSample{now, done}
+		return
+	}
+	t.samples = append(t.samples, rateSample{now, done})
+	for len(t.samples) > 2 && now.Sub(t.samples[1].at) > rateWindow {
+		t.samples = t.samples[1:]
+	}
+}
+
+// rate is the checks per second over the window.
+func (t *throughput) rate() float64 {
+	first, last := t.samples[0], t.samples[len(t.samples)-1]
+	if elapsed := last.at.Sub(first.at).Seconds(); elapsed > 0 {
+		return float64(last.done-first.done) / elapsed
+	}
+	return 0
+}
+
+// eta is the time the remaining checks take at the current rate, "--"
+// before there is a rate.
+func (t *throughput) eta(remaining int) string {
+	rate := t.rate()
+	if rate <= 0 {
+		return "--"
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second)).Round(time.Second).String()
+}
+
+// statusInterval spaces the progress lines -stats prints without a bar.
+const statusInterval = 30 * time.Second
+
+// progress draws a single-line bar on stdout. It is a no-op when stdout is
+// not a terminal, so redirected output only contains findings, unless
+// lines is set: then it prints a status line there every statusInterval.
+type progress struct {
+	enabled bool
+	total   int
+	rate    *throughput
+	lines   io.Writer
+	last    time.Time
+}
+
+// statusLines is the progress of -stats runs that have no bar.
+func statusLines(total int, w io.Writer) *progress {
+	now := time.Now()
+	return &progress{total: total, rate: newThroughput(now), lines: w, last: now}
+}
+
+func newProgress(total int) *progress {
+	return &progress{
+		enabled: term.IsTerminal(int(os.Stdout.Fd())) && enableVirtualTerminal(os.Stdout),
+		total:   total,
+		rate:    newThroughput(time.Now()),
+	}
+}
+
+func (p *progress) update(done int, stats *statistics) {
+	if !p.enabled && p.lines == nil || p.total == 0 {
+		return
+	}
+	now := time.Now()
+	p.rate.add(done, now)
+	if p.lines != nil {
+		if now.Sub(p.last) >= statusInterval {
+			p.last = now
+			fmt.Fprintf(p.lines, "Progress: %d/%d (%d%%), %.1f checks/s, ETA %s, bad %d, good %d, errors %d\n",
+				done, p.total, 100*done/p.total, p.rate.rate(), p.rate.eta(p.total-done), stats.badPasswords, stats.goodPasswords, stats.errored)
+		}
+		return
+	}
+
+	filled := progressWidth * done / p.total
+	bar := strings.Repeat("#", filled) + strings.Repeat(".", progressWidth-filled)
+
+	errs := ""
+	if stats.errored > 0 {
+		errs = fmt.Sprintf("  %serrors %d%s", colorYellow, stats.errored, colorReset)
+	}
+	fmt.Printf("\r\033[K[%s] %3d%% %d/%d  %.1f/s  ETA %s  %sbad %d%s  %sgood %d%s%s",
+		bar, 100*done/p.total, done, p.total, p.rate.rate(), p.rate.eta(p.total-done),
+		colorRed, stats.badPasswords, colorReset, colorGreen, stats.goodPasswords, colorReset, errs)
+}
+
+// clear wipes the bar so a finding can be printed on a clean line.
+func (p *progress) clear() {
+	if p.enabled {
+		fmt.Print("\r\033[K")
+	}
+}
@@ -0,0 +1,180 @@
+package checker
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/history"
+)
+
+// openAPISpec describes the proxy's endpoints, for generated clients.
+//
+//go:embed templates/openapi.json
+var openAPISpec []byte
+
+// uiRuns is how many runs the web page lists.
+const uiRuns = 50
+
+// uiHTML is the web page the proxy serves at /.
+//
+//go:embed templates/ui.html
+var uiHTML []byte
+
+// Proxy serves the HIBP range API on cfg.Listen, answering from the shared
+// cache and forwarding misses upstream under the configured rate limit, so
+// every tool in an organization can point at one internal endpoint, with
+// /healthz and /readyz for orchestrators and /openapi.json describing it
+// all. / serves a web page that checks passwords through the range API and
+// lists the runs of cfg.HistoryDB. It runs until SIGINT or SIGTERM.
+func Proxy(cfg Config) int {
+	log := newLogger(cfg.Verbosity)
+	client, err := newClient(cfg, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return clientExit(err)
+	}
+	defer client.Close()
+	var store *history.Store
+	if cfg.HistoryDB != "" {
+		var err error
+		if store, err = history.Open(cfg.HistoryDB); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitError
+		}
+		defer store.Close()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /range/{prefix}", func(w http.ResponseWriter, req *http.Request) {
+		serveRange(client, w, req)
+	})
+	ready := newReadiness(client)
+	mux.HandleFunc("GET /healthz", serveHealth)
+	mux.HandleFunc("GET /readyz", ready.serveReady)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	mux.HandleFunc("GET /{$}", serveUI)
+	if store != nil {
+		mux.HandleFunc("GET /api/runs", func(w http.ResponseWriter, _ *http.Request) {
+			serveRuns(store, w)
+		})
+	}
+	srv := &http.Server{Addr: cfg.Listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving the range API on %s\n", cfg.Listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	cs := client.CacheStats()
+	fmt.Fprintf(os.Stderr, "Stopped. Cache: %d ranges served locally, %d fetched, %d revalidated\n", cs.RangeHits, cs.Misses, cs.Revalidated)
+	return exitOK
+}
+
+// serveRange answers like api.pwnedpasswords.com: SUFFIX:COUNT lines for the
+// prefix, with mode=ntlm selecting the NTLM corpus. Upstream failures become
+// 502 so clients can tell them from their own mistakes.
+func serveRange(client *Checker, w http.ResponseWriter, req *http.Request) {
+	prefix := strings.ToUpper(req.PathValue("prefix"))
+	if len(prefix) != 5 || !isHex(prefix) {
+		http.Error(w, "the hash prefix must be 5 hex characters", http.StatusBadRequest)
+		return
+	}
+	var ntlm bool
+	switch mode := req.URL.Query().Get("mode"); mode {
+	case "", "sha1":
+	case "ntlm":
+		ntlm = true
+	default:
+		http.Error(w, "unknown mode "+strconv.Quote(mode), http.StatusBadRequest)
+		return
+	}
+
+	suffixes, err := client.Range(prefix, ntlm)
+	if err != nil {
+		client.Logger().Warn("upstream range request failed", "prefix", prefix, "err", err)
+		http.Error(w, "upstream request failed", http.StatusBadGateway)
+		return
+	}
+
+	keys := make([]string, 0, len(suffixes))
+	for s := range suffixes {
+		keys = append(keys, s)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	for _, s := range keys {
+		fmt.Fprintf(&b, "%s:%d\r\n", s, suffixes[s])
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(b.String()))
+}
+
+// serveUI sends the web page. Its script and styles are inline and it only
+// talks to the proxy, which the policy enforces; it may not be framed.
+func serveUI(w http.ResponseWriter, _ *http.Request) {
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; frame-ancestors 'none'; form-action 'none'")
+	h.Set("Referrer-Policy", "no-referrer")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.Write(uiHTML)
+}
+
+// apiRun is a recorded run as /api/runs lists it. It carries totals only:
+// the proxy has no authentication, so findings are not named.
+type apiRun struct {
+	ID       int64     `json:"id"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Source   string    `json:"source"`
+	Checked  int       `json:"checked"`
+	Pwned    int       `json:"pwned"`
+	Clean    int       `json:"clean"`
+	// New and Fixed compare with the previous run of the source; null for
+	// the first.
+	New   *int `json:"new"`
+	Fixed *int `json:"fixed"`
+}
+
+// serveRuns lists the last runs of the history, oldest first.
+func serveRuns(store *history.Store, w http.ResponseWriter) {
+	runs, err := trend(store, "", uiRuns)
+	if err != nil {
+		http.Error(w, "failed to read the history", http.StatusInternalServerError)
+		return
+	}
+	list := make([]apiRun, len(runs))
+	for i, r := range runs {
+		list[i] = apiRun{ID: r.ID, Started: r.Started, Finished: r.Finished, Source: r.Source,
+			Checked: r.Checked, Pwned: r.Pwned, Clean: r.Clean}
+		if r.Compared {
+			list[i].New, list[i].Fixed = &r.New, &r.Fixed
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(list)
+}
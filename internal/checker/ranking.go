@@ -0,0 +1,181 @@
+package checker
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// topSources is how many places -top names for one password.
+const topSources = 3
+
+// rankedFinding is what the -stats list by breach count and -top keep of a
+// finding.
+type rankedFinding struct {
+	count, item, line int
+	account, username string
+	source            string
+	hash, masked      string
+}
+
+// rank keeps rec for the list by breach count when it is a finding.
+func (s *statistics) rank(rec record) {
+	if rec.Status == statusPwned {
+		s.ranked = append(s.ranked, rankedFinding{
+			count: rec.Count, item: rec.Item, line: rec.Line, account: rec.Account, username: rec.Username,
+			source: rec.Source, hash: rec.Hash, masked: rec.Masked,
+		})
+	}
+}
+
+// byCount returns the findings most exposed first, keeping input order
+// among those seen equally often.
+func (s *statistics) byCount() []rankedFinding {
+	ranked := slices.Clone(s.ranked)
+	slices.SortStableFunc(ranked, func(a, b rankedFinding) int { return cmp.Compare(b.count, a.count) })
+	return ranked
+}
+
+// printRanking lists the findings most exposed first, so remediation can
+// start with them; findings seen equally often stay in input order.
+func (s *statistics) printRanking(w io.Writer, l lang) {
+	if len(s.ranked) == 0 {
+		return
+	}
+	ranked := s.byCount()
+	width := len(strconv.Itoa(ranked[0].count))
+	l.fprintf(w, "Findings by breach count:\n")
+	for _, f := range ranked {
+		fmt.Fprintf(w, "  %s%*s%s  %s\n", colorRed, width, countText(f.count), colorReset, f.label(l))
+	}
+}
+
+// printTop lists the n most breached distinct passwords found, masked, with
+// the places each was found; a password found in several places is listed
+// once.
+func (s *statistics) printTop(w io.Writer, l lang, n int) {
+	type top struct {
+		rankedFinding
+		places []string
+	}
+	var tops []*top
+	byHash := map[string]*top{}
+	for _, f := range s.byCount() {
+		t := byHash[f.hash]
+		if t == nil {
+			if len(tops) == n {
+				continue
+			}
+			t = &top{rankedFinding: f}
+			byHash[f.hash] = t
+			tops = append(tops, t)
+		}
+		t.places = append(t.places, f.place(l))
+	}
+	if len(tops) == 0 {
+		return
+	}
+
+	width, maskWidth := len(strconv.Itoa(tops[0].count)), 0
+	for _, t := range tops {
+		maskWidth = max(maskWidth, utf8.RuneCountInString(t.masked))
+	}
+	l.fprintf(w, "\nTop %d most exposed passwords:\n", len(tops))
+	for i, t := range tops {
+		places := strings.Join(t.places[:min(len(t.places), topSources)], ", ")
+		if len(t.places) > topSources {
+			places += fmt.Sprintf(l.tr(" and %d more"), len(t.places)-topSources)
+		}
+		masked := t.masked + strings.Repeat(" ", maskWidth-utf8.RuneCountInString(t.masked))
+		if maskWidth > 0 {
+			masked += "  "
+		}
+		fmt.Fprintf(w, "  %2d. %s%*s%s  %s%s\n", i+1, colorRed, width, countText(t.count), colorReset, masked, places)
+	}
+}
+
+// countText is a breach count; -bloom findings carry none.
+func countText(count int) string {
+	if count == 0 {
+		return "?"
+	}
+	return strconv.Itoa(count)
+}
+
+// label names the finding by account and username, or by its position when
+// the input has neither.
+func (f rankedFinding) label(l lang) string {
+	switch {
+	case f.account != "" && f.username != "":
+		return f.account + " (" + f.username + ")"
+	case f.account != "" || f.username != "":
+		return f.account + f.username
+	}
+	label := fmt.Sprintf(l.tr("item #%d"), f.item)
+	if f.line > 0 {
+		label += fmt.Sprintf(l.tr(" (line %d)"), f.line)
+	}
+	return label
+}
+
+// place is where -top says the finding was: its label, or the file and line
+// for inputs without accounts.
+func (f rankedFinding) place(l lang) string {
+	if f.account == "" && f.username == "" && f.line > 0 && f.source != "" {
+		return f.source + ":" + strconv.Itoa(f.line)
+	}
+	return f.label(l)
+}
+
+// prevalenceBuckets are the lower bounds of the -stats histogram ranges,
+// a decade each; the last range is open.
+var prevalenceBuckets = []int{1, 10, 100, 1_000, 10_000, 100_000, 1_000_000}
+
+var prevalenceLabels = []string{"1–10", "10–100", "100–1k", "1k–10k", "10k–100k", "100k–1M", "1M+"}
+
+// histogramWidth is the length of the longest histogram bar.
+const histogramWidth = 40
+
+// printHistogram buckets the findings by breach count, from the first range
+// to the highest one holding any, so the shape of the exposure shows at a
+// glance. Findings without a count have a bar of their own.
+func (s *statistics) printHistogram(w io.Writer, l lang) {
+	if len(s.ranked) == 0 {
+		return
+	}
+	counts := make([]int, len(prevalenceBuckets))
+	unknown, last := 0, 0
+	for _, f := range s.ranked {
+		if f.count == 0 {
+			unknown++
+			continue
+		}
+		i := 0
+		for i+1 < len(prevalenceBuckets) && f.count >= prevalenceBuckets[i+1] {
+			i++
+		}
+		counts[i]++
+		last = max(last, i)
+	}
+	peak := max(slices.Max(counts), unknown)
+	bar := func(label string, n int) {
+		filled := n * histogramWidth / peak
+		if n > 0 {
+			filled = max(filled, 1)
+		}
+		fmt.Fprintf(w, "  %-9s %s%-*s%s %d\n", label, colorRed, histogramWidth, strings.Repeat("#", filled), colorReset, n)
+	}
+	l.fprintf(w, "Findings by times seen in breaches:\n")
+	if unknown < len(s.ranked) {
+		for i, n := range counts[:last+1] {
+			bar(prevalenceLabels[i], n)
+		}
+	}
+	if unknown > 0 {
+		bar(l.tr("unknown"), unknown)
+	}
+}
@@ -0,0 +1,59 @@
+package checker
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// remediationFields are the -export-bad columns, in the order ticketing and
+// forced-reset imports usually map them.
+var remediationFields = []string{"account", "username", "folder", "password", "count", "source", "line"}
+
+// remediationWriter writes the findings of a run as CSV for ticketing or
+// forced-reset tooling, one row per compromised entry. Passwords are masked
+// to their first and last character, or left out when they may not be shown
+// at all; with -print-hash the column holds the hash.
+type remediationWriter struct {
+	cw     *csv.Writer
+	hashes bool
+	tags   []string
+}
+
+func newRemediationWriter(w io.Writer, cfg Config) (*remediationWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append(slices.Clone(remediationFields), tagKeys(cfg.Tags)...)); err != nil {
+		return nil, err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	return &remediationWriter{cw: cw, hashes: cfg.PrintHash, tags: tagValues(cfg.Tags)}, nil
+}
+
+func (e *remediationWriter) write(rec record) error {
+	if rec.Status != statusPwned {
+		return nil
+	}
+	password := rec.Masked
+	if e.hashes {
+		password = rec.Password
+	}
+	line := ""
+	if rec.Line > 0 {
+		line = strconv.Itoa(rec.Line)
+	}
+	row := []string{rec.Account, rec.Username, rec.Folder, password, strconv.Itoa(rec.Count), rec.Source, line}
+	if err := e.cw.Write(append(row, e.tags...)); err != nil {
+		return err
+	}
+	e.cw.Flush()
+	return e.cw.Error()
+}
+
+func (e *remediationWriter) close(runSummary) error {
+	e.cw.Flush()
+	return e.cw.Error()
+}
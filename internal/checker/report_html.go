@@ -0,0 +1,115 @@
+package checker
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+//go:embed templates/report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": percent,
+	"seen":    seenText,
+}).Parse(reportHTML))
+
+// htmlReport collects findings during the run and renders a self-contained
+// page at close, so the report can be attached to a ticket as a single file.
+type htmlReport struct {
+	w        io.Writer
+	source   string
+	started  time.Time
+	hashed   bool
+	hashes   bool
+	history  bool
+	findings []record
+}
+
+func newHTMLReport(w io.Writer, cfg Config) *htmlReport {
+	source := cfg.InputFile
+	if len(cfg.Args) > 0 || cfg.Prompt {
+		source = "command line"
+	}
+	return &htmlReport{w: w, source: source, started: time.Now(), hashed: cfg.IsHashed, hashes: cfg.PrintHash,
+		history: cfg.HistoryDB != ""}
+}
+
+func (h *htmlReport) write(rec record) error {
+	switch rec.Status {
+	case statusPwned:
+		if !h.hashes {
+			rec.Password = maskPassword(rec.Password)
+		}
+		h.findings = append(h.findings, rec)
+	}
+	return nil
+}
+
+type prevalenceBucket struct {
+	Label string
+	Count int
+}
+
+func (h *htmlReport) close(summary runSummary) error {
+	host, _ := os.Hostname()
+	buckets := prevalence(h.findings)
+	peak := 1
+	for _, b := range buckets {
+		peak = max(peak, b.Count)
+	}
+	return reportTemplate.Execute(h.w, map[string]any{
+		"Summary":    summary,
+		"Findings":   h.findings,
+		"Prevalence": buckets,
+		"Peak":       peak,
+		"Source":     h.source,
+		"Hashed":     h.hashed,
+		"Hashes":     h.hashes,
+		"History":    h.history,
+		"Host":       host,
+		"Started":    h.started.UTC().Format(time.RFC3339),
+		"Generated":  time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// prevalence groups findings by how often HIBP has seen the password, in
+// decades: 1-9, 10-99 and so on.
+func prevalence(findings []record) []prevalenceBucket {
+	labels := []string{"1-9", "10-99", "100-999", "1k-9,999", "10k-99,999", "100k+"}
+	buckets := make([]prevalenceBucket, len(labels))
+	for i, l := range labels {
+		buckets[i].Label = l
+	}
+	for _, f := range findings {
+		i := 0
+		for n := f.Count; n >= 10 && i < len(buckets)-1; n /= 10 {
+			i++
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return n * 100 / total
+}
+
+// maskPassword keeps the first and last character so a finding can be told
+// apart from its neighbours without disclosing the password.
+func maskPassword(p string) string {
+	runes := []rune(p)
+	switch {
+	case len(runes) == 0:
+		return ""
+	case len(runes) <= 2:
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+}
@@ -0,0 +1,117 @@
+package checker
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// junitWriter turns every checked entry into a test case so CI servers can
+// show an audit in their test report UI: pwned entries fail, lookups that
+// could not complete are errors. Passwords never appear in the report.
+type junitWriter struct {
+	w     io.Writer
+	tags  []Tag
+	cases []junitCase
+	fails int
+	errs  int
+	skips int
+}
+
+func (j *junitWriter) write(rec record) error {
+	tc := junitCase{Name: caseName(rec), ClassName: "pwnedcheck." + rec.Source}
+	switch rec.Status {
+	case statusPwned:
+		j.fails++
+		tc.Failure = &junitProblem{Message: fmt.Sprintf("password appears %d times in known data breaches", rec.Count), Type: statusPwned}
+	case statusError:
+		j.errs++
+		tc.Error = &junitProblem{Message: rec.Error, Type: statusError}
+	case statusSkipped:
+		j.skips++
+		tc.Skipped = &junitProblem{Message: rec.Error, Type: statusSkipped}
+	}
+	j.cases = append(j.cases, tc)
+	return nil
+}
+
+// caseName identifies an entry without its password.
+func caseName(rec record) string {
+	switch {
+	case rec.Account != "" && rec.Username != "":
+		return rec.Account + " (" + rec.Username + ")"
+	case rec.Account != "":
+		return rec.Account
+	case rec.Username != "":
+		return rec.Username
+	case rec.Line > 0:
+		return fmt.Sprintf("line %d", rec.Line)
+	}
+	return fmt.Sprintf("item #%d", rec.Item)
+}
+
+func (j *junitWriter) close(summary runSummary) error {
+	elapsed := "0"
+	if d, err := time.ParseDuration(summary.Runtime); err == nil {
+		elapsed = fmt.Sprintf("%.3f", d.Seconds())
+	}
+	suite := junitSuite{
+		Name:     "PwnedCheck",
+		Tests:    len(j.cases),
+		Failures: j.fails,
+		Errors:   j.errs,
+		Skipped:  j.skips,
+		Time:     elapsed,
+		Cases:    j.cases,
+	}
+	for _, t := range j.tags {
+		suite.Properties = append(suite.Properties, junitProperty{Name: t.Key, Value: t.Value})
+	}
+	doc := junitSuites{Name: "PwnedCheck", Tests: suite.Tests, Failures: suite.Failures, Errors: suite.Errors, Suites: []junitSuite{suite}}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(j.w, "%s%s\n", xml.Header, out)
+	return err
+}
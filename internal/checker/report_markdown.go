@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownWriter buffers the findings so the totals table can lead the
+// document, which is what readers of an issue or wiki page look at first.
+type markdownWriter struct {
+	w        io.Writer
+	fields   []string
+	tags     []Tag
+	hashes   bool
+	findings []record
+}
+
+func (m *markdownWriter) write(rec record) error {
+	switch rec.Status {
+	case statusPwned:
+		if !m.hashes {
+			rec.Password = maskPassword(rec.Password)
+		}
+		m.findings = append(m.findings, rec)
+	}
+	return nil
+}
+
+func (m *markdownWriter) close(summary runSummary) error {
+	var b strings.Builder
+	b.WriteString("## PwnedCheck results\n\n")
+	b.WriteString("| Checked | Pwned | Clean | Errors | Runtime |\n")
+	b.WriteString("| ---: | ---: | ---: | ---: | --- |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %s |\n", summary.Total, summary.Bad, summary.Good, summary.Errors, summary.Runtime)
+
+	if len(m.tags) > 0 {
+		b.WriteString("\n")
+		for _, t := range m.tags {
+			fmt.Fprintf(&b, "- **%s**: %s\n", markdownCell(t.Key), markdownCell(t.Value))
+		}
+	}
+
+	b.WriteString("\n### Findings\n\n")
+	if len(m.findings) == 0 {
+		b.WriteString("No pwned passwords were found.\n")
+	} else {
+		b.WriteString("| " + strings.Join(m.fields, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(m.fields)) + "\n")
+		for _, f := range m.findings {
+			row := f.strings(m.fields)
+			for i, cell := range row {
+				row[i] = markdownCell(cell)
+			}
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	}
+
+	_, err := io.WriteString(m.w, b.String())
+	return err
+}
+
+// markdownCell escapes the characters that would break a table row.
+func markdownCell(s string) string {
+	s = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\n", " ", "\r", "").Replace(s)
+	return s
+}
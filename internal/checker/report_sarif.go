@@ -0,0 +1,155 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifRuleID  = "PC001"
+	sarifInfoURI = "https://haveibeenpwned.com/Passwords"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	Results    []sarifResult  `json:"results"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysical `json:"physicalLocation"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifWriter maps every pwned entry to a SARIF result located at its input
+// file and line. Passwords and hashes are never included: the log usually
+// ends up in a code-scanning UI that more people can read than the input.
+type sarifWriter struct {
+	w       io.Writer
+	results []sarifResult
+}
+
+func (s *sarifWriter) write(rec record) error {
+	if rec.Status != statusPwned {
+		return nil
+	}
+
+	what := fmt.Sprintf("item #%d", rec.Item)
+	switch {
+	case rec.Account != "":
+		what = rec.Account
+		if rec.Username != "" {
+			what += " (" + rec.Username + ")"
+		}
+	case rec.Username != "":
+		what = "password of " + rec.Username
+	case rec.Line > 0:
+		what = fmt.Sprintf("password on line %d", rec.Line)
+	}
+	res := sarifResult{
+		RuleID:     sarifRuleID,
+		Level:      "error",
+		Message:    sarifMessage{Text: fmt.Sprintf("The %s appears %d times in known data breaches.", what, rec.Count)},
+		Properties: map[string]any{"count": rec.Count},
+	}
+	if uri := sarifURI(rec.Source); uri != "" {
+		loc := sarifLocation{PhysicalLocation: sarifPhysical{ArtifactLocation: sarifArtifact{URI: uri}}}
+		if rec.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: rec.Line}
+		}
+		res.Locations = []sarifLocation{loc}
+	}
+	s.results = append(s.results, res)
+	return nil
+}
+
+// sarifURI turns a source into an artifact URI; inline arguments and vault
+// entries have no location in a file.
+func sarifURI(source string) string {
+	switch {
+	case source == "argument" || source == "bitwarden" || source == "kubernetes" || source == "aws" || source == "":
+		return ""
+	case input.IsURL(source):
+		return source
+	}
+	return filepath.ToSlash(filepath.Clean(source))
+}
+
+func (s *sarifWriter) close(summary runSummary) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "PwnedCheck",
+			InformationURI: "https://github.com/mohamedation/PwnedCheck",
+			Rules: []sarifRule{{
+				ID:               sarifRuleID,
+				Name:             "PwnedPassword",
+				ShortDescription: sarifMessage{Text: "Password appears in known data breaches"},
+				HelpURI:          sarifInfoURI,
+			}},
+		}},
+		Results: s.results,
+	}
+	if run.Results == nil {
+		run.Results = []sarifResult{}
+	}
+	run.Properties = map[string]any{"summary": summary}
+
+	out, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "%s\n", out)
+	return err
+}
@@ -0,0 +1,124 @@
+package checker
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// reuseShown is how many accounts a -reuse cluster names before summing up.
+const reuseShown = 5
+
+// reuseTracker finds the passwords several accounts share, for -reuse. It
+// keys accounts by password hash, so no plaintext is kept, and sees the
+// input before -dedupe collapses the very duplicates it looks for.
+type reuseTracker struct {
+	// accounts lists the distinct identities using each hash in input order
+	accounts map[string][]string
+	// pwned holds the breach count of every hash found pwned
+	pwned      map[string]int
+	identified bool
+}
+
+// reuseCluster is one password shared by accounts.
+type reuseCluster struct {
+	accounts []string
+	pwned    bool
+	count    int
+}
+
+// identity names the account an entry belongs to, empty when the input has
+// no account or username for it.
+func identity(e entry) string {
+	switch {
+	case e.Account != "" && e.Username != "":
+		return e.Account + " (" + e.Username + ")"
+	case e.Account != "" || e.Username != "":
+		return e.Account + e.Username
+	}
+	return ""
+}
+
+func (t *reuseTracker) add(entries []entry, hashed, ntlm bool) {
+	if t.accounts == nil {
+		t.accounts, t.pwned = map[string][]string{}, map[string]int{}
+	}
+	for _, e := range entries {
+		id := identity(e)
+		if id == "" {
+			continue
+		}
+		t.identified = true
+		h := entryHash(e, hashed, ntlm)
+		if !slices.Contains(t.accounts[h], id) {
+			t.accounts[h] = append(t.accounts[h], id)
+		}
+	}
+}
+
+// finding marks the hash of a pwned rec, so its cluster says so.
+func (t *reuseTracker) finding(rec record) {
+	if rec.Status == statusPwned && t.accounts != nil {
+		t.pwned[rec.Hash] = rec.Count
+	}
+}
+
+// clusters returns the passwords of two accounts or more, the largest
+// first and breached ones before the rest.
+func (t *reuseTracker) clusters() []reuseCluster {
+	var clusters []reuseCluster
+	for h, accounts := range t.accounts {
+		if len(accounts) < 2 {
+			continue
+		}
+		count, pwned := t.pwned[h]
+		clusters = append(clusters, reuseCluster{accounts: accounts, pwned: pwned, count: count})
+	}
+	slices.SortFunc(clusters, func(a, b reuseCluster) int {
+		if c := cmp.Compare(len(b.accounts), len(a.accounts)); c != 0 {
+			return c
+		}
+		if a.pwned != b.pwned {
+			if a.pwned {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.count, a.count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.accounts[0], b.accounts[0])
+	})
+	return clusters
+}
+
+// printReuse lists the passwords shared by accounts, naming the accounts
+// and never the password. A shared password is a risk even before it shows
+// up in a breach: one leak exposes every account using it.
+func (r *runner) printReuse() {
+	t := r.stats.reuse
+	if t == nil {
+		return
+	}
+	if !t.identified {
+		r.printf("%sNo accounts or usernames in the input; -reuse has nothing to compare.%s\n", colorYellow, colorReset)
+		return
+	}
+	clusters := t.clusters()
+	r.printf("\nPasswords shared by several accounts: %d\n", len(clusters))
+	for _, c := range clusters {
+		names := strings.Join(c.accounts[:min(len(c.accounts), reuseShown)], ", ")
+		if len(c.accounts) > reuseShown {
+			names += fmt.Sprintf(r.lang.tr(" and %d more"), len(c.accounts)-reuseShown)
+		}
+		switch {
+		case c.pwned && c.count > 0:
+			r.printf("  %s%d accounts, seen %d times in breaches:%s %s\n", colorRed, len(c.accounts), c.count, colorReset, names)
+		case c.pwned:
+			r.printf("  %s%d accounts, found in breaches:%s %s\n", colorRed, len(c.accounts), colorReset, names)
+		default:
+			r.printf("  %s%d accounts, not found in breaches:%s %s\n", colorYellow, len(c.accounts), colorReset, names)
+		}
+	}
+}
@@ -0,0 +1,978 @@
+package checker
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mohamedation/PwnedCheck/internal/history"
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// entry is one password to check, with whatever context its source has.
+type entry struct {
+	Password string
+	Account  string
+	Username string
+	// Folder is the Bitwarden vault folder, or the namespace of a Kubernetes
+	// Secret.
+	Folder string
+	// Line is the 1-based line in the input file, 0 for other sources.
+	Line int
+	// Source is the file of entries from many files, such as scan-repo's;
+	// others come from the runner's source.
+	Source string
+}
+
+type style int
+
+const (
+	// styleInline reports every password, good or bad.
+	styleInline style = iota
+	// styleList draws a progress bar and only reports findings.
+	styleList
+)
+
+// runner checks a list of entries and routes each outcome to either the
+// human-readable console output or a structured writer on stdout, plus any
+// number of file sinks.
+type runner struct {
+	cfg    Config
+	client *Checker
+	stats  *statistics
+	// out replaces the human output on stdout when a structured -format is set.
+	out   resultWriter
+	sinks []sink
+	// msg receives human messages; stderr when stdout carries structured output.
+	msg    io.Writer
+	source string
+	style  style
+	// ignore holds the hashes from -ignore-file.
+	ignore map[string]struct{}
+	// secretKeys is the -keys pattern the dotenv format and the secret stores
+	// select values with
+	secretKeys *regexp.Regexp
+	// groupMap translates -group-by values with -group-map
+	groupMap  map[string]string
+	policy    *policy
+	suggester *suggester
+	normalize func(string) string
+	// known holds the previous run's findings with -every; findings collects
+	// this run's.
+	known    map[string]bool
+	findings map[string]bool
+	// dash is the -tui screen while entries are being checked.
+	dash *dashboard
+	// lang translates console messages for -lang.
+	lang    lang
+	breaker breaker
+	// apiErrors counts lookups the API failed, for -max-errors
+	apiErrors int
+	// history is the open -history-db; sightings collects the findings to
+	// record in it and historyErr the first failed lookup.
+	history    *history.Store
+	sightings  []history.Finding
+	historyErr error
+	// concluded is set once conclude has closed the outputs and the history.
+	concluded bool
+}
+
+// sink is an extra destination for results, such as a file, that finish
+// must close. Only named sinks are announced when they are done.
+type sink struct {
+	w      resultWriter
+	closer io.Closer
+	name   string
+	// perLine sinks list every result and so honour -only-bad and -only-good.
+	perLine bool
+	// alerts sinks only receive new findings with -every.
+	alerts bool
+}
+
+// newRunner wires the outputs: -o goes to a file sink while the human output
+// stays on the terminal; a structured -format without -o takes over stdout,
+// with human messages moving to stderr.
+func newRunner(client *Checker, cfg Config, stats *statistics) (*runner, error) {
+	r := &runner{cfg: cfg, client: client, stats: stats, msg: os.Stdout, lang: langFor(cfg.Lang), breaker: breaker{threshold: cfg.Breaker, retries: cfg.BreakerRetries}}
+	if cfg.Reuse {
+		stats.reuse = &reuseTracker{}
+	}
+
+	format := cfg.Format
+	structured := format != "" && format != formatText
+
+	fields, err := parseFields(cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	if fields, err = optionalFields(fields, cfg.Fields == "", cfg.Strength, strengthFields, "-strength"); err != nil {
+		return nil, err
+	}
+	if fields, err = optionalFields(fields, cfg.Fields == "", cfg.Analyze, analysisFields, "-analyze"); err != nil {
+		return nil, err
+	}
+	if fields, err = optionalFields(fields, cfg.Fields == "", cfg.PolicyFile != "", policyFields, "-policy"); err != nil {
+		return nil, err
+	}
+	if fields, err = optionalFields(fields, cfg.Fields == "", cfg.Variants, variantFields, "-variants"); err != nil {
+		return nil, err
+	}
+	if fields, err = optionalFields(fields, cfg.Fields == "", cfg.HistoryDB != "", historyFields, "-history-db"); err != nil {
+		return nil, err
+	}
+	if cfg.Suggest {
+		if r.suggester, err = newSuggester(cfg.SuggestLength, cfg.SuggestCharset, cfg.SuggestWords); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.PolicyFile != "" {
+		if r.policy, err = loadPolicy(cfg.PolicyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	open := func(w io.Writer) (resultWriter, error) {
+		return newResultWriter(format, fields, cfg.Tags, cfg.PrintHash, w)
+	}
+	if cfg.Template != "" {
+		if structured {
+			return nil, errors.New("-template cannot be combined with -format " + format)
+		}
+		open = func(w io.Writer) (resultWriter, error) {
+			return newTemplateWriter(cfg.Template, cfg.Tags, w)
+		}
+		structured = true
+	}
+
+	if cfg.OutputFile != "" {
+		if !structured {
+			if format, err = formatFromExtension(cfg.OutputFile); err != nil {
+				return nil, err
+			}
+		}
+		if err := r.addSink(cfg.OutputFile, open); err != nil {
+			return nil, err
+		}
+		r.sinks[len(r.sinks)-1].perLine = true
+	} else if structured && !cfg.Quiet {
+		if cfg.TUI {
+			return nil, errors.New("-tui takes over the terminal; write structured results to a file with -o")
+		}
+		if r.out, err = open(os.Stdout); err != nil {
+			return nil, err
+		}
+		r.msg = os.Stderr
+	}
+
+	if cfg.ReportFile != "" {
+		if err := r.addSink(cfg.ReportFile, func(w io.Writer) (resultWriter, error) {
+			return newHTMLReport(w, cfg), nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.HistoryDB != "" {
+		if r.history, err = history.Open(cfg.HistoryDB); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ExportBad != "" {
+		if err := r.addSink(cfg.ExportBad, func(w io.Writer) (resultWriter, error) {
+			return newRemediationWriter(w, cfg)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.normalize, err = normalizer(cfg.Normalize); err != nil {
+		return nil, err
+	}
+	if err := input.CheckEncoding(cfg.Encoding); err != nil {
+		return nil, err
+	}
+	if err := checkInputFormat(cfg.InputFormat); err != nil {
+		return nil, err
+	}
+	if cfg.GroupBy != "" && !slices.Contains(GroupDimensions, cfg.GroupBy) {
+		return nil, fmt.Errorf("unknown -group-by %q (available: %s)", cfg.GroupBy, strings.Join(GroupDimensions, ", "))
+	}
+	if cfg.SecretKeys != "" {
+		if r.secretKeys, err = regexp.Compile(cfg.SecretKeys); err != nil {
+			return nil, fmt.Errorf("invalid -keys: %w", err)
+		}
+	}
+	if cfg.GroupMap != "" {
+		if r.groupMap, err = loadGroupMap(cfg.GroupMap); err != nil {
+			return nil, err
+		}
+	}
+	stats.groupBy = cfg.GroupBy
+	if cfg.IgnoreFile != "" {
+		if r.ignore, err = loadIgnoreFile(cfg.IgnoreFile, r.normalize, cfg.NTLM); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Syslog != "" {
+		w, err := newSyslogWriter(cfg.Syslog, cfg.Tags)
+		if err != nil {
+			return nil, err
+		}
+		r.sinks = append(r.sinks, sink{w: w, alerts: true})
+	}
+	if cfg.Webhook != "" {
+		w, err := newWebhookWriter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		r.sinks = append(r.sinks, sink{w: w, alerts: true})
+	}
+	if cfg.SMTPServer != "" {
+		w, err := newMailWriter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		r.sinks = append(r.sinks, sink{w: w, alerts: true})
+	}
+	return r, nil
+}
+
+// optionalFields adds extra to the default fields when its flag is on, and
+// rejects explicitly requested extras when it is off.
+func optionalFields(fields []string, defaults, on bool, extra []string, flag string) ([]string, error) {
+	switch {
+	case on && defaults:
+		return append(slices.Clone(fields), extra...), nil
+	case !on && slices.ContainsFunc(fields, func(f string) bool { return slices.Contains(extra, f) }):
+		return nil, fmt.Errorf("the %s fields need %s", strings.Join(extra, ", "), flag)
+	}
+	return fields, nil
+}
+
+func (r *runner) addSink(path string, open func(io.Writer) (resultWriter, error)) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	w, err := open(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.sinks = append(r.sinks, sink{w: w, closer: file, name: path})
+	return nil
+}
+
+func (r *runner) printf(format string, args ...any) {
+	r.lang.fprintf(r.msg, format, args...)
+}
+
+// notef prints informational messages that -q silences.
+func (r *runner) notef(format string, args ...any) {
+	if !r.cfg.Quiet {
+		r.printf(format, args...)
+	}
+}
+
+// fail reports a fatal error before any entry was checked.
+func (r *runner) fail(format string, args ...any) int {
+	r.printf("%s"+format+"%s\n", append(append([]any{colorRed}, args...), colorReset)...)
+	return exitError
+}
+
+// run checks entries in order. For file inputs it honours -budget and
+// -resume, persisting a checkpoint so a later run can continue.
+func (r *runner) run(entries []entry) int {
+	if r.normalize != nil && !r.cfg.IsHashed {
+		for i := range entries {
+			entries[i].Password = r.normalize(entries[i].Password)
+		}
+	}
+	if r.ignore != nil {
+		var skipped int
+		entries, skipped = skipIgnored(entries, r.ignore, r.cfg.IsHashed, r.cfg.NTLM)
+		r.stats.ignored += skipped
+		if skipped > 0 {
+			r.notef("Skipped %d passwords listed in %s\n", skipped, r.cfg.IgnoreFile)
+		}
+	}
+	if r.stats.reuse != nil {
+		r.stats.reuse.add(entries, r.cfg.IsHashed, r.cfg.NTLM)
+	}
+	if r.cfg.Dedupe {
+		var dups int
+		entries, dups = dedupe(entries, r.cfg.IsHashed, r.cfg.NTLM)
+		r.stats.duplicates += dups
+		if dups > 0 {
+			r.notef("Collapsed %d duplicate passwords, %d left to check\n", dups, len(entries))
+		}
+	}
+
+	total := len(entries)
+	start, stop := 0, total
+	checkpointing := r.source == r.cfg.InputFile && (r.cfg.Budget > 0 || r.cfg.Resume)
+
+	var curPath string
+	var budgetEnd time.Time
+	if checkpointing {
+		var err error
+		if curPath, err = cursorPath(r.cfg); err != nil {
+			return r.fail("%v", err)
+		}
+		cur, err := loadCursor(curPath)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		switch {
+		case cur.Input != "" && cur.Input != r.cfg.InputFile:
+			r.notef("%sCheckpoint %s belongs to %s; starting over.%s\n", colorYellow, curPath, cur.Input, colorReset)
+		case cur.Item > 0 && cur.Item < total:
+			start = cur.Item
+			if r.cfg.Resume {
+				cur.restore(r.stats)
+			}
+			r.notef("Resuming at item #%d of %d\n", start+1, total)
+		}
+		if r.cfg.Budget > 0 {
+			budgetEnd = time.Now().Add(r.cfg.Budget)
+		}
+	}
+
+	human := r.out == nil
+	var bar *progress
+	switch {
+	case r.cfg.TUI:
+		d, err := startDashboard(r.source, total-start)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		r.dash, bar = d, &progress{}
+	case r.style == styleList && human && !r.cfg.Quiet:
+		bar = newProgress(total - start)
+	default:
+		bar = &progress{}
+	}
+	if !bar.enabled && r.cfg.ShowStats && !r.cfg.TUI && !r.cfg.Quiet {
+		bar = statusLines(total-start, r.msg)
+	}
+
+	// -deadline covers the whole run, loading the input included
+	runEnd := r.stats.startTime.Add(r.cfg.Deadline)
+	expired, aborted, gaveUp := false, false, false
+	r.apiErrors = 0
+	in := watchInterrupts()
+	defer in.close()
+	interrupted := false
+
+	// halt tells why no new lookup may start, if one may not
+	halt := func() string {
+		switch {
+		case r.cfg.Budget > 0 && time.Now().After(budgetEnd):
+			return haltBudget
+		case r.cfg.Deadline > 0 && time.Now().After(runEnd):
+			return haltDeadline
+		case r.cfg.MaxErrors > 0 && r.apiErrors >= r.cfg.MaxErrors:
+			return haltErrors
+		}
+		r.dash.wait()
+		if in.stopped() || r.dash.stopped() {
+			return haltInterrupt
+		}
+		return ""
+	}
+	// with -batch every lookup happens up front, and the loop below only
+	// reports them, up to the first entry the lookups did not reach
+	var batch []batchResult
+	var batchHalt string
+	if r.cfg.Batch {
+		batch, batchHalt = r.batchLookups(entries[start:], halt, bar)
+	}
+
+	lastCheckpoint := time.Now()
+	for i := start; i < total; i++ {
+		why := ""
+		switch {
+		case batch == nil:
+			why = halt()
+		case !batch[i-start].done:
+			why = batchHalt
+		}
+		if why != "" {
+			stop, expired, interrupted, aborted, gaveUp = i, why == haltDeadline, why == haltInterrupt, why == haltErrors, why == haltOutage
+			break
+		}
+		if checkpointing && time.Since(lastCheckpoint) >= checkpointInterval {
+			if err := saveCursor(curPath, newCursor(r.cfg.InputFile, i, r.stats)); err != nil {
+				bar.clear()
+				r.printf("%sFailed to write checkpoint: %v%s\n", colorRed, err, colorReset)
+			} else {
+				r.client.Logger().Debug("checkpoint written", "path", curPath, "item", i)
+			}
+			lastCheckpoint = time.Now()
+		}
+		if batch == nil {
+			bar.update(i-start, r.stats)
+		}
+
+		// the outcome isn't known yet, so filtered runs skip the header
+		if r.style == styleInline && human && !r.cfg.OnlyBad && !r.cfg.OnlyGood {
+			r.notef("\nChecking password %d of %d...\n", i+1, total)
+		}
+
+		e := entries[i]
+		var res Result
+		var err error
+		if batch != nil {
+			res, err = batch[i-start].res, batch[i-start].err
+		} else if why := r.guard(halt, bar, func() error {
+			res, err = r.client.Check(e.Password, r.cfg.IsHashed)
+			return err
+		}); why != "" {
+			stop, expired, interrupted, aborted, gaveUp = i, why == haltDeadline, why == haltInterrupt, why == haltErrors, why == haltOutage
+			break
+		}
+		rec := r.record(i+1, e, res, err)
+		// the entry itself is checked, so a halt among its variants stops
+		// after it, unless it was the last one
+		variantHalt := ""
+		if r.cfg.Variants && rec.Status == statusClean {
+			var v variant
+			var count int
+			var ok bool
+			if v, count, ok, variantHalt = r.checkVariants(e.Password, halt, bar); ok {
+				rec.Variant, rec.VariantCount = v.Kind, count
+			}
+		}
+
+		bar.clear()
+		if err := r.emit(rec); err != nil {
+			return r.fail("Failed to write results: %v", err)
+		}
+		if r.dash != nil {
+			r.dash.update(i+1-start, r.stats, r.client)
+		}
+		if why := variantHalt; why != "" && i+1 < total {
+			stop, expired, interrupted, aborted, gaveUp = i+1, why == haltDeadline, why == haltInterrupt, why == haltErrors, why == haltOutage
+			break
+		}
+	}
+
+	bar.clear()
+	if r.dash != nil {
+		rows, err := r.dash.close(interrupted)
+		r.dash = nil
+		if err != nil {
+			return r.fail("Dashboard failed: %v", err)
+		}
+		// the screen is gone, so the findings are listed as usual
+		for _, rec := range rows {
+			r.printHuman(rec)
+		}
+	}
+
+	if interrupted {
+		r.stats.stoppedAt, r.stats.stoppedLine = stop+1, entries[stop].Line
+		where := fmt.Sprintf(r.lang.tr("item #%d"), stop+1)
+		if entries[stop].Line > 0 {
+			where += fmt.Sprintf(r.lang.tr(" (line %d)"), entries[stop].Line)
+		}
+		r.notef("%sInterrupted: stopped at %s of %d; %d items were not checked.%s\n",
+			colorYellow, where, total, total-stop, colorReset)
+	}
+
+	if expired || aborted || gaveUp {
+		reason := "deadline passed before the lookup"
+		switch {
+		case aborted:
+			reason = fmt.Sprintf("run aborted after %d API errors", r.apiErrors)
+		case gaveUp:
+			reason = fmt.Sprintf("API still unavailable after %d breaker retries", r.cfg.BreakerRetries)
+		}
+		for i := stop; i < total; i++ {
+			if err := r.emit(r.skipped(i+1, entries[i], reason)); err != nil {
+				return r.fail("Failed to write results: %v", err)
+			}
+		}
+	}
+	if expired {
+		r.notef("%sDeadline of %s reached: %d of %d items were skipped.%s\n",
+			colorYellow, r.cfg.Deadline, total-stop, total, colorReset)
+	}
+	if aborted {
+		r.stats.aborted = true
+		r.notef("%sAborted after %d API errors: %d of %d items were checked, %d were skipped.%s\n",
+			colorRed, r.apiErrors, stop, total, total-stop, colorReset)
+	}
+	if gaveUp {
+		r.stats.gaveUp = true
+		r.notef("%sGave up after %d retries with the API still unavailable: %d of %d items were checked, %d were skipped.%s\n",
+			colorRed, r.cfg.BreakerRetries, stop, total, total-stop, colorReset)
+	}
+
+	if checkpointing {
+		if stop < total {
+			if err := saveCursor(curPath, newCursor(r.cfg.InputFile, stop, r.stats)); err != nil {
+				return r.fail("Failed to save cursor: %v", err)
+			}
+			if expired || interrupted || aborted || gaveUp {
+				r.notef("The next run resumes at item #%d.\n", stop+1)
+			} else {
+				r.notef("%sBudget of %s used up: %d of %d items remain, next run resumes at item #%d.%s\n",
+					colorYellow, r.cfg.Budget, total-stop, total, stop+1, colorReset)
+			}
+		} else {
+			if err := os.Remove(curPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				r.printf("%sFailed to reset cursor: %v%s\n", colorRed, err, colorReset)
+			}
+			if r.cfg.Budget > 0 {
+				r.notef("Reached the end of the input; the next run starts over from item #1.\n")
+			}
+		}
+	}
+
+	return exitOK
+}
+
+// dedupe keeps the first entry for every distinct password, comparing hashes
+// so plaintext is never held in a second structure.
+func dedupe(entries []entry, hashed, ntlm bool) ([]entry, int) {
+	seen := make(map[string]struct{}, len(entries))
+	kept := entries[:0:0]
+	for _, e := range entries {
+		h := entryHash(e, hashed, ntlm)
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+		kept = append(kept, e)
+	}
+	return kept, len(entries) - len(kept)
+}
+
+func (r *runner) record(item int, e entry, res Result, err error) record {
+	rec := record{
+		Item:     item,
+		Line:     e.Line,
+		Source:   cmp.Or(e.Source, r.source),
+		Account:  e.Account,
+		Username: e.Username,
+		Folder:   e.Folder,
+		Hash:     res.Hash,
+		Status:   statusClean,
+		Count:    res.Count,
+	}
+	rec.Password = r.displayPassword(e.Password, res.Hash)
+	if (r.cfg.Top > 0 || r.cfg.ExportBad != "") && !r.cfg.HidePassword && !r.cfg.PrintHash && !r.cfg.IsHashed {
+		rec.Masked = maskPassword(e.Password)
+	}
+	if r.cfg.Strength {
+		st := estimateStrength(e)
+		rec.Strength, rec.CrackTime = st.Score, st.CrackTime
+	}
+	if r.cfg.Analyze {
+		c := analyze(e.Password)
+		rec.Length, rec.Classes, rec.Entropy = c.Length, strings.Join(c.Classes, "+"), c.Entropy
+		r.stats.analysis.add(c)
+	}
+	if r.policy != nil {
+		rec.Policy = policyPass
+		if rec.Violations = r.policy.evaluate(e.Password); len(rec.Violations) > 0 {
+			rec.Policy = policyFail
+		}
+	}
+	switch {
+	case err != nil:
+		rec.Status = statusError
+		rec.Error = err.Error()
+		if r.stats.errorKinds == nil {
+			r.stats.errorKinds = make(map[string]int)
+		}
+		r.stats.errorKinds[errorKind(err)]++
+	case isPwned(res, r.cfg.MinCount):
+		rec.Status = statusPwned
+	}
+	return rec
+}
+
+// skipped is the record for an entry never looked up because -deadline
+// passed or -max-errors was reached, with that reason as its error.
+func (r *runner) skipped(item int, e entry, reason string) record {
+	return record{
+		Item:     item,
+		Line:     e.Line,
+		Source:   cmp.Or(e.Source, r.source),
+		Account:  e.Account,
+		Username: e.Username,
+		Folder:   e.Folder,
+		Password: r.displayPassword(e.Password, ""),
+		Status:   statusSkipped,
+		Error:    reason,
+	}
+}
+
+// displayPassword applies -hide, -print-hash and -mask. hash is the lookup
+// hash, computed here for entries never looked up.
+func (r *runner) displayPassword(password, hash string) string {
+	switch {
+	case r.cfg.HidePassword:
+		return ""
+	case r.cfg.PrintHash:
+		return cmp.Or(hash, entryHash(entry{Password: password}, r.cfg.IsHashed, r.cfg.NTLM))
+	case r.cfg.MaskPassword:
+		return maskPassword(password)
+	}
+	return password
+}
+
+// isPwned applies -min-count: hits seen fewer times count as clean. Starter
+// filter hits have no count; they are the most common passwords, so they
+// always count as pwned.
+func isPwned(res Result, minCount int) bool {
+	return res.Pwned && (res.Count == 0 || res.Count >= minCount)
+}
+
+// emit counts the outcome and reports it.
+func (r *runner) emit(rec record) error {
+	switch rec.Status {
+	case statusPwned:
+		r.stats.badPasswords++
+	case statusError:
+		r.stats.errored++
+	case statusClean:
+		r.stats.goodPasswords++
+		if r.cfg.Strength && rec.Strength <= weakScore {
+			r.stats.weak++
+		}
+	}
+	if rec.Status == statusSkipped {
+		r.stats.skipped++
+	} else {
+		r.stats.totalChecked++
+	}
+	if rec.Variant != "" {
+		r.stats.variants++
+	}
+	if rec.Policy == policyFail {
+		r.stats.policyFailures++
+	}
+	r.countGroup(rec)
+	if r.cfg.ShowStats || r.cfg.Top > 0 {
+		r.stats.rank(rec)
+	}
+	if r.stats.reuse != nil {
+		r.stats.reuse.finding(rec)
+	}
+	if r.history != nil && rec.Status == statusPwned {
+		r.see(&rec)
+	}
+
+	shown, alert := r.shown(rec), r.alerting(rec)
+	for _, s := range r.sinks {
+		if s.perLine && !shown || s.alerts && !alert {
+			continue
+		}
+		if err := s.w.write(rec); err != nil {
+			return err
+		}
+	}
+	if !shown || !alert {
+		return nil
+	}
+	if r.out != nil {
+		return r.out.write(rec)
+	}
+	// skipped entries are summed up in a single note instead
+	if r.cfg.Quiet || rec.Status == statusSkipped {
+		return nil
+	}
+	if r.dash != nil {
+		if r.listed(rec) {
+			r.dash.add(rec)
+		}
+		return nil
+	}
+	r.printHuman(rec)
+	return nil
+}
+
+// listed reports whether the list view prints anything for rec, so the
+// dashboard only keeps what the console would have shown.
+func (r *runner) listed(rec record) bool {
+	return rec.Status != statusClean || r.cfg.OnlyGood || rec.Variant != "" || rec.Policy == policyFail ||
+		r.cfg.Strength && rec.Strength <= weakScore
+}
+
+// shown applies -only-bad and -only-good to the per-line outputs. Errors are
+// always shown; the statistics count everything either way.
+func (r *runner) shown(rec record) bool {
+	switch {
+	case r.cfg.OnlyBad:
+		return rec.Status != statusClean
+	case r.cfg.OnlyGood:
+		return rec.Status != statusPwned
+	}
+	return true
+}
+
+// printPassword adds the length to masked passwords, since the mask alone
+// is easy to miscount, and labels -print-hash hashes as such.
+func (r *runner) printPassword(rec record) {
+	switch {
+	case r.cfg.PrintHash:
+		r.printf("  Hash:     %s\n", rec.Password)
+		return
+	case r.cfg.MaskPassword:
+		r.printf("  Password: %s (%d chars)\n", rec.Password, utf8.RuneCountInString(rec.Password))
+		return
+	}
+	r.printf("  Password: %s\n", rec.Password)
+}
+
+func (r *runner) printHuman(rec record) {
+	if r.style == styleInline {
+		switch rec.Status {
+		case statusError:
+			r.printf("%sError: %s%s\n", colorRed, rec.Error, colorReset)
+			if r.cfg.Strength {
+				r.printStrength(rec, "  Strength: ")
+			}
+			return
+		case statusPwned:
+			r.printf("%sBAD PASSWORD FOUND%s\n", colorRed, colorReset)
+		default:
+			r.printf("%sGood password%s\n", colorGreen, colorReset)
+		}
+		if rec.Password != "" {
+			r.printPassword(rec)
+		}
+		if r.cfg.Strength {
+			r.printStrength(rec, "  Strength: ")
+		}
+		if r.cfg.Analyze {
+			r.printComposition(rec, "  Makeup:   ")
+		}
+		if rec.Policy != "" {
+			r.printPolicy(rec, "  Policy:   ")
+		}
+		if rec.Variant != "" {
+			r.printf("  %sA %s variant appears %d times in breaches%s\n", colorYellow, rec.Variant, rec.VariantCount, colorReset)
+		}
+		if rec.Status == statusPwned {
+			r.printSuggestion("  Replace with: ")
+		}
+		if rec.Status == statusPwned && len(r.cfg.Tags) > 0 {
+			r.printf("  Tags: %s\n", formatTags(r.cfg.Tags))
+		}
+		return
+	}
+
+	switch rec.Status {
+	case statusError:
+		if name := cmp.Or(rec.Account, rec.Username); name != "" {
+			r.printf("%sError checking %s: %s%s\n", colorRed, name, rec.Error, colorReset)
+		} else {
+			r.printf("%sError (item #%d): %s%s\n", colorRed, rec.Item, rec.Error, colorReset)
+		}
+	case statusPwned:
+		r.printHeading(rec, colorRed, "BAD PASSWORD — BREACH DETECTED")
+		if rec.Password != "" {
+			r.printPassword(rec)
+		}
+		if r.cfg.Strength {
+			r.printStrength(rec, "  Strength: ")
+		}
+		if r.cfg.Analyze {
+			r.printComposition(rec, "  Makeup:   ")
+		}
+		if rec.Policy != "" {
+			r.printPolicy(rec, "  Policy:   ")
+		}
+		r.printSuggestion("  Replace:  ")
+		if len(r.cfg.Tags) > 0 {
+			r.printf("  Tags:     %s\n", formatTags(r.cfg.Tags))
+		}
+	case statusClean:
+		if rec.Variant != "" && !r.cfg.OnlyGood {
+			r.printHeading(rec, colorYellow, r.lang.tr("BREACHED VARIANT — ")+rec.Variant)
+			if rec.Password != "" {
+				r.printPassword(rec)
+			}
+			r.printf("  Seen:     %d times in breaches\n", rec.VariantCount)
+			return
+		}
+		if rec.Policy == policyFail && !r.cfg.OnlyGood {
+			r.printHeading(rec, colorYellow, "POLICY VIOLATION")
+			if rec.Password != "" {
+				r.printPassword(rec)
+			}
+			if r.cfg.Strength {
+				r.printStrength(rec, "  Strength: ")
+			}
+			r.printPolicy(rec, "  Policy:   ")
+			return
+		}
+		// a weak password HIBP hasn't seen still deserves a warning
+		if r.cfg.Strength && rec.Strength <= weakScore && !r.cfg.OnlyGood {
+			r.printHeading(rec, colorYellow, "WEAK PASSWORD — NOT BREACHED")
+			if rec.Password != "" {
+				r.printPassword(rec)
+			}
+			r.printStrength(rec, "  Strength: ")
+			return
+		}
+		// the list view stays terse unless good entries were asked for
+		if !r.cfg.OnlyGood {
+			return
+		}
+		if name := cmp.Or(rec.Account, rec.Username); name != "" {
+			r.printf("%sGood password%s  %s\n", colorGreen, colorReset, name)
+		} else {
+			r.printf("%sGood password (item #%d)%s\n", colorGreen, rec.Item, colorReset)
+		}
+	}
+}
+
+// printHeading titles a finding and names its entry: account and username
+// when the input has them, the item number otherwise.
+func (r *runner) printHeading(rec record, c, title string) {
+	title = r.lang.tr(title)
+	if rec.Account == "" && rec.Username == "" {
+		r.printf("%s%s (item #%d)%s\n", c, title, rec.Item, colorReset)
+		return
+	}
+	r.printf("%s%s%s\n", c, title, colorReset)
+	if rec.Source != r.source {
+		r.printf("  File:     %s:%d\n", rec.Source, rec.Line)
+	}
+	if rec.Account != "" {
+		r.printf("  Account:  %s\n", rec.Account)
+	}
+	if rec.Username != "" {
+		r.printf("  Username: %s\n", rec.Username)
+	}
+}
+
+func (r *runner) printStrength(rec record, label string) {
+	label = r.lang.tr(label)
+	c := colorGreen
+	if rec.Strength <= weakScore {
+		c = colorYellow
+	}
+	r.printf("%s%s%d/4%s (crack time: %s)\n", label, c, rec.Strength, colorReset, rec.CrackTime)
+}
+
+// printSuggestion offers a random replacement for a pwned password. It only
+// ever reaches the console, never files or structured output.
+func (r *runner) printSuggestion(label string) {
+	label = r.lang.tr(label)
+	if r.suggester == nil {
+		return
+	}
+	p, err := r.suggester.suggest()
+	if err != nil {
+		r.client.Logger().Error("failed to generate a replacement", "err", err)
+		return
+	}
+	r.printf("%s%s%s%s\n", label, colorGreen, p, colorReset)
+}
+
+func (r *runner) printPolicy(rec record, label string) {
+	label = r.lang.tr(label)
+	if rec.Policy == policyPass {
+		r.printf("%s%spass%s\n", label, colorGreen, colorReset)
+		return
+	}
+	r.printf("%s%sfail%s (%s)\n", label, colorYellow, colorReset, strings.Join(rec.Violations, "; "))
+}
+
+func (r *runner) printComposition(rec record, label string) {
+	label = r.lang.tr(label)
+	r.printf("%s%d chars, %s, %.1f bits of entropy\n", label, rec.Length, rec.Classes, rec.Entropy)
+}
+
+// finish concludes the run and records its exit code in -stats-file.
+func (r *runner) finish() int {
+	code := r.conclude()
+	if r.cfg.StatsFile != "" {
+		if err := r.writeStatsFile(r.cfg.StatsFile, code); err != nil {
+			return r.fail("Failed to write %s: %v", r.cfg.StatsFile, err)
+		}
+	}
+	return code
+}
+
+// conclude closes the structured output, prints the summary when asked, and
+// turns the verdict into the exit code. Up to -fail-threshold findings are
+// tolerated; failed lookups and entries skipped at -deadline make an
+// otherwise clean run incomplete, and an interrupted run exits 130 whatever
+// it found. A run the breaker gave up on failed.
+func (r *runner) conclude() int {
+	r.concluded = true
+	summary := r.stats.summary(r.client, r.cfg.Tags)
+	if r.out != nil {
+		if err := r.out.close(summary); err != nil {
+			return r.fail("Failed to write results: %v", err)
+		}
+	}
+	for _, s := range r.sinks {
+		if err := s.w.close(summary); err != nil {
+			return r.fail("Failed to write results: %v", err)
+		}
+		if s.closer == nil {
+			continue
+		}
+		if err := s.closer.Close(); err != nil {
+			return r.fail("Failed to write %s: %v", s.name, err)
+		}
+		r.notef("Results written to %s\n", s.name)
+	}
+	if r.history != nil {
+		r.saveHistory()
+	}
+	if summary.Analysis != nil && !r.cfg.Quiet {
+		summary.Analysis.print(r.msg)
+	}
+	if r.cfg.InputFormat == "potfile" {
+		if checked := r.stats.badPasswords + r.stats.goodPasswords; checked > 0 {
+			r.notef("Exposure: %d of %d cracked passwords (%.1f%%) are in public breach corpora\n",
+				r.stats.badPasswords, checked, 100*float64(r.stats.badPasswords)/float64(checked))
+		}
+	}
+	if r.cfg.Reuse {
+		r.printReuse()
+	}
+	if r.cfg.Top > 0 {
+		r.stats.printTop(r.msg, r.lang, r.cfg.Top)
+	}
+	if r.cfg.ShowStats {
+		r.stats.printSummary(r.msg, r.lang, r.client, r.cfg.Tags)
+	}
+	if r.stats.stoppedAt > 0 {
+		return exitInterrupted
+	}
+	if r.stats.gaveUp {
+		return exitError
+	}
+	if r.stats.badPasswords > r.cfg.FailThreshold {
+		return exitPwned
+	}
+	if r.stats.badPasswords > 0 {
+		r.notef("%s%d compromised passwords are within the -fail-threshold of %d.%s\n",
+			colorYellow, r.stats.badPasswords, r.cfg.FailThreshold, colorReset)
+	}
+	if r.stats.errored > 0 {
+		r.notef("%s%d lookups failed; those entries were not checked.%s\n", colorYellow, r.stats.errored, colorReset)
+	}
+	if r.stats.errored > 0 || r.stats.skipped > 0 {
+		return exitIncomplete
+	}
+	return exitOK
+}
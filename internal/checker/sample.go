@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// z-score for a 95% confidence level
+const sampleZ = 1.96
+
+// reservoirSample streams r once and keeps a uniform random sample of at most
+// n non-empty lines (Algorithm R), so memory stays bounded by the sample size
+// no matter how large the corpus is. It also returns the population size
+// and, like readLines, the lines longer than maxLine.
+func reservoirSample(r io.Reader, n, maxLine int, rng *rand.Rand, text lineText) ([]entry, int, []int, error) {
+	sample := make([]entry, 0, n)
+	var oversized []int
+	population := 0
+	lineNo := 0
+
+	lines := input.NewLines(r, maxLine)
+	for lines.Next() {
+		lineNo++
+		if lines.TooLong() {
+			oversized = append(oversized, lineNo)
+			continue
+		}
+		line := trimLine(lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		population++
+		e := entry{Password: text(line), Line: lineNo}
+		if len(sample) < n {
+			sample = append(sample, e)
+			continue
+		}
+		if j := rng.Intn(population); j < n {
+			sample[j] = e
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, 0, nil, err
+	}
+	return sample, population, oversized, nil
+}
+
+type estimate struct {
+	rate       float64
+	low, high  float64
+	checked    int
+	population int
+}
+
+// estimateRate returns the Wilson score interval for the pwned proportion.
+// The sample is drawn without replacement from a corpus of known size, so
+// the finite population correction enlarges the effective sample, and a
+// sample of the whole corpus pins the interval to the observed rate.
+func estimateRate(bad, checked, population int) estimate {
+	e := estimate{checked: checked, population: population}
+	if checked == 0 {
+		return e
+	}
+
+	p := float64(bad) / float64(checked)
+	if population <= checked {
+		e.rate, e.low, e.high = p, p, p
+		return e
+	}
+	n := float64(checked) * float64(population-1) / float64(population-checked)
+	z2 := sampleZ * sampleZ
+
+	denom := 1 + z2/n
+	center := (p + z2/(2*n)) / denom
+	half := sampleZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denom
+
+	e.rate = p
+	e.low = math.Max(0, center-half)
+	e.high = math.Min(1, center+half)
+	return e
+}
+
+// printEstimate is informational, so -q silences it like other notes.
+func (r *runner) printEstimate(e estimate) {
+	r.notef("\n%sESTIMATE (random sample, not a full check)%s\n", colorYellow, colorReset)
+	r.notef("Checked %d sampled passwords out of %d\n", e.checked, e.population)
+	if e.checked == 0 {
+		r.notef("%sNo sampled password was checked successfully; no estimate available.%s\n", colorYellow, colorReset)
+		return
+	}
+	r.notef("Estimated pwned rate: %.2f%% (95%% CI %.2f%% – %.2f%%)\n", e.rate*100, e.low*100, e.high*100)
+	r.notef("Estimated pwned passwords: %d – %d\n",
+		int(math.Floor(e.low*float64(e.population))), int(math.Ceil(e.high*float64(e.population))))
+}
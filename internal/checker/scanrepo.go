@@ -0,0 +1,353 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// repoFileMax skips files too large to be hand-written configuration.
+const repoFileMax = 1 << 20
+
+// configFiles are the names the default assignment rule looks at.
+var configFiles = []string{
+	".env", ".env.*", "*.env", "*.ini", "*.cfg", "*.conf", "*.cnf", "*.config", "*.properties",
+	"*.yaml", "*.yml", "*.json", "*.toml", "*.xml", "*.tf", "*.tfvars", "*.hcl",
+	"*.sh", "*.bash", "*.ps1", "*.sql", "Dockerfile", "docker-compose*", ".npmrc", ".pypirc", ".netrc",
+}
+
+// scanRule finds candidate passwords, the first non-empty group of Pattern
+// other than "key", in files whose base name matches one of Files (every
+// file when empty). A "key" group names the finding, otherwise the rule's
+// name does.
+type scanRule struct {
+	Name    string   `yaml:"name"`
+	Pattern string   `yaml:"pattern"`
+	Files   []string `yaml:"files"`
+	re      *regexp.Regexp
+}
+
+var defaultRules = []scanRule{
+	{
+		Name:    "assignment",
+		Pattern: `(?i)(?P<key>[\w.-]*(?:pass|pwd|secret|credential)[\w.-]*)["']?\s*[:=]\s*(?:"([^"\r\n]+)"|'([^'\r\n]+)'|([^\s"',;#]+))`,
+		Files:   configFiles,
+	},
+	{
+		Name:    "url-password",
+		Pattern: `[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`,
+	},
+}
+
+// loadRules reads a rules: list in the scanRule layout from rulesPath, or
+// returns the defaults when it is empty.
+func loadRules(rulesPath string) ([]scanRule, error) {
+	rules := defaultRules
+	if rulesPath != "" {
+		data, err := os.ReadFile(rulesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules: %w", err)
+		}
+		var file struct {
+			Rules []scanRule `yaml:"rules"`
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid rules %s: %w", rulesPath, err)
+		}
+		if len(file.Rules) == 0 {
+			return nil, fmt.Errorf("invalid rules %s: no rules", rulesPath)
+		}
+		rules = file.Rules
+	}
+	compiled := make([]scanRule, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		if re.NumSubexp() == 0 || re.NumSubexp() == 1 && re.SubexpIndex("key") == 1 {
+			return nil, fmt.Errorf("rule %q: the pattern needs a group capturing the password", rule.Name)
+		}
+		for _, glob := range rule.Files {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("rule %q: bad file pattern %q", rule.Name, glob)
+			}
+		}
+		rule.re = re
+		compiled[i] = rule
+	}
+	return compiled, nil
+}
+
+func (rule scanRule) appliesTo(name string) bool {
+	if len(rule.Files) == 0 {
+		return true
+	}
+	base := path.Base(name)
+	for _, glob := range rule.Files {
+		if ok, _ := path.Match(glob, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// repoScanner collects candidates, keeping a value found in the same file
+// only once: the working tree copy, or else the newest commit adding it.
+type repoScanner struct {
+	rules   []scanRule
+	entries []entry
+	seen    map[string]bool
+}
+
+func (s *repoScanner) scanLine(file string, lineNo int, line, commit string) {
+	for _, rule := range s.rules {
+		if !rule.appliesTo(file) {
+			continue
+		}
+		for _, m := range rule.re.FindAllStringSubmatch(line, -1) {
+			password, name := "", rule.Name
+			key := rule.re.SubexpIndex("key")
+			for i := 1; i < len(m) && password == ""; i++ {
+				if i != key {
+					password = m[i]
+				}
+			}
+			if key > 0 && m[key] != "" {
+				name = m[key]
+			}
+			if isPlaceholder(password) || s.seen[file+"\x00"+password] {
+				continue
+			}
+			s.seen[file+"\x00"+password] = true
+			if commit != "" {
+				name += " @ " + commit[:min(len(commit), 12)]
+			}
+			s.entries = append(s.entries, entry{Password: password, Account: name, Line: lineNo, Source: file})
+		}
+	}
+}
+
+// isPlaceholder rules out values that only point at the real password:
+// variable and template references, masks and literals.
+func isPlaceholder(v string) bool {
+	if !isPasswordValue([]byte(v)) || isEnvReference(v) {
+		return true
+	}
+	for _, marker := range []string{"${", "{{", "%(", "<%", "#{"} {
+		if strings.Contains(v, marker) {
+			return true
+		}
+	}
+	if strings.HasPrefix(v, "<") && strings.HasSuffix(v, ">") || strings.Trim(v, v[:1]) == "" && len(v) > 1 {
+		return true
+	}
+	switch strings.ToLower(v) {
+	case "true", "false", "null", "nil", "none", "required", "optional":
+		return true
+	}
+	return false
+}
+
+// runRepo scans a git working tree, and its history with -history, for
+// passwords in configuration files. Findings carry their file and line, so
+// the SARIF output locates them in the repository.
+func runRepo(r *runner) int {
+	rules, err := loadRules(r.cfg.RulesFile)
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	s := &repoScanner{rules: rules, seen: make(map[string]bool)}
+	if r.cfg.RepoStaged {
+		return runHook(r, s)
+	}
+	files, err := git(r.cfg.RepoPath, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	scanned := 0
+	for _, file := range strings.Split(strings.TrimSuffix(string(files), "\x00"), "\x00") {
+		if file == "" {
+			continue
+		}
+		ok, err := scanWorkingFile(s, r.cfg.RepoPath, file)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		if ok {
+			scanned++
+		}
+	}
+	r.notef("Scanned %d files", scanned)
+	if r.cfg.RepoHistory {
+		commits, err := scanHistory(s, r.cfg.RepoPath)
+		if err != nil {
+			return r.fail("%v", err)
+		}
+		r.notef(" and %d commits", commits)
+	}
+	r.notef(", found %d candidate passwords.\n\n", len(s.entries))
+	if len(s.entries) == 0 {
+		return r.finish()
+	}
+
+	r.cfg.IsHashed = false
+	r.source, r.style = r.cfg.RepoPath, styleList
+	if code := r.run(s.entries); code != exitOK {
+		return code
+	}
+	return r.finish()
+}
+
+// runHook checks the staged changes and stays silent when they hold no
+// candidate, so a pre-commit hook only speaks up about findings.
+func runHook(r *runner, s *repoScanner) int {
+	if err := scanStaged(s, r.cfg.RepoPath); err != nil {
+		return r.fail("%v", err)
+	}
+	if len(s.entries) == 0 {
+		return exitOK
+	}
+	r.notef("Checking %d candidate passwords in the staged changes.\n\n", len(s.entries))
+	r.cfg.IsHashed = false
+	r.source, r.style = r.cfg.RepoPath, styleList
+	code := r.run(s.entries)
+	if code == exitOK {
+		code = r.finish()
+	}
+	if code == exitPwned {
+		r.printf("%sCommit blocked: replace the breached passwords above, or commit with --no-verify to override.%s\n", colorRed, colorReset)
+	}
+	return code
+}
+
+// scanWorkingFile scans one file of the tree unless it is gone, binary or
+// too large.
+func scanWorkingFile(s *repoScanner, root, file string) (bool, error) {
+	name := filepath.Join(root, filepath.FromSlash(file))
+	info, err := os.Stat(name)
+	// deleted but still in the index, or a directory such as a submodule
+	if errors.Is(err, fs.ErrNotExist) || err == nil && (!info.Mode().IsRegular() || info.Size() > repoFileMax) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return false, err
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return false, nil
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		s.scanLine(file, i+1, strings.TrimSuffix(line, "\r"), "")
+	}
+	return true, nil
+}
+
+// scanHistory scans the lines every commit on every ref added, newest
+// first.
+func scanHistory(s *repoScanner, root string) (int, error) {
+	return scanPatches(s, root, "log", "--all", "-p", "--format=%x00%H")
+}
+
+// scanStaged scans the lines added in the index, for the pre-commit hook.
+func scanStaged(s *repoScanner, root string) error {
+	_, err := scanPatches(s, root, "diff", "--cached")
+	return err
+}
+
+// scanPatches scans the added lines of the zero-context patches git prints
+// for args, counting the commits git log separates with a NUL line.
+func scanPatches(s *repoScanner, root string, args ...string) (int, error) {
+	sub := args[0]
+	args = append([]string{"-C", root, "-c", "core.quotePath=false"}, append(args, "-U0", "--no-color", "--no-ext-diff", "--no-renames")...)
+	cmd := exec.Command("git", args...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to run git: %w", err)
+	}
+
+	commits, commit, file, lineNo := 0, "", "", 0
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			commit, file = line[1:], ""
+			commits++
+		case strings.HasPrefix(line, "+++ "):
+			file = patchPath(line[4:])
+		case strings.HasPrefix(line, "@@ "):
+			lineNo = hunkStart(line)
+		case strings.HasPrefix(line, "+") && file != "":
+			s.scanLine(file, lineNo, line[1:], commit)
+			lineNo++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, fmt.Errorf("failed to read git %s: %w", sub, err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return 0, fmt.Errorf("git %s failed: %s", sub, strings.TrimSpace(stderr.String()))
+	}
+	return commits, nil
+}
+
+// patchPath reads the new path of a "+++ b/path" header; deleted files have
+// none.
+func patchPath(p string) string {
+	if p == "/dev/null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(p); err == nil {
+		p = unquoted
+	}
+	return strings.TrimPrefix(p, "b/")
+}
+
+// hunkStart reads the first new line number of "@@ -a,b +c,d @@".
+func hunkStart(header string) int {
+	_, rest, _ := strings.Cut(header, " +")
+	n, _, _ := strings.Cut(rest, " ")
+	n, _, _ = strings.Cut(n, ",")
+	start, _ := strconv.Atoi(n)
+	return start
+}
+
+func git(root string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("failed to run git: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package checker
+
+import (
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSecretKeys matches the keys -input-format dotenv, k8s-audit and
+// aws-audit check by default.
+const DefaultSecretKeys = `(?i)pass|pwd|secret|token|credential`
+
+// secretValueMax is the longest value treated as a password; certificates,
+// keys and whole config files are longer.
+const secretValueMax = 256
+
+// isPasswordValue rules out empty, binary, multi-line and overly long values.
+func isPasswordValue(v []byte) bool {
+	return len(v) > 0 && len(v) <= secretValueMax && utf8.Valid(v) && !strings.ContainsAny(string(v), "\r\n")
+}
+
+// jsonSecretEntries reads a secret holding a JSON object, as Secrets Manager's
+// key/value secrets do: string values under matching keys are checked, with
+// the object's username when it has one. ok is false for anything but an
+// object.
+func jsonSecretEntries(value, name string, keys *regexp.Regexp) (entries []entry, ok bool) {
+	var fields map[string]any
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") || json.Unmarshal([]byte(value), &fields) != nil {
+		return nil, false
+	}
+	username := ""
+	for _, k := range []string{"username", "user"} {
+		if v, ok := fields[k].(string); ok && isPasswordValue([]byte(v)) {
+			username = v
+			break
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		if v, ok := fields[k].(string); ok && keys.MatchString(k) && isPasswordValue([]byte(v)) {
+			entries = append(entries, entry{Password: v, Account: name + "#" + k, Username: username})
+		}
+	}
+	return entries, true
+}
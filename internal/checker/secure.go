@@ -0,0 +1,54 @@
+package checker
+
+import (
+	"bytes"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"golang.org/x/text/unicode/norm"
+)
+
+// lineText turns a trimmed input line into the string an entry carries. The
+// line aliases the scanner's reusable buffer, so it must not be retained.
+type lineText func(line []byte) string
+
+func plainText(line []byte) string {
+	return string(line)
+}
+
+// secureText hashes each line straight out of the scanner buffer and zeroes
+// it, so -secure-memory runs never hold plaintext strings: entries carry the
+// hash and are checked as hashed input.
+func secureText(cfg Config) lineText {
+	return func(line []byte) string {
+		buf := line
+		switch cfg.Normalize {
+		case "nfc":
+			buf = norm.NFC.Bytes(line)
+		case "nfkc":
+			buf = norm.NFKC.Bytes(line)
+		}
+		var hash string
+		if cfg.NTLM {
+			hash = hibp.HashNTLMBytes(buf)
+		} else {
+			hash = hibp.HashPasswordBytes(buf)
+		}
+		clear(line)
+		if len(buf) > 0 && &buf[0] != &line[0] {
+			clear(buf)
+		}
+		return hash
+	}
+}
+
+// textFor picks how input lines become entries for this run.
+func textFor(cfg Config) lineText {
+	if cfg.SecureMemory && !cfg.IsHashed {
+		return secureText(cfg)
+	}
+	return plainText
+}
+
+func trimLine(b []byte) []byte {
+	return bytes.TrimSpace(b)
+}
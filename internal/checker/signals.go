@@ -0,0 +1,41 @@
+package checker
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// interrupts notices the first SIGINT or SIGTERM so a run can stop between
+// checks and still report what it did. Handling is reset after that signal,
+// so a second one kills the process the usual way.
+type interrupts struct {
+	ch  chan os.Signal
+	got atomic.Bool
+}
+
+func watchInterrupts() *interrupts {
+	in := &interrupts{ch: make(chan os.Signal, 1)}
+	signal.Notify(in.ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig, ok := <-in.ch
+		if !ok {
+			return
+		}
+		signal.Stop(in.ch)
+		in.got.Store(true)
+		fmt.Fprintf(os.Stderr, "\n%sReceived %v: stopping after the current check; repeat to abort.%s\n", colorYellow, sig, colorReset)
+	}()
+	return in
+}
+
+func (in *interrupts) stopped() bool {
+	return in.got.Load()
+}
+
+func (in *interrupts) close() {
+	signal.Stop(in.ch)
+	close(in.ch)
+}
@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// Error kinds counted in the summary's error_kinds.
+const (
+	errKindInvalidHash = "invalid_hash"
+	errKindTimeout     = "timeout"
+	errKindRateLimited = "rate_limited"
+	errKindAPIStatus   = "api_status"
+	errKindMalformed   = "malformed"
+	errKindNetwork     = "network"
+	errKindOther       = "other"
+)
+
+// errorKind sorts a failed lookup into one of the errKind buckets.
+func errorKind(err error) string {
+	var invalid *InvalidHashError
+	switch {
+	case errors.As(err, &invalid):
+		return errKindInvalidHash
+	case errors.Is(err, hibp.ErrTimeout):
+		return errKindTimeout
+	case errors.Is(err, hibp.ErrRateLimited):
+		return errKindRateLimited
+	case errors.Is(err, hibp.ErrMalformed):
+		return errKindMalformed
+	case errors.Is(err, hibp.ErrBadResponse):
+		return errKindAPIStatus
+	case errors.Is(err, hibp.ErrOffline), errors.Is(err, hibp.ErrPinMismatch):
+		return errKindNetwork
+	}
+	return errKindOther
+}
+
+// statsFile is the -stats-file document: the JSON summary plus what a
+// monitoring job needs without the results.
+type statsFile struct {
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	RuntimeSeconds float64   `json:"runtime_seconds"`
+	ExitCode       int       `json:"exit_code"`
+	runSummary
+	// CacheHitRatio is the share of lookups answered without a request.
+	CacheHitRatio float64   `json:"cache_hit_ratio"`
+	Connections   connUsage `json:"connections"`
+}
+
+type connUsage struct {
+	New    int `json:"new"`
+	Reused int `json:"reused"`
+	HTTP2  int `json:"http2"`
+}
+
+// writeStatsFile replaces path through a temporary file, like saveState,
+// so a job polling it never reads half a document.
+func (r *runner) writeStatsFile(path string, code int) error {
+	now := time.Now()
+	doc := statsFile{
+		StartedAt:      r.stats.startTime.UTC(),
+		FinishedAt:     now.UTC(),
+		RuntimeSeconds: now.Sub(r.stats.startTime).Seconds(),
+		ExitCode:       code,
+		runSummary:     r.stats.summary(r.client, r.cfg.Tags),
+	}
+	if cs := r.client.CacheStats(); cs.PositiveHits+cs.NegativeHits+cs.Misses > 0 {
+		doc.CacheHitRatio = float64(cs.PositiveHits+cs.NegativeHits) / float64(cs.PositiveHits+cs.NegativeHits+cs.Misses)
+	}
+	cs := r.client.ConnStats()
+	doc.Connections = connUsage{New: cs.New, Reused: cs.Reused, HTTP2: cs.HTTP2}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,75 @@
+package checker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+const (
+	stdioPwned = "pwned"
+	stdioClean = "clean"
+	stdioError = "error"
+)
+
+// runStdio answers one `status<TAB>count` line per input line and flushes
+// after every answer, so a parent process can drive it as a long-lived
+// subprocess. Blank and oversized lines are answered too, to keep requests
+// and responses in lockstep. Diagnostics go to stderr and never interleave with stdout.
+func runStdio(client *Checker, cfg Config, in io.Reader, out io.Writer) int {
+	normalize, err := normalizer(cfg.Normalize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pwnedcheck: %v\n", err)
+		return exitUsage
+	}
+
+	w := bufio.NewWriter(out)
+	lines := input.NewLines(in, cfg.MaxLineLength)
+	errored := false
+
+	for lines.Next() {
+		line := strings.TrimSpace(string(lines.Bytes()))
+
+		status, count := stdioClean, 0
+		if lines.TooLong() {
+			status = stdioError
+			fmt.Fprintf(os.Stderr, "pwnedcheck: input line longer than %d bytes\n", cfg.MaxLineLength)
+		} else if line == "" {
+			status = stdioError
+			fmt.Fprintln(os.Stderr, "pwnedcheck: empty input line")
+		} else {
+			if normalize != nil && !cfg.IsHashed {
+				line = normalize(line)
+			}
+			res, err := client.Check(line, cfg.IsHashed)
+			switch {
+			case err != nil:
+				status = stdioError
+				fmt.Fprintf(os.Stderr, "pwnedcheck: %v\n", err)
+			case isPwned(res, cfg.MinCount):
+				status, count = stdioPwned, res.Count
+			}
+		}
+
+		if status == stdioError {
+			errored = true
+		}
+		fmt.Fprintf(w, "%s\t%d\n", status, count)
+		if err := w.Flush(); err != nil {
+			return exitError
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "pwnedcheck: failed to read stdin: %v\n", err)
+		return exitError
+	}
+	if errored {
+		return exitIncomplete
+	}
+	return exitOK
+}
@@ -0,0 +1,26 @@
+package checker
+
+import (
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// weakScore is the highest zxcvbn score that still earns a warning.
+const weakScore = 2
+
+type strength struct {
+	Score     int
+	CrackTime string
+}
+
+// estimateStrength runs zxcvbn locally. The account and username are passed
+// as user inputs so passwords built from them score as weak.
+func estimateStrength(e entry) strength {
+	var inputs []string
+	for _, s := range []string{e.Account, e.Username} {
+		if s != "" {
+			inputs = append(inputs, s)
+		}
+	}
+	m := zxcvbn.PasswordStrength(e.Password, inputs)
+	return strength{Score: m.Score, CrackTime: m.CrackTimeDisplay}
+}
@@ -0,0 +1,93 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// strictSingleMax bounds the stdin -strict-single reads; nothing longer is
+// a password.
+const strictSingleMax = 4096
+
+var errStrictInput = errors.New("stdin must hold exactly one password")
+
+// strictResult is the JSON object -strict-single writes to fd 3.
+type strictResult struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runStrictSingle checks the one password on stdin for PAM helpers, signup
+// flows and other callers that only look at the exit code: 0 clean, 3 pwned,
+// 4 failed lookup, 2 bad input and 1 bad setup. Nothing is printed; when the
+// caller opened fd 3, the same answer goes there as one JSON line.
+func runStrictSingle(cfg Config, in io.Reader) int {
+	// taken before anything else is opened, which could land on fd 3
+	report := os.NewFile(3, "fd3")
+	if _, err := report.Stat(); err != nil {
+		report = nil
+	}
+	answer := func(code int, res strictResult) int {
+		if report != nil {
+			json.NewEncoder(report).Encode(res)
+		}
+		return code
+	}
+	fail := func(code int, err error) int {
+		return answer(code, strictResult{Status: stdioError, Error: err.Error()})
+	}
+
+	password, err := readStrictSingle(in)
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+	normalize, err := normalizer(cfg.Normalize)
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+	if normalize != nil && !cfg.IsHashed {
+		password = normalize(password)
+	}
+
+	client, err := newClient(cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		return fail(clientExit(err), err)
+	}
+	defer client.Close()
+
+	res, err := client.Check(password, cfg.IsHashed)
+	var invalid *InvalidHashError
+	switch {
+	case errors.As(err, &invalid):
+		return fail(exitUsage, err)
+	case err != nil:
+		return fail(exitIncomplete, err)
+	case isPwned(res, cfg.MinCount):
+		return answer(exitPwned, strictResult{Status: stdioPwned, Count: res.Count})
+	}
+	return answer(exitOK, strictResult{Status: stdioClean})
+}
+
+// readStrictSingle reads stdin to the end and accepts one non-empty line,
+// with or without its line ending.
+func readStrictSingle(in io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(in, strictSingleMax+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if len(data) > strictSingleMax {
+		return "", errStrictInput
+	}
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	if len(data) == 0 || bytes.ContainsAny(data, "\r\n") {
+		return "", errStrictInput
+	}
+	return string(data), nil
+}
@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/nbutton23/zxcvbn-go/frequency"
+)
+
+// passphraseWords is the diceware-sized word count: 7776 words give about
+// 12.9 bits of entropy each.
+const passphraseWords = 7776
+
+var charsets = map[string]string{
+	"alnum":   "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	"symbols": "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#$%&*+-=?@^_~",
+	"hex":     "0123456789abcdef",
+}
+
+// wordlist takes the most frequent plain English words of 4 to 8 letters,
+// which are easy to type and remember.
+var wordlist = sync.OnceValue(func() []string {
+	words := make([]string, 0, passphraseWords)
+	for _, w := range frequency.Lists["English"].List {
+		if len(w) < 4 || len(w) > 8 || strings.IndexFunc(w, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0 {
+			continue
+		}
+		if words = append(words, w); len(words) == passphraseWords {
+			break
+		}
+	}
+	return words
+})
+
+// suggester generates replacements with crypto/rand: a passphrase of words
+// joined by dashes when words > 0, otherwise length characters of charset.
+type suggester struct {
+	length  int
+	charset []rune
+	words   int
+}
+
+func newSuggester(length int, charset string, words int) (*suggester, error) {
+	if named, ok := charsets[charset]; ok {
+		charset = named
+	}
+	s := &suggester{length: length, charset: []rune(charset), words: words}
+	if words <= 0 && (length <= 0 || len(s.charset) < 2) {
+		return nil, fmt.Errorf("-suggest needs a positive -suggest-length and a -suggest-charset of at least two characters")
+	}
+	return s, nil
+}
+
+func (s *suggester) suggest() (string, error) {
+	if s.words > 0 {
+		list := wordlist()
+		words := make([]string, s.words)
+		for i := range words {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+			if err != nil {
+				return "", err
+			}
+			words[i] = list[n.Int64()]
+		}
+		return strings.Join(words, "-"), nil
+	}
+
+	out := make([]rune, s.length)
+	for i := range out {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(s.charset))))
+		if err != nil {
+			return "", err
+		}
+		out[i] = s.charset[n.Int64()]
+	}
+	return string(out), nil
+}
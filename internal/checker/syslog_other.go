@@ -0,0 +1,65 @@
+//go:build !windows
+
+package checker
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+)
+
+// openSyslog connects to the local daemon for "local", or to a remote
+// collector given as udp://host:port or tcp://host:port.
+func openSyslog(target string) (*syslog.Writer, error) {
+	const priority = syslog.LOG_AUTH | syslog.LOG_INFO
+	network, addr := "", ""
+	if target != "local" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("invalid -syslog %q, expected local, udp://host:port or tcp://host:port", target)
+		}
+		network, addr = u.Scheme, u.Host
+	}
+	w, err := syslog.Dial(network, addr, priority, "pwnedcheck")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return w, nil
+}
+
+type syslogWriter struct {
+	w    *syslog.Writer
+	tags string
+}
+
+func newSyslogWriter(target string, tags []Tag) (*syslogWriter, error) {
+	w, err := openSyslog(target)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogWriter{w: w, tags: formatTags(tags)}, nil
+}
+
+// write forwards findings as warnings and failed lookups as errors; clean
+// entries only show up in the closing summary.
+func (s *syslogWriter) write(rec record) error {
+	switch rec.Status {
+	case statusPwned:
+		return s.w.Warning(syslogMessage(rec, s.tags))
+	case statusError:
+		return s.w.Err(syslogMessage(rec, s.tags))
+	}
+	return nil
+}
+
+func (s *syslogWriter) close(summary runSummary) error {
+	msg := fmt.Sprintf("summary total=%d bad=%d good=%d runtime=%s", summary.Total, summary.Bad, summary.Good, summary.Runtime)
+	if s.tags != "" {
+		msg += " " + s.tags
+	}
+	if err := s.w.Notice(msg); err != nil {
+		s.w.Close()
+		return err
+	}
+	return s.w.Close()
+}
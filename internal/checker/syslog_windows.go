@@ -0,0 +1,15 @@
+//go:build windows
+
+package checker
+
+import "errors"
+
+type syslogWriter struct{}
+
+func newSyslogWriter(string, []Tag) (*syslogWriter, error) {
+	return nil, errors.New("-syslog is not supported on Windows")
+}
+
+func (*syslogWriter) write(record) error { return nil }
+
+func (*syslogWriter) close(runSummary) error { return nil }
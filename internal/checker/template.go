@@ -0,0 +1,64 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// templateData is what a -template sees for each result.
+type templateData struct {
+	record
+	Pwned bool
+	Tags  map[string]string
+}
+
+// templateEscapes lets the usual escapes be typed inside single quotes on a
+// shell command line.
+var templateEscapes = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+
+// templateWriter renders one line per result. A newline is added unless the
+// template already ends with one.
+type templateWriter struct {
+	w    io.Writer
+	tmpl *template.Template
+	tags map[string]string
+	nl   bool
+}
+
+func newTemplateWriter(text string, tags []Tag, w io.Writer) (*templateWriter, error) {
+	text = templateEscapes.Replace(text)
+	tmpl, err := template.New("result").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -template: %w", err)
+	}
+	t := &templateWriter{w: w, tmpl: tmpl, nl: !strings.HasSuffix(text, "\n")}
+	if len(tags) > 0 {
+		t.tags = make(map[string]string, len(tags))
+		for _, tag := range tags {
+			t.tags[tag.Key] = tag.Value
+		}
+	}
+	// catch unknown fields before the first lookup instead of after it
+	if err := tmpl.Execute(io.Discard, templateData{Tags: t.tags}); err != nil {
+		return nil, fmt.Errorf("invalid -template: %w", err)
+	}
+	return t, nil
+}
+
+func (t *templateWriter) write(rec record) error {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, templateData{record: rec, Pwned: rec.Status == statusPwned, Tags: t.tags}); err != nil {
+		return err
+	}
+	if t.nl {
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *templateWriter) close(runSummary) error {
+	return nil
+}
@@ -0,0 +1,357 @@
+package checker
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// dashboard runs the -tui screen next to a runner. The runner sends it
+// every listed record and a stats snapshot after each check; the screen
+// pauses the runner between checks and asks it to stop on q.
+type dashboard struct {
+	prog *tea.Program
+	mu   sync.Mutex
+	// resume is open while paused and closed to let the runner go on.
+	resume chan struct{}
+	quit   atomic.Bool
+	// done is closed when the screen is gone; final holds its last state.
+	done  chan struct{}
+	final dashModel
+	err   error
+}
+
+func startDashboard(source string, total int) (*dashboard, error) {
+	if !term.IsTerminal(int(os.Stdout.Fd())) || !enableVirtualTerminal(os.Stdout) {
+		return nil, errors.New("-tui needs a terminal on stdout")
+	}
+	d := &dashboard{done: make(chan struct{})}
+	now := time.Now()
+	m := dashModel{ctl: d, source: source, total: total, start: now, rate: newThroughput(now)}
+	d.prog = tea.NewProgram(m, tea.WithAltScreen(), tea.WithInputTTY())
+	go func() {
+		defer close(d.done)
+		final, err := d.prog.Run()
+		if m, ok := final.(dashModel); ok {
+			d.final = m
+		}
+		d.err = err
+		d.unpause()
+	}()
+	return d, nil
+}
+
+// wait blocks while the screen is paused.
+func (d *dashboard) wait() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	ch := d.resume
+	d.mu.Unlock()
+	if ch != nil {
+		<-ch
+	}
+}
+
+// stopped reports that the screen was quit before the run ended.
+func (d *dashboard) stopped() bool {
+	return d != nil && d.quit.Load()
+}
+
+func (d *dashboard) togglePause() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resume == nil {
+		d.resume = make(chan struct{})
+		return true
+	}
+	close(d.resume)
+	d.resume = nil
+	return false
+}
+
+func (d *dashboard) unpause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resume != nil {
+		close(d.resume)
+		d.resume = nil
+	}
+}
+
+func (d *dashboard) add(rec record) {
+	d.prog.Send(recordMsg(rec))
+}
+
+func (d *dashboard) update(done int, stats *statistics, client *Checker) {
+	d.prog.Send(statsMsg{
+		done:   done,
+		bad:    stats.badPasswords,
+		good:   stats.goodPasswords,
+		errors: stats.errored,
+		cache:  client.CacheStats(),
+		conn:   client.ConnStats(),
+	})
+}
+
+// close tells the screen the run is over and, unless it was interrupted,
+// waits for the user to leave it. It returns the records the screen listed
+// so they end up in the terminal.
+func (d *dashboard) close(interrupted bool) ([]record, error) {
+	if interrupted {
+		d.prog.Quit()
+	} else {
+		d.prog.Send(finishedMsg{})
+	}
+	<-d.done
+	return d.final.rows, d.err
+}
+
+type (
+	recordMsg   record
+	finishedMsg struct{}
+	statsMsg    struct {
+		done, bad, good, errors int
+		cache                   hibp.CacheStats
+		conn                    hibp.ConnStats
+	}
+)
+
+// dashFilters are the views f cycles through.
+var dashFilters = []string{"all", statusPwned, statusError}
+
+type dashModel struct {
+	ctl    *dashboard
+	source string
+	total  int
+	start  time.Time
+	rate   *throughput
+	stats  statsMsg
+	rows   []record
+	// filter indexes dashFilters; search narrows rows to those containing it.
+	filter    int
+	search    string
+	searching bool
+	// scroll counts rows hidden below the view; 0 follows new rows.
+	scroll        int
+	width, height int
+	paused        bool
+	finished      bool
+	took          time.Duration
+}
+
+func (m dashModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m dashModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case recordMsg:
+		m.rows = append(m.rows, record(msg))
+		if m.scroll > 0 && m.matches(record(msg)) {
+			m.scroll++
+		}
+	case statsMsg:
+		m.stats = msg
+		m.rate.add(msg.done, time.Now())
+	case finishedMsg:
+		m.finished, m.paused = true, false
+		m.took = time.Since(m.start)
+	case tea.KeyMsg:
+		return m.key(msg)
+	}
+	return m, nil
+}
+
+func (m dashModel) key(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searching {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.searching = false
+		case tea.KeyEsc:
+			m.searching, m.search = false, ""
+		case tea.KeyBackspace:
+			if m.search != "" {
+				_, size := utf8.DecodeLastRuneInString(m.search)
+				m.search = m.search[:len(m.search)-size]
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.search += string(msg.Runes)
+		case tea.KeyCtrlC:
+			m.ctl.quit.Store(true)
+			return m, tea.Quit
+		}
+		m.scroll = 0
+		return m, nil
+	}
+	switch msg.String() {
+	case "q", "ctrl+c":
+		if !m.finished {
+			m.ctl.quit.Store(true)
+		}
+		return m, tea.Quit
+	case "p", " ":
+		if !m.finished {
+			m.paused = m.ctl.togglePause()
+		}
+	case "f":
+		m.filter = (m.filter + 1) % len(dashFilters)
+		m.scroll = 0
+	case "/":
+		m.searching = true
+	case "up", "k":
+		m.scroll++
+	case "down", "j":
+		m.scroll--
+	case "pgup":
+		m.scroll += m.tableHeight()
+	case "pgdown":
+		m.scroll -= m.tableHeight()
+	case "home", "g":
+		m.scroll = len(m.rows)
+	case "end", "G":
+		m.scroll = 0
+	}
+	m.scroll = max(m.scroll, 0)
+	return m, nil
+}
+
+func (m dashModel) matches(rec record) bool {
+	if f := dashFilters[m.filter]; f != "all" && rec.Status != f {
+		return false
+	}
+	if m.search == "" {
+		return true
+	}
+	needle := strings.ToLower(m.search)
+	for _, s := range []string{rec.Account, rec.Username, rec.Folder, rec.Source, rec.Password, rec.Error} {
+		if strings.Contains(strings.ToLower(s), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableHeight leaves room for the header, the column titles and the help
+// line.
+func (m dashModel) tableHeight() int {
+	return max(m.height-7, 1)
+}
+
+func (m dashModel) View() string {
+	var b strings.Builder
+	width := cmp.Or(m.width, 80)
+	s := m.stats
+
+	state := "running"
+	switch {
+	case m.finished:
+		state = "done in " + m.took.Round(time.Second).String()
+	case m.paused:
+		state = colorYellow + "paused" + colorReset
+	}
+	fmt.Fprintf(&b, "PwnedCheck  %s  [%s]\n", clip(m.source, width-30), state)
+
+	filled, pct := 0, 100
+	if m.total > 0 {
+		filled, pct = progressWidth*s.done/m.total, 100*s.done/m.total
+	}
+	rate, eta := 0.0, "--"
+	if !m.finished {
+		rate, eta = m.rate.rate(), m.rate.eta(m.total-s.done)
+	}
+	fmt.Fprintf(&b, "[%s%s] %3d%% %d/%d  %.1f/s  ETA %s\n", strings.Repeat("#", filled), strings.Repeat(".", progressWidth-filled), pct, s.done, m.total, rate, eta)
+	fmt.Fprintf(&b, "%sbad %d%s  %sgood %d%s  errors %d   cache %d hits / %d misses   connections %d new / %d reused\n",
+		colorRed, s.bad, colorReset, colorGreen, s.good, colorReset, s.errors,
+		s.cache.PositiveHits+s.cache.NegativeHits+s.cache.RangeHits, s.cache.Misses, s.conn.New, s.conn.Reused)
+
+	var rows []record
+	for _, rec := range m.rows {
+		if m.matches(rec) {
+			rows = append(rows, rec)
+		}
+	}
+	search := ""
+	if m.search != "" || m.searching {
+		search = "  search: " + m.search
+		if m.searching {
+			search += "_"
+		}
+	}
+	fmt.Fprintf(&b, "\nFindings: %s (%d)%s\n", dashFilters[m.filter], len(rows), search)
+	fmt.Fprintf(&b, "%s\n", clip(fmt.Sprintf("%7s  %-7s %9s  %s", "#", "STATUS", "COUNT", "ENTRY"), width))
+
+	height := m.tableHeight()
+	end := max(len(rows)-min(m.scroll, max(len(rows)-height, 0)), 0)
+	for _, rec := range rows[max(end-height, 0):end] {
+		b.WriteString(dashRow(rec, width))
+		b.WriteByte('\n')
+	}
+	for range height - min(len(rows), height) {
+		b.WriteByte('\n')
+	}
+
+	help := "p pause  f filter  / search  ↑↓ scroll  q stop"
+	if m.finished {
+		help = "f filter  / search  ↑↓ scroll  q exit"
+	}
+	b.WriteString(help)
+	return b.String()
+}
+
+func dashRow(rec record, width int) string {
+	status, c := strings.ToUpper(rec.Status), ""
+	switch {
+	case rec.Status == statusPwned:
+		c = colorRed
+	case rec.Status == statusError:
+		c = colorYellow
+	case rec.Variant != "":
+		status, c = "VARIANT", colorYellow
+	case rec.Policy == policyFail:
+		status, c = "POLICY", colorYellow
+	}
+	name := cmp.Or(rec.Account, rec.Username)
+	if rec.Line > 0 {
+		name = strings.TrimSpace(fmt.Sprintf("%s:%d %s", rec.Source, rec.Line, name))
+	}
+	if name == "" {
+		name = fmt.Sprintf("item #%d", rec.Item)
+	}
+	switch {
+	case rec.Status == statusError:
+		name += "  " + rec.Error
+	case rec.Password != "":
+		name += "  " + rec.Password
+	}
+	count := ""
+	if rec.Count > 0 {
+		count = fmt.Sprint(rec.Count)
+	}
+	// coloring after clipping keeps escapes out of the width
+	line := clip(fmt.Sprintf("%7d  %-7s %9s  %s", rec.Item, status, count, name), width)
+	return c + line + colorReset
+}
+
+// clip cuts s to width runes.
+func clip(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max(width-1, 0)]) + "…"
+}
@@ -0,0 +1,92 @@
+package checker
+
+import (
+	"strings"
+	"unicode"
+)
+
+// variant is an obvious transformation of a password and how it was made.
+type variant struct {
+	Kind     string
+	Password string
+}
+
+var (
+	unleet = strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "5", "s", "$", "s", "7", "t")
+	leet   = strings.NewReplacer("a", "@", "e", "3", "i", "1", "o", "0", "s", "$", "t", "7")
+)
+
+// variants lists case flips, trailing-digit changes and leetspeak in both
+// directions, without duplicates or the password itself.
+func variants(password string) []variant {
+	base := strings.TrimRightFunc(password, unicode.IsDigit)
+	candidates := []variant{
+		{"lowercase", strings.ToLower(password)},
+		{"uppercase", strings.ToUpper(password)},
+		{"capitalized", capitalize(strings.ToLower(password))},
+		{"case-flip", swapCase(password)},
+		{"trailing-digits", base},
+		{"trailing-digits", base + "1"},
+		{"trailing-digits", base + "123"},
+		{"leetspeak", unleet.Replace(strings.ToLower(password))},
+		{"leetspeak", leet.Replace(strings.ToLower(password))},
+	}
+
+	seen := map[string]bool{password: true, "": true}
+	var out []variant
+	for _, v := range candidates {
+		if !seen[v.Password] {
+			seen[v.Password] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
+
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsUpper(r):
+			return unicode.ToLower(r)
+		case unicode.IsLower(r):
+			return unicode.ToUpper(r)
+		}
+		return r
+	}, s)
+}
+
+// checkVariants looks up the variants of a clean password and returns the
+// first one that is pwned. Each lookup is gated by halt and runs under the
+// breaker like the entry's own, stopping with the reason halt gave; failed
+// ones are logged and skipped.
+func (r *runner) checkVariants(password string, halt func() string, bar *progress) (variant, int, bool, string) {
+	for _, v := range variants(password) {
+		if why := halt(); why != "" {
+			return variant{}, 0, false, why
+		}
+		var res Result
+		var err error
+		if why := r.guard(halt, bar, func() error {
+			res, err = r.client.Check(v.Password, false)
+			return err
+		}); why != "" {
+			return variant{}, 0, false, why
+		}
+		if err != nil {
+			r.client.Logger().Warn("variant lookup failed", "kind", v.Kind, "err", err)
+			continue
+		}
+		if isPwned(res, r.cfg.MinCount) {
+			return v, res.Count, true, ""
+		}
+	}
+	return variant{}, 0, false, ""
+}
@@ -0,0 +1,285 @@
+package checker
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+const (
+	verdictFixed      = "fixed"
+	verdictStillPwned = "still-pwned"
+	verdictRemoved    = "removed"
+)
+
+// reportResult mirrors the JSON written by -format json. Every field is
+// optional because -fields may have left it out.
+type reportResult struct {
+	Item     int    `json:"item"`
+	Line     int    `json:"line"`
+	Source   string `json:"source"`
+	Account  string `json:"account"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Hash     string `json:"hash"`
+	Status   string `json:"status"`
+	Count    int    `json:"count"`
+}
+
+type report struct {
+	Results []reportResult `json:"results"`
+}
+
+type closureEntry struct {
+	Item     int    `json:"item"`
+	Line     int    `json:"line,omitempty"`
+	Account  string `json:"account,omitempty"`
+	Username string `json:"username,omitempty"`
+	Verdict  string `json:"verdict"`
+	Count    int    `json:"count,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type closureReport struct {
+	From       string         `json:"from"`
+	VerifiedAt time.Time      `json:"verified_at"`
+	Flagged    int            `json:"flagged"`
+	Fixed      int            `json:"fixed"`
+	StillPwned int            `json:"still_pwned"`
+	Removed    int            `json:"removed"`
+	Errors     int            `json:"errors"`
+	Entries    []closureEntry `json:"entries"`
+}
+
+// VerifyFix re-checks only the entries a previous JSON report flagged as
+// pwned. With an input (-i or -bw) each finding is matched against the
+// current data by account and username, or by line for plain lists: entries
+// that disappeared are "removed", the rest are re-checked with their current
+// password. Without an input, the hash or password stored in the report is
+// re-checked as is.
+func VerifyFix(cfg Config) int {
+	setColors(colorEnabled(cfg, os.Stdout))
+	if err := cmp.Or(checkInputFormat(cfg.InputFormat), input.CheckEncoding(cfg.Encoding)); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+
+	data, err := os.ReadFile(cfg.VerifyFrom)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sFailed to read report: %v%s\n", colorRed, err, colorReset)
+		return exitError
+	}
+	var prior report
+	if err := json.Unmarshal(data, &prior); err != nil {
+		fmt.Fprintf(os.Stderr, "%sFailed to parse report %s: %v%s\n", colorRed, cfg.VerifyFrom, err, colorReset)
+		return exitError
+	}
+
+	var flagged []reportResult
+	for _, res := range prior.Results {
+		if res.Status == statusPwned {
+			flagged = append(flagged, res)
+		}
+	}
+
+	withInput := cfg.InputFile != "" && len(flagged) > 0
+	if cfg.InputFormat == "auto" {
+		cfg.InputFormat = "lines"
+		if withInput && !cfg.Bitwarden {
+			cfg.InputFormat = detectInputFormat(cfg)
+		}
+	}
+	if isHashDump(cfg.InputFormat) {
+		cfg.NTLM, cfg.IsHashed = true, true
+	}
+
+	client, err := newClient(cfg, newLogger(cfg.Verbosity))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s%v%s\n", colorRed, err, colorReset)
+		return clientExit(err)
+	}
+	defer client.Close()
+
+	r := &runner{cfg: cfg, client: client, stats: &statistics{startTime: time.Now()}, msg: os.Stderr, lang: langFor(cfg.Lang)}
+	if r.secretKeys, err = regexp.Compile(cmp.Or(cfg.SecretKeys, DefaultSecretKeys)); err != nil {
+		return r.fail("invalid -keys: %v", err)
+	}
+
+	var current []entry
+	if withInput {
+		if !slices.ContainsFunc(flagged, matchable) {
+			return r.fail("Report %s has neither line nor account for its findings, so they cannot be matched to the current input; write it with -fields including line", cfg.VerifyFrom)
+		}
+		if current, err = loadCurrent(r); err != nil {
+			return r.fail("%v", err)
+		}
+		if len(current) > 0 && slices.ContainsFunc(flagged, byName) && !slices.ContainsFunc(current, named) {
+			return r.fail("Report %s matches findings by account or username, but the current input read as %s has neither; pass the -input-format the report was written with", cfg.VerifyFrom, cfg.InputFormat)
+		}
+	}
+
+	closure := closureReport{From: cfg.VerifyFrom, VerifiedAt: time.Now().UTC(), Flagged: len(flagged)}
+	for _, f := range flagged {
+		closure.add(r.verifyFinding(f, current, withInput))
+	}
+
+	if cfg.Format == formatJSON {
+		out, err := json.MarshalIndent(closure, "", "  ")
+		if err != nil {
+			return r.fail("Failed to write closure report: %v", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		closure.print()
+	}
+
+	switch {
+	case closure.StillPwned > 0:
+		return exitPwned
+	case closure.Errors > 0:
+		return exitError
+	}
+	return exitOK
+}
+
+// verifyFinding re-checks one prior finding, in the current input when
+// withInput is set.
+func (r *runner) verifyFinding(f reportResult, current []entry, withInput bool) closureEntry {
+	ce := closureEntry{Item: f.Item, Line: f.Line, Account: f.Account, Username: f.Username}
+
+	password, hashed := f.Hash, true
+	if withInput {
+		if !matchable(f) {
+			ce.Verdict = statusError
+			ce.Error = "report has neither line nor account for this entry"
+			return ce
+		}
+		e, ok := matchEntry(current, f)
+		if !ok {
+			ce.Verdict = verdictRemoved
+			return ce
+		}
+		password, hashed = e.Password, r.cfg.IsHashed
+	} else if password == "" {
+		password, hashed = f.Password, r.cfg.IsHashed
+	}
+	if password == "" {
+		ce.Verdict = statusError
+		ce.Error = "report has neither hash nor password for this entry; pass the current input with -i"
+		return ce
+	}
+
+	res, err := r.client.Check(password, hashed)
+	switch {
+	case err != nil:
+		ce.Verdict, ce.Error = statusError, err.Error()
+	case res.Pwned:
+		ce.Verdict, ce.Count = verdictStillPwned, res.Count
+	default:
+		ce.Verdict = verdictFixed
+	}
+	return ce
+}
+
+func loadCurrent(r *runner) ([]entry, error) {
+	if r.cfg.Bitwarden {
+		return loadVault(r)
+	}
+	file, err := openInput(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries, oversized, err := readLines(file, textFor(r.cfg), r.cfg.MaxLineLength)
+	if err != nil {
+		return nil, err
+	}
+	r.noteOversized(oversized)
+	// a report of user:password lines matches by username, so they must
+	// be split the way the run that wrote it split them
+	entries, _ = parseEntries(r.cfg.InputFormat, r.secretKeys, entries)
+	return entries, nil
+}
+
+// matchable reports whether a prior finding carries what matchEntry needs.
+// Without it the finding cannot be told apart from a removed entry.
+func matchable(f reportResult) bool {
+	return f.Account != "" || f.Username != "" || f.Line > 0
+}
+
+func byName(f reportResult) bool {
+	return f.Account != "" || f.Username != ""
+}
+
+func named(e entry) bool {
+	return e.Account != "" || e.Username != ""
+}
+
+// matchEntry finds the current entry for a matchable prior finding: by
+// account and username when the report has them, otherwise by line number.
+func matchEntry(current []entry, f reportResult) (entry, bool) {
+	for _, e := range current {
+		switch {
+		case f.Account != "" || f.Username != "":
+			if e.Account == f.Account && e.Username == f.Username {
+				return e, true
+			}
+		case e.Line == f.Line:
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+func (c *closureReport) add(ce closureEntry) {
+	switch ce.Verdict {
+	case verdictFixed:
+		c.Fixed++
+	case verdictStillPwned:
+		c.StillPwned++
+	case verdictRemoved:
+		c.Removed++
+	default:
+		c.Errors++
+	}
+	c.Entries = append(c.Entries, ce)
+}
+
+func (c *closureReport) print() {
+	fmt.Printf("Verifying %d flagged entries from %s\n\n", c.Flagged, c.From)
+	for _, ce := range c.Entries {
+		label := fmt.Sprintf("item #%d", ce.Item)
+		switch {
+		case ce.Account != "":
+			label = ce.Account
+			if ce.Username != "" {
+				label += " (" + ce.Username + ")"
+			}
+		case ce.Line > 0:
+			label = fmt.Sprintf("line %d", ce.Line)
+		}
+
+		switch ce.Verdict {
+		case verdictFixed:
+			fmt.Printf("%sFIXED%s        %s\n", colorGreen, colorReset, label)
+		case verdictRemoved:
+			fmt.Printf("%sREMOVED%s      %s\n", colorGreen, colorReset, label)
+		case verdictStillPwned:
+			fmt.Printf("%sSTILL PWNED%s  %s\n", colorRed, colorReset, label)
+		default:
+			fmt.Printf("%sERROR%s        %s: %s\n", colorYellow, colorReset, label, ce.Error)
+		}
+	}
+
+	fmt.Printf("\nFlagged: %d  Fixed: %d  Removed: %d  Still pwned: %d  Errors: %d\n",
+		c.Flagged, c.Fixed, c.Removed, c.StillPwned, c.Errors)
+	if c.StillPwned == 0 && c.Errors == 0 {
+		fmt.Printf("%sAll flagged entries are resolved.%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package checker
+
+import "os"
+
+// enableVirtualTerminal is a no-op: Unix terminals handle ANSI escapes natively.
+func enableVirtualTerminal(*os.File) bool {
+	return true
+}
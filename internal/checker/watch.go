@@ -0,0 +1,133 @@
+package checker
+
+import (
+	"crypto/sha256"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// watchDebounce lets a burst of writes settle before the file is re-read.
+const watchDebounce = 250 * time.Millisecond
+
+// runWatch checks the input file once, then keeps watching it and checks
+// only the lines that were added or changed since, until SIGINT or SIGTERM
+// ends the run with the usual summary and exit code.
+func runWatch(r *runner) int {
+	if input.IsURL(r.cfg.InputFile) {
+		return r.fail("-watch needs a local input file")
+	}
+	// decided once: with -secure-memory the first load flips IsHashed
+	text := textFor(r.cfg)
+	load := func() ([]entry, []int, error) {
+		file, err := openInput(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+		entries, oversized, err := readLines(file, text, r.cfg.MaxLineLength)
+		entries, _ = parseEntries(r.cfg.InputFormat, r.secretKeys, entries)
+		return entries, oversized, err
+	}
+
+	entries, oversized, err := load()
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	r.noteOversized(oversized)
+	if r.cfg.SecureMemory && !r.cfg.IsHashed {
+		r.cfg.IsHashed, r.cfg.HidePassword = true, true
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return r.fail("Failed to watch %s: %v", r.cfg.InputFile, err)
+	}
+	defer w.Close()
+	// watch the directory: editors and rotation tools often replace the file
+	// rather than write to it
+	target, err := filepath.Abs(r.cfg.InputFile)
+	if err != nil {
+		return r.fail("%v", err)
+	}
+	if err := w.Add(filepath.Dir(target)); err != nil {
+		return r.fail("Failed to watch %s: %v", r.cfg.InputFile, err)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	r.source, r.style = r.cfg.InputFile, styleList
+	seen := map[[sha256.Size]byte]int{}
+	if batch := changedLines(entries, seen); len(batch) > 0 {
+		if code := r.run(batch); code != exitOK {
+			return code
+		}
+	}
+	r.notef("Watching %s for new or changed lines; press Ctrl-C to stop.\n", r.cfg.InputFile)
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return r.finish()
+			}
+			if ev.Name == target && ev.Has(fsnotify.Write|fsnotify.Create) {
+				settle = time.After(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if ok {
+				r.client.Logger().Warn("watch error", "err", err)
+			}
+		case <-settle:
+			settle = nil
+			entries, _, err := load()
+			if err != nil {
+				// mid-rotation; the next event retries
+				r.client.Logger().Warn("failed to re-read input", "err", err)
+				continue
+			}
+			batch := changedLines(entries, seen)
+			if len(batch) == 0 {
+				continue
+			}
+			r.notef("\n%d new or changed lines in %s\n", len(batch), r.cfg.InputFile)
+			if code := r.run(batch); code != exitOK {
+				return code
+			}
+			if r.stats.stoppedAt > 0 {
+				return r.finish()
+			}
+		case <-sigs:
+			return r.finish()
+		}
+	}
+}
+
+// changedLines returns the entries not checked before and records the file's
+// current contents in seen. Lines are matched by content and counted, so
+// inserting or deleting a line does not re-check the ones that moved, while
+// a second occurrence of a password still counts as new.
+func changedLines(entries []entry, seen map[[sha256.Size]byte]int) []entry {
+	current := make(map[[sha256.Size]byte]int, len(entries))
+	var batch []entry
+	for _, e := range entries {
+		fp := sha256.Sum256([]byte(e.Password))
+		current[fp]++
+		if current[fp] > seen[fp] {
+			batch = append(batch, e)
+		}
+	}
+	clear(seen)
+	for fp, n := range current {
+		seen[fp] = n
+	}
+	return batch
+}
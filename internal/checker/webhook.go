@@ -0,0 +1,136 @@
+package checker
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Webhook events.
+const (
+	eventRunFinished = "run_finished"
+	eventFinding     = "finding"
+)
+
+// webhookPayload is the JSON body of every -webhook POST. Findings carry
+// where a password was found and how often it was breached, never the
+// password or its hash.
+type webhookPayload struct {
+	Event    string           `json:"event"`
+	Host     string           `json:"host"`
+	Source   string           `json:"source"`
+	Time     time.Time        `json:"time"`
+	Summary  *runSummary      `json:"summary,omitempty"`
+	Findings []webhookFinding `json:"findings"`
+}
+
+type webhookFinding struct {
+	Item     int    `json:"item"`
+	Line     int    `json:"line,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Account  string `json:"account,omitempty"`
+	Username string `json:"username,omitempty"`
+	Folder   string `json:"folder,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// webhookWriter POSTs the findings of a run with its summary when the run
+// ends, and with -watch each finding as it appears too. As an alerts sink
+// it only sees new findings with -every.
+type webhookWriter struct {
+	url      string
+	format   string
+	source   string
+	watch    bool
+	client   *http.Client
+	findings []webhookFinding
+}
+
+func newWebhookWriter(cfg Config) (*webhookWriter, error) {
+	u, err := url.Parse(cfg.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid -webhook %q, expected an http(s):// URL", cfg.Webhook)
+	}
+	format := cmp.Or(cfg.WebhookFormat, "json")
+	if !slices.Contains(WebhookFormats, format) {
+		return nil, fmt.Errorf("unknown -webhook-format %q (available: %s)", format, strings.Join(WebhookFormats, ", "))
+	}
+	source := cfg.InputFile
+	if len(cfg.Args) > 0 || cfg.Prompt {
+		source = "command line"
+	}
+	return &webhookWriter{
+		url:    cfg.Webhook,
+		format: format,
+		source: source,
+		watch:  cfg.Watch,
+		client: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+func (wh *webhookWriter) write(rec record) error {
+	if rec.Status != statusPwned {
+		return nil
+	}
+	f := webhookFinding{
+		Item:     rec.Item,
+		Line:     rec.Line,
+		Source:   rec.Source,
+		Account:  rec.Account,
+		Username: rec.Username,
+		Folder:   rec.Folder,
+		Count:    rec.Count,
+	}
+	wh.findings = append(wh.findings, f)
+	if wh.watch {
+		return wh.post(webhookPayload{Event: eventFinding, Findings: []webhookFinding{f}})
+	}
+	return nil
+}
+
+func (wh *webhookWriter) close(summary runSummary) error {
+	return wh.post(webhookPayload{Event: eventRunFinished, Summary: &summary, Findings: wh.findings})
+}
+
+func (wh *webhookWriter) post(p webhookPayload) error {
+	p.Host, _ = os.Hostname()
+	p.Source, p.Time = wh.source, time.Now().UTC()
+	if p.Findings == nil {
+		p.Findings = []webhookFinding{}
+	}
+	var msg any = p
+	switch wh.format {
+	case "slack":
+		msg = slackMessage(p)
+	case "teams":
+		msg = teamsMessage(p)
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PwnedCheck/1.0")
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package checker
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WebhookFormats are the bodies -webhook-format can post: the raw payload,
+// or a message for a Slack or Microsoft Teams incoming webhook.
+var WebhookFormats = []string{"json", "slack", "teams"}
+
+// chatTopFindings caps the findings listed in a chat message; the totals
+// still count all of them.
+const chatTopFindings = 10
+
+// chatText is the content shared by the Slack and Teams messages.
+type chatText struct {
+	title string
+	facts [][2]string
+	// top lists the most breached findings, one line each.
+	top  []string
+	more int
+}
+
+func newChatText(p webhookPayload) chatText {
+	var t chatText
+	if p.Event == eventFinding {
+		t.title = fmt.Sprintf("PwnedCheck: compromised password in %s", p.Source)
+	} else {
+		s := p.Summary
+		t.title = fmt.Sprintf("PwnedCheck: %d compromised passwords in %s", s.Bad, p.Source)
+		if s.NewFindings > 0 {
+			t.title = fmt.Sprintf("PwnedCheck: %d new compromised passwords in %s", s.NewFindings, p.Source)
+		}
+		t.facts = [][2]string{
+			{"Checked", fmt.Sprint(s.Total)},
+			{"Bad", fmt.Sprint(s.Bad)},
+			{"Good", fmt.Sprint(s.Good)},
+			{"Errors", fmt.Sprint(s.Errors)},
+			{"Runtime", s.Runtime},
+		}
+		for _, k := range slices.Sorted(maps.Keys(s.Tags)) {
+			t.facts = append(t.facts, [2]string{k, s.Tags[k]})
+		}
+	}
+	t.facts = append(t.facts, [2]string{"Host", p.Host})
+
+	findings := slices.Clone(p.Findings)
+	slices.SortStableFunc(findings, func(a, b webhookFinding) int { return cmp.Compare(b.Count, a.Count) })
+	for i, f := range findings {
+		if i == chatTopFindings {
+			t.more = len(findings) - i
+			break
+		}
+		t.top = append(t.top, chatFinding(f))
+	}
+	return t
+}
+
+func chatFinding(f webhookFinding) string {
+	name := strings.TrimSpace(f.Account + " " + f.Username)
+	if f.Line > 0 {
+		name = strings.TrimSpace(fmt.Sprintf("%s:%d %s", f.Source, f.Line, name))
+	}
+	return fmt.Sprintf("%s, seen %d times", cmp.Or(name, fmt.Sprintf("item #%d", f.Item)), f.Count)
+}
+
+func (t chatText) findings() string {
+	if len(t.top) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range t.top {
+		fmt.Fprintf(&b, "• %s\n", line)
+	}
+	if t.more > 0 {
+		fmt.Fprintf(&b, "…and %d more\n", t.more)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// slackMessage is a Block Kit message; text is the notification fallback.
+func slackMessage(p webhookPayload) map[string]any {
+	t := newChatText(p)
+	var fields []map[string]any
+	for _, f := range t.facts {
+		fields = append(fields, map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", slackEscape(f[0]), slackEscape(f[1]))})
+	}
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": clip(t.title, 150)}},
+	}
+	// a section holds at most 10 fields
+	for chunk := range slices.Chunk(fields, 10) {
+		blocks = append(blocks, map[string]any{"type": "section", "fields": chunk})
+	}
+	if list := t.findings(); list != "" {
+		blocks = append(blocks, map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "*Top findings*\n" + slackEscape(list)}})
+	}
+	return map[string]any{"text": t.title, "blocks": blocks}
+}
+
+// slackEscape keeps names from being read as links or mentions.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+// teamsMessage wraps an Adaptive Card the way Teams incoming webhooks and
+// Workflows expect it.
+func teamsMessage(p webhookPayload) map[string]any {
+	t := newChatText(p)
+	var facts []map[string]any
+	for _, f := range t.facts {
+		facts = append(facts, map[string]any{"title": f[0], "value": f[1]})
+	}
+	body := []map[string]any{
+		{"type": "TextBlock", "text": t.title, "weight": "Bolder", "size": "Medium", "wrap": true},
+		{"type": "FactSet", "facts": facts},
+	}
+	if list := t.findings(); list != "" {
+		body = append(body,
+			map[string]any{"type": "TextBlock", "text": "Top findings", "weight": "Bolder", "wrap": true},
+			map[string]any{"type": "TextBlock", "text": strings.ReplaceAll(list, "\n", "\n\n"), "wrap": true})
+	}
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	return map[string]any{
+		"type":        "message",
+		"attachments": []map[string]any{{"contentType": "application/vnd.microsoft.card.adaptive", "content": card}},
+	}
+}
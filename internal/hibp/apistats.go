@@ -0,0 +1,102 @@
+package hibp
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Latency buckets grow by 2^(1/8), about 9%, from latencyBase, so a
+// percentile is within that of the truth however many requests were made.
+const (
+	latencyBase    = 100 * time.Microsecond
+	latencyBuckets = 200
+)
+
+// APIStats accounts for the range requests sent so far. Latencies are
+// those of the requests that got a response.
+type APIStats struct {
+	Requests int
+	// Errors counts requests without a usable response: network failures
+	// and any status but 200 and 304.
+	Errors int
+	// RateLimited counts 429 responses; they are also Errors.
+	RateLimited int
+	// Retries counts requests sent again for a range after a failure or an
+	// eviction, and is part of Requests.
+	Retries       int
+	P50, P95, P99 time.Duration
+}
+
+type apiCounters struct {
+	mu                                   sync.Mutex
+	requests, errors, rateLimited, retry int
+	latency                              [latencyBuckets]int
+	answered                             int
+}
+
+// record counts one request; elapsed is ignored when status is 0, i.e.
+// there was no response.
+func (a *apiCounters) record(status int, elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests++
+	if status != 200 && status != 304 {
+		a.errors++
+	}
+	if status == 429 {
+		a.rateLimited++
+	}
+	if status != 0 {
+		a.latency[latencyBucket(elapsed)]++
+		a.answered++
+	}
+}
+
+func (a *apiCounters) retried() {
+	a.mu.Lock()
+	a.retry++
+	a.mu.Unlock()
+}
+
+func (a *apiCounters) snapshot() APIStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return APIStats{
+		Requests:    a.requests,
+		Errors:      a.errors,
+		RateLimited: a.rateLimited,
+		Retries:     a.retry,
+		P50:         a.percentile(50),
+		P95:         a.percentile(95),
+		P99:         a.percentile(99),
+	}
+}
+
+// percentile returns the upper bound of the bucket holding the p-th
+// percentile, or 0 before any response.
+func (a *apiCounters) percentile(p int) time.Duration {
+	rank := (a.answered*p + 99) / 100
+	if rank == 0 {
+		return 0
+	}
+	seen := 0
+	for i, n := range a.latency {
+		if seen += n; seen >= rank {
+			return latencyBound(i)
+		}
+	}
+	return latencyBound(latencyBuckets - 1)
+}
+
+func latencyBucket(d time.Duration) int {
+	if d <= latencyBase {
+		return 0
+	}
+	i := int(math.Ceil(8 * math.Log2(float64(d)/float64(latencyBase))))
+	return min(i, latencyBuckets-1)
+}
+
+func latencyBound(i int) time.Duration {
+	return time.Duration(float64(latencyBase) * math.Exp2(float64(i)/8))
+}
@@ -0,0 +1,146 @@
+package hibp
+
+import (
+	"sync"
+	"time"
+)
+
+// default bound on cached prefixes; each range holds roughly 800-1000 suffixes
+const defaultCacheEntries = 1024
+
+// CacheStats separates hits answered "pwned" from hits answered "not found":
+// negative hits are only possible because whole ranges are cached.
+type CacheStats struct {
+	PositiveHits int
+	NegativeHits int
+	Misses       int
+	// Revalidated counts expired ranges HIBP confirmed unchanged with a 304.
+	Revalidated int
+	// RangeHits counts whole ranges served from the cache by Range.
+	RangeHits int
+}
+
+type rangeEntry struct {
+	suffixes map[string]int
+	fetched  time.Time
+	// etag lets an expired entry be revalidated instead of downloaded again
+	etag string
+}
+
+// rangeCache keeps the complete suffix set of recently fetched prefixes, so
+// any suffix under a cached prefix is answered locally until the TTL runs out.
+type rangeCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*rangeEntry
+	stats      CacheStats
+}
+
+func newRangeCache(ttl time.Duration, maxEntries int) *rangeCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &rangeCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*rangeEntry),
+	}
+}
+
+// lookup returns the count for suffix and whether the prefix was cached.
+func (rc *rangeCache) lookup(prefix, suffix string) (int, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[prefix]
+	if !ok || time.Since(entry.fetched) > rc.ttl {
+		if ok && entry.etag == "" {
+			delete(rc.entries, prefix)
+		}
+		rc.stats.Misses++
+		return 0, false
+	}
+
+	count, found := entry.suffixes[suffix]
+	if found {
+		rc.stats.PositiveHits++
+	} else {
+		rc.stats.NegativeHits++
+	}
+	return count, true
+}
+
+// get returns a whole fresh range.
+func (rc *rangeCache) get(prefix string) (map[string]int, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[prefix]
+	if !ok || time.Since(entry.fetched) > rc.ttl {
+		rc.stats.Misses++
+		return nil, false
+	}
+	rc.stats.RangeHits++
+	return entry.suffixes, true
+}
+
+func (rc *rangeCache) store(prefix string, suffixes map[string]int, etag string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if _, ok := rc.entries[prefix]; !ok && len(rc.entries) >= rc.maxEntries {
+		rc.evictOldest()
+	}
+	rc.entries[prefix] = &rangeEntry{suffixes: suffixes, fetched: time.Now(), etag: etag}
+}
+
+// etag returns the validator of a cached, possibly expired, range.
+func (rc *rangeCache) etag(prefix string) string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if entry, ok := rc.entries[prefix]; ok {
+		return entry.etag
+	}
+	return ""
+}
+
+// revalidate restarts the TTL of a range HIBP reported unchanged. It returns
+// nil if the entry was evicted in the meantime.
+func (rc *rangeCache) revalidate(prefix string) map[string]int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[prefix]
+	if !ok {
+		return nil
+	}
+	entry.fetched = time.Now()
+	rc.stats.Revalidated++
+	return entry.suffixes
+}
+
+func (rc *rangeCache) evictOldest() {
+	var oldest string
+	var oldestAt time.Time
+	for prefix, entry := range rc.entries {
+		if oldest == "" || entry.fetched.Before(oldestAt) {
+			oldest, oldestAt = prefix, entry.fetched
+		}
+	}
+	delete(rc.entries, oldest)
+}
+
+// usage returns the number of cached ranges, expired ones included.
+func (rc *rangeCache) usage() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.entries)
+}
+
+func (rc *rangeCache) snapshot() CacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.stats
+}
@@ -1,99 +1,489 @@
 package hibp
 
 import (
+	"cmp"
+	"context"
 	"crypto/sha1"
+	"crypto/tls"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/index"
+	"golang.org/x/crypto/md4"
 )
 
+const userAgent = "PwnedCheck/1.0"
+
+// DefaultBaseURL is where range requests go unless Options.BaseURL is set.
+const DefaultBaseURL = "https://api.pwnedpasswords.com"
+
+const defaultTimeout = 10 * time.Second
+
+// Suffix lengths in a range body: the hash less its 5-character prefix.
 const (
-	colorCyan  = "\033[36m"
-	colorReset = "\033[0m"
-	userAgent  = "PwnedCheck/1.0"
+	sha1SuffixLen = 40 - 5
+	ntlmSuffixLen = 32 - 5
 )
 
+type Options struct {
+	// Logger receives request, cache and timing diagnostics; nil discards them.
+	Logger *slog.Logger
+	// CacheTTL is how long a fetched range answers lookups locally; 0 disables caching.
+	CacheTTL time.Duration
+	// RPS caps requests per second across all callers; 0 means no limit.
+	RPS float64
+	// Timeout bounds each request, including reading the body; 0 means
+	// defaultTimeout.
+	Timeout time.Duration
+	// IdleConns is how many keep-alive connections are pooled for callers
+	// sharing the Client; 0 means defaultIdleConns.
+	IdleConns int
+	// CacheEntries bounds how many ranges the cache holds; 0 means
+	// defaultCacheEntries.
+	CacheEntries int
+	// Pins are SHA-256 digests of public keys (SPKI) the API server must
+	// present one of, on top of normal certificate validation.
+	Pins [][]byte
+	// TLS overrides the trusted roots or verification, e.g. for a corporate
+	// proxy that re-signs traffic; nil uses the system defaults.
+	TLS *tls.Config
+	// Offline answers SHA-1 lookups from this filter without sending
+	// anything; hits have no count and NTLM lookups fail.
+	Offline *bloom.Filter
+	// Index answers lookups from a packed offline index, with counts,
+	// without sending anything. It takes precedence over Offline.
+	Index *index.Index
+	// BaseURL replaces DefaultBaseURL, e.g. with a pwnedcheck proxy or a
+	// hibptest.Server; requests go to BaseURL/range/PREFIX.
+	BaseURL string
+	// Transport replaces the tuned transport, which makes IdleConns, Pins
+	// and TLS no-ops. Tests stub the API with it.
+	Transport http.RoundTripper
+}
+
+// Client is safe for concurrent use.
 type Client struct {
 	client  *http.Client
-	verbose bool
+	baseURL string
+	log     *slog.Logger
+	starter *bloom.Filter
+	offline *bloom.Filter
+	index   *index.Index
+	cache   *rangeCache
+	limiter *tokenBucket
+	conns   connCounters
+	api     apiCounters
+}
+
+func NewClient(opts Options) *Client {
+	log := opts.Logger
+	if log == nil {
+		log = slog.New(slog.DiscardHandler)
+	}
+
+	// only present in binaries built with -tags starterbloom
+	starter, err := bloom.Starter()
+	if err != nil {
+		log.Warn("starter filter disabled", "err", err)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	idleConns := opts.IdleConns
+	if idleConns <= 0 {
+		idleConns = defaultIdleConns
+	}
+	transport := opts.Transport
+	if transport == nil {
+		transport = newTransport(idleConns, opts.TLS, opts.Pins)
+	}
+	c := &Client{
+		client:  &http.Client{Timeout: timeout, Transport: transport},
+		baseURL: strings.TrimSuffix(cmp.Or(opts.BaseURL, DefaultBaseURL), "/"),
+		log:     log,
+		starter: starter,
+		offline: opts.Offline,
+		index:   opts.Index,
+	}
+	if opts.CacheTTL > 0 {
+		c.cache = newRangeCache(opts.CacheTTL, opts.CacheEntries)
+	}
+	if opts.RPS > 0 {
+		c.limiter = newTokenBucket(opts.RPS)
+	}
+	return c
 }
 
-func NewClient(verbose bool) *Client {
-	return &Client{
-		client:  &http.Client{Timeout: 10 * time.Second},
-		verbose: verbose,
+// CheckPassword reports whether the password appears in the HIBP corpus and
+// how many times it was seen. A hit from the embedded starter filter has no
+// known count and reports 0.
+func (c *Client) CheckPassword(password string, alreadyHashed bool) (bool, int, error) {
+	hashString := strings.ToUpper(password)
+	if !alreadyHashed {
+		hashString = HashPassword(password)
+	}
+
+	if len(hashString) < 5 {
+		return false, 0, fmt.Errorf("hash must be at least 5 characters")
 	}
+	return c.lookup(hashString, false)
 }
 
-func (c *Client) CheckPassword(password string, alreadyHashed bool) (bool, error) {
-	hashString := password
+// CheckNTLM is CheckPassword for the NTLM corpus, which HIBP serves from the
+// same range API with mode=ntlm.
+func (c *Client) CheckNTLM(password string, alreadyHashed bool) (bool, int, error) {
+	hashString := strings.ToUpper(password)
 	if !alreadyHashed {
-		hashString = hashPassword(password)
+		hashString = HashNTLM(password)
 	}
 
 	if len(hashString) < 5 {
-		return false, fmt.Errorf("hash must be at least 5 characters")
+		return false, 0, fmt.Errorf("hash must be at least 5 characters")
+	}
+	return c.lookup(hashString, true)
+}
+
+// Known answers an uppercase hash from the offline index, the filters or
+// the cache, sending nothing; ok is false when only HIBP can answer, from
+// the range Match reads. CheckPassword and CheckNTLM decide the same way,
+// so -batch can fetch the ranges it needs itself.
+func (c *Client) Known(hashString string, ntlm bool) (found bool, count int, ok bool, err error) {
+	switch {
+	case c.index != nil:
+		found, count, err = c.lookupIndex(hashString, ntlm)
+		return found, count, true, err
+	case ntlm && c.offline != nil:
+		return false, 0, true, fmt.Errorf("the offline filter only covers SHA-1")
+	case !ntlm && c.starter != nil && c.starter.TestHex(hashString):
+		c.log.Debug("starter filter hit, no request sent", "prefix", hashString[:5])
+		return true, 0, true, nil
+	case !ntlm && c.offline != nil:
+		return c.offline.TestHex(hashString), 0, true, nil
+	}
+	if c.cache != nil {
+		if count, ok := c.cache.lookup(cacheKey(hashString[:5], ntlm), hashString[5:]); ok {
+			c.log.Debug("cache hit", "prefix", hashString[:5], "found", count > 0)
+			return count > 0, count, true, nil
+		}
+	}
+	return false, 0, false, nil
+}
+
+// Match looks an uppercase hash up in the range of its prefix.
+func Match(suffixes map[string]int, hashString string) (bool, int) {
+	count := suffixes[hashString[5:]]
+	return count > 0, count
+}
+
+func (c *Client) lookupIndex(hashString string, ntlm bool) (bool, int, error) {
+	if c.index.NTLM() != ntlm {
+		return false, 0, fmt.Errorf("the offline index holds %s hashes", c.index.Kind())
+	}
+	count, found, err := c.index.LookupHex(hashString)
+	c.log.Debug("offline index lookup", "prefix", hashString[:5], "found", found)
+	return found, count, err
+}
+
+// cacheKey keeps NTLM ranges apart: both corpora share prefixes.
+func cacheKey(prefix string, ntlm bool) string {
+	if ntlm {
+		return "ntlm:" + prefix
+	}
+	return prefix
+}
+
+func (c *Client) lookup(hashString string, ntlm bool) (bool, int, error) {
+	if found, count, ok, err := c.Known(hashString, ntlm); ok {
+		return found, count, err
 	}
 
 	prefix := hashString[:5]
-	suffix := hashString[5:]
-	url := fmt.Sprintf("https://api.pwnedpasswords.com/range/%s", prefix)
+	suffixes, err := c.refresh(cacheKey(prefix, ntlm), prefix, ntlm)
+	if err != nil {
+		return false, 0, err
+	}
+
+	found, count := Match(suffixes, hashString)
+	c.log.Debug("range searched locally", "prefix", prefix, "suffixes", len(suffixes), "found", found, "count", count)
+	return found, count, nil
+}
 
-	if c.verbose {
-		fmt.Printf("%s[HIBP REQUEST] GET %s%s\n", colorCyan, url, colorReset)
-		fmt.Printf("%s[HIBP REQUEST] Sending prefix: %s  (suffix %s stays local)%s\n", colorCyan, prefix, suffix, colorReset)
+// Range returns every suffix under an uppercase 5-hex-digit prefix with its
+// count, from the cache while it is fresh. It serves the proxy subcommand,
+// so the map must not be modified.
+func (c *Client) Range(prefix string, ntlm bool) (map[string]int, error) {
+	key := cacheKey(prefix, ntlm)
+	if c.cache != nil {
+		if suffixes, ok := c.cache.get(key); ok {
+			c.log.Debug("cache hit", "prefix", prefix, "suffixes", len(suffixes))
+			return suffixes, nil
+		}
 	}
+	return c.refresh(key, prefix, ntlm)
+}
+
+// Fetch downloads one range past the cache, conditionally when etag is set;
+// an unchanged range returns a nil map. It serves the download subcommand.
+func (c *Client) Fetch(prefix string, ntlm bool, etag string) (map[string]int, string, error) {
+	return c.fetchRange(prefix, ntlm, etag)
+}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// refresh fetches a range that is not cached or has expired, revalidating
+// it when the cache still has its ETag.
+func (c *Client) refresh(key, prefix string, ntlm bool) (map[string]int, error) {
+	var etag string
+	if c.cache != nil {
+		etag = c.cache.etag(key)
+	}
+	suffixes, etag, err := c.fetchRange(prefix, ntlm, etag)
+	fresh := suffixes != nil
+	if err == nil && !fresh {
+		if suffixes = c.cache.revalidate(key); suffixes == nil {
+			// evicted while the request was in flight
+			c.api.retried()
+			suffixes, etag, err = c.fetchRange(prefix, ntlm, "")
+			fresh = true
+		}
+	}
 	if err != nil {
-		return false, fmt.Errorf("failed to build request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("User-Agent", userAgent)
+	if c.cache != nil && fresh {
+		c.cache.store(key, suffixes, etag)
+	}
+	return suffixes, nil
+}
 
-	resp, err := c.client.Do(req)
+// fetchRange downloads every suffix under prefix with its breach count, along
+// with the ETag of the response. With an etag from an earlier response the
+// request is conditional, and an unchanged range returns a nil map and no
+// error. Only the prefix is ever sent or logged.
+func (c *Client) fetchRange(prefix string, ntlm bool, etag string) (map[string]int, string, error) {
+	resp, err := c.get(prefix, ntlm, etag)
 	if err != nil {
-		return false, fmt.Errorf("API request failed: %w", err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
-	if c.verbose {
-		fmt.Printf("%s[HIBP RESPONSE] Status: %s%s\n", colorCyan, resp.Status, colorReset)
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		c.log.Debug("range unchanged", "prefix", prefix)
+		return nil, etag, nil
+	}
+	suffixes, err := readRange(resp, ntlm)
+	if err != nil {
+		return nil, "", err
 	}
+	return suffixes, resp.Header.Get("ETag"), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("unexpected API status: %s", resp.Status)
+// get sends the range request for prefix, conditional on etag when set.
+// The caller closes the response.
+func (c *Client) get(prefix string, ntlm bool, etag string) (*http.Response, error) {
+	url := c.baseURL + "/range/" + prefix
+	if ntlm {
+		url += "?mode=ntlm"
+	}
+
+	req, err := http.NewRequestWithContext(c.conns.trace(context.Background()), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
 	}
 
+	if c.limiter != nil {
+		if waited := c.limiter.take(); waited > 0 {
+			c.log.Debug("rate limited", "waited", waited)
+		}
+	}
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.api.record(0, 0)
+		c.log.Warn("HIBP request failed", "url", url, "elapsed", time.Since(start), "err", err)
+		return nil, requestError(err)
+	}
+	c.api.record(resp.StatusCode, time.Since(start))
+	if resp.ProtoMajor == 2 {
+		c.conns.http2.Add(1)
+	}
+	c.log.Info("HIBP request", "method", http.MethodGet, "url", url, "status", resp.StatusCode, "proto", resp.Proto, "elapsed", time.Since(start))
+	return resp, nil
+}
+
+func readRange(resp *http.Response, ntlm bool) (map[string]int, error) {
+	if resp.StatusCode != http.StatusOK {
+		err := &StatusError{Code: resp.StatusCode, Status: resp.Status}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			err.RetryAfter = retryAfter(resp.Header)
+		}
+		return nil, err
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return false, fmt.Errorf("failed to read API response: %w", err)
+		return nil, bodyError(err)
+	}
+	return parseRange(string(body), ntlm)
+}
+
+// parseRange reads a range body of SUFFIX:COUNT lines, ending in \n or
+// \r\n. Every suffix must be the rest of a SHA-1 or NTLM hash in hex and
+// every count a non-negative integer; anything else fails the whole body
+// with ErrMalformed rather than leaving its hashes out, which would report
+// them as not found. Blank lines are allowed, as at the end of the body.
+// Suffixes with a count of 0 are padding, as HIBP adds with Add-Padding, and
+// are left out, so a hash is pwned exactly when its suffix is listed, fetched
+// or cached.
+func parseRange(body string, ntlm bool) (map[string]int, error) {
+	suffixLen := sha1SuffixLen
+	if ntlm {
+		suffixLen = ntlmSuffixLen
 	}
+	suffixes := make(map[string]int)
+	for n, line := range strings.Split(body, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		suffix, count, ok := strings.Cut(line, ":")
+		switch {
+		case !ok:
+			return nil, malformedError(n+1, "no SUFFIX:COUNT pair in %.40q", line)
+		case len(suffix) != suffixLen || !isHex(suffix):
+			return nil, malformedError(n+1, "suffix %.40q is not %d hex characters", suffix, suffixLen)
+		}
+		c, err := strconv.Atoi(count)
+		if err != nil || c < 0 {
+			return nil, malformedError(n+1, "count %.20q is not a non-negative integer", count)
+		}
+		if c > 0 {
+			suffixes[strings.ToUpper(suffix)] = c
+		}
+	}
+	return suffixes, nil
+}
 
-	for _, line := range strings.Split(string(body), "\n") {
-		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
-		if len(parts) == 2 && parts[0] == suffix {
-			if c.verbose {
-				fmt.Printf("%s[HIBP MATCH] Suffix %s found in response%s\n", colorCyan, suffix, colorReset)
-			}
-			return true, nil
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
 		}
 	}
+	return true
+}
+
+// Probe is how one range request went, for the doctor subcommand.
+type Probe struct {
+	Suffixes map[string]int
+	// Date is the server clock from the Date header, zero when missing.
+	Date    time.Time
+	Proto   string
+	Latency time.Duration
+}
+
+// Probe fetches one range past the cache and reports the exchange.
+func (c *Client) Probe(prefix string, ntlm bool) (Probe, error) {
+	start := time.Now()
+	resp, err := c.get(prefix, ntlm, "")
+	if err != nil {
+		return Probe{}, err
+	}
+	defer resp.Body.Close()
+	p := Probe{Proto: resp.Proto, Latency: time.Since(start)}
+	p.Date, _ = http.ParseTime(resp.Header.Get("Date"))
+	if p.Suffixes, err = readRange(resp, ntlm); err != nil {
+		return p, err
+	}
+	return p, nil
+}
 
-	if c.verbose {
-		fmt.Printf("%s[HIBP MATCH] Suffix %s not found — password clean%s\n", colorCyan, suffix, colorReset)
+// CacheStats returns zero values when caching is disabled.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
 	}
-	return false, nil
+	return c.cache.snapshot()
+}
+
+// CacheUsage reports how many ranges the cache holds and how many it may
+// hold; both are 0 when caching is disabled.
+func (c *Client) CacheUsage() (ranges, capacity int) {
+	if c.cache == nil {
+		return 0, 0
+	}
+	return c.cache.usage(), c.cache.maxEntries
+}
+
+// ConnStats reports connection reuse across all requests so far.
+func (c *Client) ConnStats() ConnStats {
+	return c.conns.snapshot()
 }
 
-// to be nice
-func (c *Client) Wait() {
-	time.Sleep(100 * time.Millisecond)
+// APIStats reports the range requests sent so far.
+func (c *Client) APIStats() APIStats {
+	return c.api.snapshot()
 }
 
-func hashPassword(password string) string {
-	hash := sha1.Sum([]byte(password))
+// Retried counts a request the caller is about to send again, so APIStats
+// includes retries made outside the Client.
+func (c *Client) Retried() {
+	c.api.retried()
+}
+
+// Close releases idle keep-alive connections.
+func (c *Client) Close() {
+	c.client.CloseIdleConnections()
+}
+
+// HashPassword returns the uppercase hex SHA-1 used by the range API.
+func HashPassword(password string) string {
+	return HashPasswordBytes([]byte(password))
+}
+
+// HashPasswordBytes is HashPassword for callers that keep the plaintext in a
+// buffer they zero afterwards; it makes no copy of it.
+func HashPasswordBytes(password []byte) string {
+	hash := sha1.Sum(password)
 	return strings.ToUpper(hex.EncodeToString(hash[:]))
 }
+
+// HashNTLM returns the uppercase hex NTLM hash: MD4 over the UTF-16LE
+// encoding of the password.
+func HashNTLM(password string) string {
+	return HashNTLMBytes([]byte(password))
+}
+
+// HashNTLMBytes is HashNTLM over a UTF-8 buffer, encoding it to UTF-16LE
+// two bytes at a time rather than into a second copy.
+func HashNTLMBytes(password []byte) string {
+	h := md4.New()
+	var unit [2]byte
+	write := func(u uint16) {
+		unit[0], unit[1] = byte(u), byte(u>>8)
+		h.Write(unit[:])
+	}
+	for len(password) > 0 {
+		r, size := utf8.DecodeRune(password)
+		password = password[size:]
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			write(uint16(r1))
+			write(uint16(r2))
+		} else {
+			write(uint16(r))
+		}
+	}
+	clear(unit[:])
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+}
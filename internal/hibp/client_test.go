@@ -0,0 +1,231 @@
+package hibp_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/hibp/hibptest"
+)
+
+func newClient(t *testing.T, srv *hibptest.Server, cacheTTL time.Duration) *hibp.Client {
+	t.Helper()
+	client := hibp.NewClient(hibp.Options{BaseURL: srv.URL, Transport: srv.Client().Transport, CacheTTL: cacheTTL})
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestCheckPassword(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 100)
+	client := newClient(t, srv, 0)
+
+	for _, tc := range []struct {
+		password string
+		hashed   bool
+		found    bool
+		count    int
+	}{
+		{"password", false, true, 100},
+		{hibp.HashPassword("password"), true, true, 100},
+		{"correct horse battery staple", false, false, 0},
+	} {
+		found, count, err := client.CheckPassword(tc.password, tc.hashed)
+		if err != nil || found != tc.found || count != tc.count {
+			t.Errorf("CheckPassword(%q, %v) = %v, %d, %v; want %v, %d, nil", tc.password, tc.hashed, found, count, err, tc.found, tc.count)
+		}
+	}
+}
+
+func TestCheckNTLM(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.AddHash(hibp.HashNTLM("letmein"), 42)
+	client := newClient(t, srv, time.Hour)
+
+	if found, count, err := client.CheckNTLM("letmein", false); err != nil || !found || count != 42 {
+		t.Fatalf("CheckNTLM(letmein) = %v, %d, %v; want true, 42, nil", found, count, err)
+	}
+	if found, count, err := client.CheckNTLM(hibp.HashNTLM("letmein"), true); err != nil || !found || count != 42 {
+		t.Fatalf("CheckNTLM(hash of letmein) = %v, %d, %v; want true, 42, nil", found, count, err)
+	}
+	// the SHA-1 corpus doesn't have it, and its range is cached apart
+	if found, _, err := client.CheckPassword("letmein", false); err != nil || found {
+		t.Fatalf("CheckPassword(letmein) = %v, %v; want false, nil", found, err)
+	}
+	if got := srv.Requests(); got != 2 {
+		t.Errorf("requests = %d, want 2: one NTLM range, cached, and one SHA-1 range", got)
+	}
+}
+
+func TestRevalidation(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 100)
+	// every cached range is expired by the next lookup
+	client := newClient(t, srv, time.Nanosecond)
+
+	for i := range 3 {
+		found, count, err := client.CheckPassword("password", false)
+		if err != nil || !found || count != 100 {
+			t.Fatalf("lookup %d = %v, %d, %v; want true, 100, nil", i+1, found, count, err)
+		}
+	}
+	if got := client.CacheStats().Revalidated; got != 2 {
+		t.Errorf("revalidated = %d, want 2", got)
+	}
+	if got := srv.Requests(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+
+	// a changed range gets a new ETag and replaces the cached one
+	srv.Add("password", 101)
+	if _, count, err := client.CheckPassword("password", false); err != nil || count != 101 {
+		t.Fatalf("lookup after the change = %d, %v; want 101, nil", count, err)
+	}
+	if got := client.CacheStats().Revalidated; got != 2 {
+		t.Errorf("revalidated after the change = %d, want 2", got)
+	}
+}
+
+func TestFetchConditional(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 100)
+	prefix := hibp.HashPassword("password")[:5]
+	client := newClient(t, srv, 0)
+
+	suffixes, etag, err := client.Fetch(prefix, false, "")
+	if err != nil || len(suffixes) != 1 || etag == "" {
+		t.Fatalf("Fetch = %v, %q, %v; want one suffix and an ETag", suffixes, etag, err)
+	}
+	suffixes, again, err := client.Fetch(prefix, false, etag)
+	if err != nil || suffixes != nil || again != etag {
+		t.Fatalf("conditional Fetch = %v, %q, %v; want nil, %q, nil", suffixes, again, err, etag)
+	}
+}
+
+func TestMalformedRange(t *testing.T) {
+	hash := hibp.HashPassword("password")
+	for _, tc := range []struct {
+		name string
+		body string
+	}{
+		{"captive portal", "<html><body>Please log in</body></html>"},
+		{"short suffix", hash[5:30] + ":3\r\n"},
+		{"not hex", "Z" + hash[6:] + ":3\r\n"},
+		{"bad count", hash[5:] + ":lots\r\n"},
+		{"negative count", hash[5:] + ":-1\r\n"},
+		{"bad line after good ones", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + "\r\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := hibptest.NewServer()
+			defer srv.Close()
+			srv.Serve(tc.body)
+			client := newClient(t, srv, time.Hour)
+
+			_, _, err := client.CheckPassword("password", false)
+			if !errors.Is(err, hibp.ErrMalformed) || !errors.Is(err, hibp.ErrBadResponse) {
+				t.Fatalf("err = %v, want ErrMalformed and ErrBadResponse", err)
+			}
+			// nothing was cached, so the next lookup asks again
+			srv.Restore()
+			srv.Add("password", 7)
+			if found, count, err := client.CheckPassword("password", false); err != nil || !found || count != 7 {
+				t.Fatalf("lookup after the fix = %v, %d, %v; want true, 7, nil", found, count, err)
+			}
+		})
+	}
+}
+
+func TestWellFormedRange(t *testing.T) {
+	hash := hibp.HashPassword("password")
+	for _, tc := range []struct {
+		name string
+		body string
+	}{
+		{"LF endings", "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + hash[5:] + ":12\n"},
+		{"CRLF without trailing newline", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":12"},
+		{"lowercase suffix", "0018a45c4d1def81644b54ab7f969b88d65:1\r\n" + hash[5:] + ":12\r\n\r\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := hibptest.NewServer()
+			defer srv.Close()
+			srv.Serve(tc.body)
+			client := newClient(t, srv, 0)
+
+			if found, count, err := client.CheckPassword("password", false); err != nil || !found || count != 12 {
+				t.Fatalf("CheckPassword = %v, %d, %v; want true, 12, nil", found, count, err)
+			}
+		})
+	}
+}
+
+func TestPaddingSuffix(t *testing.T) {
+	hash := hibp.HashPassword("password")
+	for _, tc := range []struct {
+		name     string
+		cacheTTL time.Duration
+	}{
+		{"fetched", 0},
+		{"cached", time.Hour},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := hibptest.NewServer()
+			defer srv.Close()
+			srv.Serve("0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":0\r\n")
+			client := newClient(t, srv, tc.cacheTTL)
+
+			for i := range 2 {
+				if found, count, err := client.CheckPassword("password", false); err != nil || found || count != 0 {
+					t.Fatalf("lookup %d = %v, %d, %v; want false, 0, nil", i+1, found, count, err)
+				}
+			}
+			if suffixes, err := client.Range(hash[:5], false); err != nil || len(suffixes) != 1 {
+				t.Errorf("Range = %v, %v; want only the real suffix", suffixes, err)
+			}
+		})
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 100)
+	srv.RateLimit(3 * time.Second)
+	client := newClient(t, srv, time.Hour)
+
+	_, _, err := client.CheckPassword("password", false)
+	if !errors.Is(err, hibp.ErrRateLimited) || errors.Is(err, hibp.ErrBadResponse) {
+		t.Fatalf("err = %v, want ErrRateLimited only", err)
+	}
+	var status *hibp.StatusError
+	if !errors.As(err, &status) || status.Code != http.StatusTooManyRequests || status.RetryAfter != 3*time.Second {
+		t.Fatalf("err = %#v, want a 429 StatusError asking to wait 3s", err)
+	}
+	if stats := client.APIStats(); stats.RateLimited != 1 || stats.Errors != 1 {
+		t.Errorf("API stats = %+v, want one rate-limited error", stats)
+	}
+
+	srv.Fail(0)
+	if found, count, err := client.CheckPassword("password", false); err != nil || !found || count != 100 {
+		t.Fatalf("lookup after the limit = %v, %d, %v; want true, 100, nil", found, count, err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Fail(http.StatusServiceUnavailable)
+	client := newClient(t, srv, 0)
+
+	_, _, err := client.CheckPassword("password", false)
+	var status *hibp.StatusError
+	if !errors.Is(err, hibp.ErrBadResponse) || errors.Is(err, hibp.ErrRateLimited) ||
+		!errors.As(err, &status) || status.Code != http.StatusServiceUnavailable || status.RetryAfter != 0 {
+		t.Fatalf("err = %v, want a 503 StatusError matching ErrBadResponse", err)
+	}
+}
@@ -0,0 +1,107 @@
+package hibp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Causes of a failed range request. Client errors match one of them with
+// errors.Is and keep the underlying error, such as a net.Error, for
+// errors.As.
+var (
+	// ErrRateLimited is a 429 answer; lower the request rate.
+	ErrRateLimited = errors.New("rate limited by the API")
+	// ErrTimeout is a request that ran past Options.Timeout.
+	ErrTimeout = errors.New("API request timed out")
+	// ErrBadResponse is any other status than 200 or 304, or a body that
+	// could not be read for another reason than a timeout or a dropped
+	// connection.
+	ErrBadResponse = errors.New("bad API response")
+	// ErrMalformed is a 200 answer whose body is not a range listing, such
+	// as a proxy's login page. It also matches ErrBadResponse.
+	ErrMalformed = errors.New("malformed range response")
+	// ErrOffline is a request that never got an answer: DNS, connection or
+	// TLS failures, except ErrPinMismatch, which stays distinct, and
+	// connections that dropped while the body was read.
+	ErrOffline = errors.New("API unreachable")
+)
+
+// StatusError is an unexpected HTTP status from the API. It matches
+// ErrRateLimited for 429 and ErrBadResponse otherwise.
+type StatusError struct {
+	Code   int
+	Status string
+	// RetryAfter is how long a 429 asked to wait, 0 when it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string { return "unexpected API status: " + e.Status }
+
+func (e *StatusError) Is(target error) bool {
+	if e.Code == http.StatusTooManyRequests {
+		return target == ErrRateLimited
+	}
+	return target == ErrBadResponse
+}
+
+// retryAfter reads a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// causeError adds a cause to err without changing its message.
+type causeError struct {
+	msg   string
+	cause error
+	err   error
+}
+
+func (e *causeError) Error() string   { return e.msg }
+func (e *causeError) Unwrap() []error { return []error{e.cause, e.err} }
+
+// malformedError reports the first line of a range body that doesn't parse.
+func malformedError(line int, format string, args ...any) error {
+	msg := fmt.Sprintf("malformed API response: line %d: ", line) + fmt.Sprintf(format, args...)
+	return &causeError{msg: msg, cause: ErrMalformed, err: ErrBadResponse}
+}
+
+// requestError tags a failed round trip with ErrTimeout or ErrOffline.
+func requestError(err error) error {
+	var netErr net.Error
+	cause := ErrOffline
+	switch {
+	case errors.Is(err, ErrPinMismatch):
+		cause = ErrPinMismatch
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		cause = ErrTimeout
+	}
+	return &causeError{msg: "API request failed: " + err.Error(), cause: cause, err: err}
+}
+
+// bodyError tags a failed read of a response body like requestError does,
+// so a slow body counts as a timeout and a reset as an outage.
+func bodyError(err error) error {
+	var netErr net.Error
+	cause := ErrBadResponse
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		cause = ErrTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr):
+		cause = ErrOffline
+	}
+	return &causeError{msg: "failed to read API response: " + err.Error(), cause: cause, err: err}
+}
@@ -0,0 +1,150 @@
+// Package hibptest serves a fake HIBP range API for tests:
+//
+//	srv := hibptest.NewServer()
+//	defer srv.Close()
+//	srv.Add("password", 100)
+//	client := hibp.NewClient(hibp.Options{BaseURL: srv.URL, Transport: srv.Client().Transport})
+//
+// Only the passwords added are in its corpus; every other range is empty.
+package hibptest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+)
+
+// Server answers GET /range/{prefix}, with mode=ntlm for the NTLM corpus,
+// like api.pwnedpasswords.com: SUFFIX:COUNT lines with an ETag, and 304 to
+// a matching If-None-Match.
+type Server struct {
+	*httptest.Server
+	mu         sync.Mutex
+	sha1       map[string]int
+	ntlm       map[string]int
+	status     int
+	retryAfter time.Duration
+	body       *string
+	// requests counts every range request, including failed ones.
+	requests atomic.Int64
+}
+
+func NewServer() *Server {
+	s := &Server{sha1: make(map[string]int), ntlm: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /range/{prefix}", s.serveRange)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Add puts password in both corpora with its count.
+func (s *Server) Add(password string, count int) {
+	s.AddHash(hibp.HashPassword(password), count)
+	s.AddHash(hibp.HashNTLM(password), count)
+}
+
+// AddHash puts a 40-hex SHA-1 or 32-hex NTLM hash in its corpus.
+func (s *Server) AddHash(hash string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash = strings.ToUpper(hash)
+	switch len(hash) {
+	case 40:
+		s.sha1[hash] = count
+	case 32:
+		s.ntlm[hash] = count
+	default:
+		panic("hibptest: hash must be 40 or 32 hex characters: " + hash)
+	}
+}
+
+// Fail makes every later request answer status with no body; 0 or 200
+// goes back to serving ranges.
+func (s *Server) Fail(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.retryAfter = status, 0
+}
+
+// RateLimit makes every later request answer 429 with a Retry-After of
+// wait, rounded down to seconds, as HIBP does; Fail(0) ends it.
+func (s *Server) RateLimit(wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.retryAfter = http.StatusTooManyRequests, wait
+}
+
+// Serve makes every later request answer 200 with body instead of the
+// range, such as a login page or a corrupt listing; Restore ends it.
+func (s *Server) Serve(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = &body
+}
+
+// Restore goes back to serving ranges after Serve.
+func (s *Server) Restore() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = nil
+}
+
+// Requests returns how many range requests were made so far.
+func (s *Server) Requests() int {
+	return int(s.requests.Load())
+}
+
+func (s *Server) serveRange(w http.ResponseWriter, req *http.Request) {
+	s.requests.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 && s.status != http.StatusOK {
+		if s.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.retryAfter.Seconds())))
+		}
+		w.WriteHeader(s.status)
+		return
+	}
+	if s.body != nil {
+		w.Write([]byte(*s.body))
+		return
+	}
+
+	prefix := strings.ToUpper(req.PathValue("prefix"))
+	if len(prefix) != 5 {
+		http.Error(w, "the hash prefix must be 5 hex characters", http.StatusBadRequest)
+		return
+	}
+	corpus := s.sha1
+	if req.URL.Query().Get("mode") == "ntlm" {
+		corpus = s.ntlm
+	}
+	var lines []string
+	for hash, count := range corpus {
+		if strings.HasPrefix(hash, prefix) {
+			lines = append(lines, fmt.Sprintf("%s:%d\r\n", hash[5:], count))
+		}
+	}
+	slices.Sort(lines)
+	body := strings.Join(lines, "")
+
+	sum := sha256.Sum256([]byte(body))
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(body))
+}
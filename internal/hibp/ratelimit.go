@@ -0,0 +1,40 @@
+package hibp
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket paces requests to rate per second, allowing bursts of up to
+// one second's worth. Callers that find it empty reserve the next token and
+// sleep until it is due, so concurrent callers queue in order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := max(rate, 1)
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take blocks until a token is available and returns how long it waited.
+func (b *tokenBucket) take() time.Duration {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit <= 0 {
+		return 0
+	}
+	wait := time.Duration(deficit / b.rate * float64(time.Second))
+	time.Sleep(wait)
+	return wait
+}
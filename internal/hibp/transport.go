@@ -0,0 +1,101 @@
+package hibp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// defaultIdleConns keeps enough warm connections for a Client shared by a
+// handful of goroutines; a serial run only ever uses one.
+const defaultIdleConns = 8
+
+// ConnStats counts how requests got their connection. A high reuse ratio
+// means keep-alives are working and most lookups skip the TCP and TLS
+// handshakes.
+type ConnStats struct {
+	New    int
+	Reused int
+	// HTTP2 counts responses served over HTTP/2.
+	HTTP2 int
+}
+
+type connCounters struct {
+	fresh, reused, http2 atomic.Int64
+}
+
+func (cc *connCounters) snapshot() ConnStats {
+	return ConnStats{New: int(cc.fresh.Load()), Reused: int(cc.reused.Load()), HTTP2: int(cc.http2.Load())}
+}
+
+// trace records whether req ends up on a pooled connection.
+func (cc *connCounters) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				cc.reused.Add(1)
+			} else {
+				cc.fresh.Add(1)
+			}
+		},
+	})
+}
+
+// newTransport tunes the default transport for many small requests to a
+// single host: keep-alives with an idle pool sized for idleConns concurrent
+// callers, and HTTP/2 negotiated over TLS. tlsConfig, when set, supplies
+// the trusted roots; with pins, the server must also present one of the
+// pinned public keys.
+func newTransport(idleConns int, tlsConfig *tls.Config, pins [][]byte) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	t.MaxIdleConns = idleConns
+	t.MaxIdleConnsPerHost = idleConns
+	t.IdleConnTimeout = 90 * time.Second
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig.Clone()
+	}
+	if len(pins) > 0 {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.VerifyConnection = verifyPins(pins)
+	}
+	return t
+}
+
+// ErrPinMismatch fails a handshake whose chain holds none of the -pin-sha256
+// keys.
+var ErrPinMismatch = errors.New("server certificate matches none of the pinned public keys")
+
+// verifyPins runs after the standard chain verification, so a pin narrows
+// what is trusted and never replaces it. Pins are matched against the
+// verified chains, not the certificates the server sent: those may carry a
+// real intermediate appended to a forged leaf. With verification disabled
+// only the leaf can be pinned.
+func verifyPins(pins [][]byte) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		chains := cs.VerifiedChains
+		if len(chains) == 0 && len(cs.PeerCertificates) > 0 {
+			chains = [][]*x509.Certificate{cs.PeerCertificates[:1]}
+		}
+		for _, chain := range chains {
+			for _, cert := range chain {
+				sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				for _, pin := range pins {
+					if bytes.Equal(sum[:], pin) {
+						return nil
+					}
+				}
+			}
+		}
+		return ErrPinMismatch
+	}
+}
@@ -0,0 +1,248 @@
+// Package history keeps the findings of every run in an SQLite database, so
+// audits can be compared over time: which findings are new, which were
+// fixed, and when each was first and last seen.
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// The database holds one row per run and one per finding of a run. Findings
+// are keyed by a fingerprint of source, account, username and hash; neither
+// the hashes nor the passwords are stored:
+//
+//	SELECT started, pwned FROM runs WHERE source = 'creds.txt' ORDER BY id;
+const version = 1
+
+var schema = []string{
+	"CREATE TABLE IF NOT EXISTS meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)",
+	`CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY,
+		started TEXT NOT NULL,
+		finished TEXT NOT NULL,
+		source TEXT NOT NULL,
+		checked INTEGER NOT NULL,
+		pwned INTEGER NOT NULL,
+		clean INTEGER NOT NULL,
+		errors INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS findings (
+		run INTEGER NOT NULL REFERENCES runs(id),
+		key TEXT NOT NULL,
+		account TEXT NOT NULL,
+		username TEXT NOT NULL,
+		line INTEGER NOT NULL,
+		count INTEGER NOT NULL,
+		PRIMARY KEY (run, key)
+	) WITHOUT ROWID`,
+	"CREATE INDEX IF NOT EXISTS findings_key ON findings (key, run)",
+	"INSERT OR IGNORE INTO meta VALUES ('version', '" + strconv.Itoa(version) + "')",
+}
+
+// timeLayout sorts as text, so runs can be ordered by their timestamps too.
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+// Store is an open history database.
+type Store struct {
+	db *sql.DB
+}
+
+// Run is one recorded run. Source is the input it checked.
+type Run struct {
+	ID       int64
+	Started  time.Time
+	Finished time.Time
+	Source   string
+	Checked  int
+	Pwned    int
+	Clean    int
+	Errors   int
+}
+
+// Finding is one compromised entry of a run.
+type Finding struct {
+	Key      string
+	Account  string
+	Username string
+	Line     int
+	Count    int
+}
+
+// Sighting is when a finding was first and last recorded, and in how many
+// runs.
+type Sighting struct {
+	First time.Time
+	Last  time.Time
+	Runs  int
+}
+
+// Open opens the database at path, creating it when it does not exist.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	s := &Store{db: db}
+	if err := s.init(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open history %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *Store) init() error {
+	for _, stmt := range schema {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	var v string
+	if err := s.db.QueryRow("SELECT value FROM meta WHERE key = 'version'").Scan(&v); err != nil {
+		return errors.New("not a PwnedCheck history database")
+	}
+	if v != strconv.Itoa(version) {
+		return fmt.Errorf("unsupported history version %q", v)
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error { return s.db.Close() }
+
+// Add records a run and its findings in one transaction and returns the
+// run's ID. A finding listed twice is recorded once.
+func (s *Store) Add(run Run, findings []Finding) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO runs (started, finished, source, checked, pwned, clean, errors) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		formatTime(run.Started), formatTime(run.Finished), run.Source, run.Checked, run.Pwned, run.Clean, run.Errors)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	insert, err := tx.Prepare("INSERT OR IGNORE INTO findings VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+	for _, f := range findings {
+		if _, err := insert.Exec(id, f.Key, f.Account, f.Username, f.Line, f.Count); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// Seen returns the earlier sightings of the finding key; ok is false when
+// no recorded run had it.
+func (s *Store) Seen(key string) (seen Sighting, ok bool, err error) {
+	var first, last sql.NullString
+	err = s.db.QueryRow(`SELECT min(r.started), max(r.started), count(*) FROM findings f JOIN runs r ON r.id = f.run
+		WHERE f.key = ?`, key).Scan(&first, &last, &seen.Runs)
+	if err != nil || seen.Runs == 0 {
+		return Sighting{}, false, err
+	}
+	seen.First, seen.Last = parseTime(first.String), parseTime(last.String)
+	return seen, true, nil
+}
+
+// Runs returns the last n runs of source, or of every source when it is
+// empty, newest first; n <= 0 returns them all.
+func (s *Store) Runs(source string, n int) ([]Run, error) {
+	if n <= 0 {
+		n = -1
+	}
+	return s.runs("WHERE ? IN ('', source) ORDER BY id DESC LIMIT ?", source, n)
+}
+
+// Run returns the run with the given ID.
+func (s *Store) Run(id int64) (Run, error) {
+	runs, err := s.runs("WHERE id = ?", id)
+	if err != nil {
+		return Run{}, err
+	}
+	if len(runs) == 0 {
+		return Run{}, fmt.Errorf("no run %d in the history", id)
+	}
+	return runs[0], nil
+}
+
+// Previous returns the run of the same source before run; ok is false for
+// the first one.
+func (s *Store) Previous(run Run) (prev Run, ok bool, err error) {
+	runs, err := s.runs("WHERE source = ? AND id < ? ORDER BY id DESC LIMIT 1", run.Source, run.ID)
+	if err != nil || len(runs) == 0 {
+		return Run{}, false, err
+	}
+	return runs[0], true, nil
+}
+
+func (s *Store) runs(where string, args ...any) ([]Run, error) {
+	rows, err := s.db.Query("SELECT id, started, finished, source, checked, pwned, clean, errors FROM runs "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []Run
+	for rows.Next() {
+		var r Run
+		var started, finished string
+		if err := rows.Scan(&r.ID, &started, &finished, &r.Source, &r.Checked, &r.Pwned, &r.Clean, &r.Errors); err != nil {
+			return nil, err
+		}
+		r.Started, r.Finished = parseTime(started), parseTime(finished)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// Diff returns the findings of run to that run from did not have, and those
+// of from that to no longer has, each in line order.
+func (s *Store) Diff(from, to int64) (added, fixed []Finding, err error) {
+	if added, err = s.only(to, from); err != nil {
+		return nil, nil, err
+	}
+	if fixed, err = s.only(from, to); err != nil {
+		return nil, nil, err
+	}
+	return added, fixed, nil
+}
+
+// only returns the findings of run a that run b does not have.
+func (s *Store) only(a, b int64) ([]Finding, error) {
+	rows, err := s.db.Query(`SELECT key, account, username, line, count FROM findings WHERE run = ?
+		AND key NOT IN (SELECT key FROM findings WHERE run = ?) ORDER BY line, account, username`, a, b)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var findings []Finding
+	for rows.Next() {
+		var f Finding
+		if err := rows.Scan(&f.Key, &f.Account, &f.Username, &f.Line, &f.Count); err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
+
+func formatTime(t time.Time) string { return t.UTC().Format(timeLayout) }
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(timeLayout, s)
+	return t
+}
@@ -0,0 +1,230 @@
+// Package index reads and writes PwnedCheck's packed offline index: the
+// corpus as fixed-size records of a binary hash and a count, sorted by hash,
+// so a lookup is a binary search over a memory-mapped file. The same records
+// can be stored in an SQLite database instead.
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+var magic = [4]byte{'P', 'C', 'I', 'X'}
+
+const version = 1
+
+// headerSize covers the magic, the version and the hash length; the record
+// count follows from the file size.
+const headerSize = 6
+
+const (
+	SHA1Len = 20
+	NTLMLen = 16
+)
+
+// Backends are the formats an index can be built in: the packed binary
+// file, or an SQLite database.
+var Backends = []string{"binary", "sqlite"}
+
+// Writer appends records, which must arrive in strictly ascending hash order.
+type Writer struct {
+	bw      *bufio.Writer
+	hashLen int
+	last    []byte
+	n       int64
+	buf     []byte
+}
+
+// NewWriter writes the header for hashes of hashLen bytes, SHA1Len or
+// NTLMLen.
+func NewWriter(w io.Writer, hashLen int) (*Writer, error) {
+	if hashLen != SHA1Len && hashLen != NTLMLen {
+		return nil, fmt.Errorf("unsupported hash length %d", hashLen)
+	}
+	bw := bufio.NewWriterSize(w, 1<<20)
+	header := append(magic[:], version, byte(hashLen))
+	if _, err := bw.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{bw: bw, hashLen: hashLen, buf: make([]byte, hashLen+4)}, nil
+}
+
+// Add writes one record. Counts above the uint32 range are clamped.
+func (w *Writer) Add(hash []byte, count int) error {
+	if len(hash) != w.hashLen {
+		return fmt.Errorf("expected a %d-byte hash, got %d bytes", w.hashLen, len(hash))
+	}
+	if w.last != nil && bytes.Compare(hash, w.last) <= 0 {
+		return errors.New("hashes must be sorted and unique")
+	}
+	copy(w.buf, hash)
+	binary.LittleEndian.PutUint32(w.buf[w.hashLen:], uint32(min(max(count, 0), math.MaxUint32)))
+	if _, err := w.bw.Write(w.buf); err != nil {
+		return err
+	}
+	w.last = append(w.last[:0], hash...)
+	w.n++
+	return nil
+}
+
+func (w *Writer) HashLen() int { return w.hashLen }
+
+// Len is the number of records written so far.
+func (w *Writer) Len() int64 { return w.n }
+
+func (w *Writer) Flush() error { return w.bw.Flush() }
+
+// Index is safe for concurrent lookups until Close.
+type Index struct {
+	file    *os.File
+	data    []byte // the mapped file, nil where mmap is unavailable
+	hashLen int
+	n       int64
+	// db is set instead of file for the SQLite backend
+	db     *sql.DB
+	lookup [partitions]*sql.Stmt
+}
+
+// Open maps the index at path into memory, or opens it as a database when
+// it was built with the SQLite backend.
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var header [len(sqliteMagic)]byte
+	if _, err := f.ReadAt(header[:], 0); err == nil && string(header[:]) == sqliteMagic {
+		f.Close()
+		return openSQLite(path)
+	}
+	if _, err := f.ReadAt(header[:headerSize], 0); err != nil || [4]byte(header[:4]) != magic {
+		f.Close()
+		return nil, errors.New("not a PwnedCheck index file")
+	}
+	if header[4] != version {
+		f.Close()
+		return nil, fmt.Errorf("unsupported index version %d", header[4])
+	}
+	hashLen := int(header[5])
+	body := info.Size() - headerSize
+	if (hashLen != SHA1Len && hashLen != NTLMLen) || body%int64(hashLen+4) != 0 {
+		f.Close()
+		return nil, errors.New("corrupted index file")
+	}
+	ix := &Index{file: f, hashLen: hashLen, n: body / int64(hashLen+4)}
+	if ix.data, err = mmap(f, info.Size()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return ix, nil
+}
+
+// Len is the number of hashes in the index.
+func (ix *Index) Len() int64 { return ix.n }
+
+// NTLM reports whether the index holds NTLM rather than SHA-1 hashes.
+func (ix *Index) NTLM() bool { return ix.hashLen == NTLMLen }
+
+// Kind names the hash kind for messages: "SHA-1" or "NTLM".
+func (ix *Index) Kind() string {
+	if ix.NTLM() {
+		return "NTLM"
+	}
+	return "SHA-1"
+}
+
+// Lookup returns the count recorded for hash, and whether it is present.
+func (ix *Index) Lookup(hash []byte) (int, bool, error) {
+	if len(hash) != ix.hashLen {
+		return 0, false, fmt.Errorf("expected a %d-byte hash, got %d bytes", ix.hashLen, len(hash))
+	}
+	if ix.db != nil {
+		return ix.lookupSQLite(hash)
+	}
+	size := int64(ix.hashLen + 4)
+	rec := make([]byte, size)
+	var readErr error
+	read := func(i int64) []byte {
+		off := headerSize + i*size
+		if ix.data != nil {
+			return ix.data[off : off+size]
+		}
+		if _, err := ix.file.ReadAt(rec, off); err != nil {
+			readErr = err
+		}
+		return rec
+	}
+
+	lo, hi := int64(0), ix.n
+	for lo < hi && readErr == nil {
+		mid := lo + (hi-lo)/2
+		r := read(mid)
+		switch bytes.Compare(r[:ix.hashLen], hash) {
+		case 0:
+			return int(binary.LittleEndian.Uint32(r[ix.hashLen:])), true, readErr
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false, readErr
+}
+
+// LookupHex is Lookup for a hex-encoded hash.
+func (ix *Index) LookupHex(s string) (int, bool, error) {
+	hash, err := hex.DecodeString(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid hash: %w", err)
+	}
+	return ix.Lookup(hash)
+}
+
+// Verify reads every record and checks that the hashes are strictly
+// increasing, as the binary search relies on; a database gets verifySQLite.
+func (ix *Index) Verify() error {
+	if ix.db != nil {
+		return ix.verifySQLite()
+	}
+	size := ix.hashLen + 4
+	r := bufio.NewReaderSize(io.NewSectionReader(ix.file, headerSize, ix.n*int64(size)), 1<<20)
+	rec, prev := make([]byte, size), make([]byte, ix.hashLen)
+	for i := int64(0); i < ix.n; i++ {
+		if _, err := io.ReadFull(r, rec); err != nil {
+			return fmt.Errorf("failed to read record %d: %w", i, err)
+		}
+		if i > 0 && bytes.Compare(rec[:ix.hashLen], prev) <= 0 {
+			return fmt.Errorf("record %d is out of order; rebuild the index from a sorted corpus", i)
+		}
+		copy(prev, rec[:ix.hashLen])
+	}
+	return nil
+}
+
+func (ix *Index) Close() error {
+	if ix.db != nil {
+		return ix.db.Close()
+	}
+	var err error
+	if ix.data != nil {
+		err = munmap(ix.data)
+		ix.data = nil
+	}
+	if cerr := ix.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
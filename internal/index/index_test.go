@@ -0,0 +1,193 @@
+package index
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sortedHashes returns n distinct SHA-1 digests in ascending order.
+func sortedHashes(n int) [][]byte {
+	hashes := make([][]byte, n)
+	for i := range hashes {
+		sum := sha1.Sum([]byte(strconv.Itoa(i)))
+		hashes[i] = sum[:]
+	}
+	slices.SortFunc(hashes, bytes.Compare)
+	return hashes
+}
+
+func writeBinary(t *testing.T, path string, hashes [][]byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := NewWriter(f, SHA1Len)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, h := range hashes {
+		if err := w.Add(h, i+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeSQLite(t *testing.T, path string, hashes [][]byte) {
+	t.Helper()
+	w, err := NewSQLiteWriter(path, SHA1Len)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for i, h := range hashes {
+		if err := w.Add(h, i+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	hashes := sortedHashes(500)
+	for _, tc := range []struct {
+		backend string
+		write   func(*testing.T, string, [][]byte)
+	}{
+		{"binary", writeBinary},
+		{"sqlite", writeSQLite},
+	} {
+		t.Run(tc.backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "index")
+			tc.write(t, path, hashes)
+			ix, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ix.Close()
+
+			if ix.Len() != 500 || ix.NTLM() || ix.Kind() != "SHA-1" {
+				t.Fatalf("index = %d %s records, want 500 SHA-1", ix.Len(), ix.Kind())
+			}
+			if err := ix.Verify(); err != nil {
+				t.Fatalf("Verify = %v", err)
+			}
+			for i, h := range hashes {
+				if count, ok, err := ix.LookupHex(strings.ToUpper(hex.EncodeToString(h))); err != nil || !ok || count != i+1 {
+					t.Fatalf("lookup of record %d = %d, %v, %v; want %d, true, nil", i, count, ok, err, i+1)
+				}
+			}
+			absent := sha1.Sum([]byte("not in the index"))
+			if count, ok, err := ix.Lookup(absent[:]); err != nil || ok || count != 0 {
+				t.Errorf("lookup of an absent hash = %d, %v, %v; want 0, false, nil", count, ok, err)
+			}
+			if _, _, err := ix.Lookup(absent[:NTLMLen]); err == nil {
+				t.Error("lookup of an NTLM-sized hash succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestWriterOrder(t *testing.T) {
+	hashes := sortedHashes(2)
+	for _, tc := range []struct {
+		name   string
+		second []byte
+	}{
+		{"duplicate", hashes[0]},
+		{"descending", bytes.Repeat([]byte{0}, SHA1Len)},
+		{"wrong length", hashes[1][:NTLMLen]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWriter(new(bytes.Buffer), SHA1Len)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Add(hashes[0], 1); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Add(tc.second, 1); err == nil || w.Len() != 1 {
+				t.Fatalf("Add = %v with %d records; want an error with 1", err, w.Len())
+			}
+		})
+	}
+	if _, err := NewWriter(new(bytes.Buffer), 32); err == nil {
+		t.Error("NewWriter(32-byte hashes) succeeded, want an error")
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good")
+	writeBinary(t, good, sortedHashes(3))
+	data, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(i int, b byte) []byte {
+		d := bytes.Clone(data)
+		d[i] = b
+		return d
+	}
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", with(0, 'X')},
+		{"newer version", with(4, version+1)},
+		{"bad hash length", with(5, 32)},
+		{"partial record", data[:len(data)-1]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "-"))
+			if err := os.WriteFile(path, tc.data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if ix, err := Open(path); err == nil {
+				ix.Close()
+				t.Fatal("Open succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestVerifyOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	hashes := sortedHashes(3)
+	// swap the first two records behind the writer's back
+	writeBinary(t, path, hashes)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := SHA1Len + 4
+	first := bytes.Clone(data[headerSize : headerSize+size])
+	copy(data[headerSize:], data[headerSize+size:headerSize+2*size])
+	copy(data[headerSize+size:], first)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	if err := ix.Verify(); err == nil || !strings.Contains(err.Error(), "record 1 is out of order") {
+		t.Fatalf("Verify = %v, want record 1 out of order", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package index
+
+import "os"
+
+// Without mmap, lookups fall back to a ReadAt per probe, which the OS page
+// cache still keeps fast.
+func mmap(f *os.File, size int64) ([]byte, error) {
+	return nil, nil
+}
+
+func munmap(data []byte) error {
+	return nil
+}
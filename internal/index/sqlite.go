@@ -0,0 +1,220 @@
+package index
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	_ "modernc.org/sqlite"
+)
+
+// The SQLite backend stores the same records as a database, for users who
+// would rather query the corpus than search a packed file. Records are
+// partitioned by their first hash byte into 256 tables, hashes_00 to
+// hashes_ff, each keyed by the full binary hash with its count; the hashes
+// view joins them and the meta table names the hash kind:
+//
+//	SELECT count FROM hashes WHERE hash = x'5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8';
+const sqliteMagic = "SQLite format 3\x00"
+
+// partitions is the number of hashes_XX tables.
+const partitions = 256
+
+func partition(b byte) string { return fmt.Sprintf("hashes_%02x", b) }
+
+// SQLiteWriter is Writer for the SQLite backend. Records must arrive in
+// strictly ascending hash order too, which keeps every insert an append.
+type SQLiteWriter struct {
+	db      *sql.DB
+	tx      *sql.Tx
+	insert  [partitions]*sql.Stmt
+	hashLen int
+	last    []byte
+	n       int64
+}
+
+// NewSQLiteWriter creates the database at path, which must not exist yet,
+// for hashes of hashLen bytes.
+func NewSQLiteWriter(path string, hashLen int) (*SQLiteWriter, error) {
+	if hashLen != SHA1Len && hashLen != NTLMLen {
+		return nil, fmt.Errorf("unsupported hash length %d", hashLen)
+	}
+	// the file is built once and renamed into place, so it needs no journal
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(OFF)&_pragma=synchronous(OFF)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	w := &SQLiteWriter{db: db, hashLen: hashLen}
+	if err := w.create(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *SQLiteWriter) create() error {
+	var err error
+	if w.tx, err = w.db.Begin(); err != nil {
+		return err
+	}
+	kind := "SHA-1"
+	if w.hashLen == NTLMLen {
+		kind = "NTLM"
+	}
+	schema := []string{
+		"CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)",
+		"INSERT INTO meta VALUES ('version', '" + strconv.Itoa(version) + "'), ('kind', '" + kind + "')",
+	}
+	view := "CREATE VIEW hashes AS "
+	for i := range partitions {
+		table := partition(byte(i))
+		schema = append(schema, "CREATE TABLE "+table+" (hash BLOB PRIMARY KEY, count INTEGER NOT NULL) WITHOUT ROWID")
+		if i > 0 {
+			view += " UNION ALL "
+		}
+		view += "SELECT hash, count FROM " + table
+	}
+	for _, stmt := range append(schema, view) {
+		if _, err := w.tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	for i := range partitions {
+		if w.insert[i], err = w.tx.Prepare("INSERT INTO " + partition(byte(i)) + " VALUES (?, ?)"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add writes one record. Counts above the uint32 range are clamped, as in
+// the packed index.
+func (w *SQLiteWriter) Add(hash []byte, count int) error {
+	if len(hash) != w.hashLen {
+		return fmt.Errorf("expected a %d-byte hash, got %d bytes", w.hashLen, len(hash))
+	}
+	if w.last != nil && bytes.Compare(hash, w.last) <= 0 {
+		return errors.New("hashes must be sorted and unique")
+	}
+	if _, err := w.insert[hash[0]].Exec(hash, min(max(count, 0), 1<<32-1)); err != nil {
+		return err
+	}
+	w.last = append(w.last[:0], hash...)
+	w.n++
+	return nil
+}
+
+func (w *SQLiteWriter) HashLen() int { return w.hashLen }
+
+// Len is the number of records written so far.
+func (w *SQLiteWriter) Len() int64 { return w.n }
+
+// Flush records the count and commits the records.
+func (w *SQLiteWriter) Flush() error {
+	if _, err := w.tx.Exec("INSERT INTO meta VALUES ('count', ?)", strconv.FormatInt(w.n, 10)); err != nil {
+		return err
+	}
+	return w.tx.Commit()
+}
+
+// Close closes the database; records not flushed are discarded.
+func (w *SQLiteWriter) Close() error {
+	w.tx.Rollback()
+	return w.db.Close()
+}
+
+// openSQLite opens a database written by SQLiteWriter, read-only.
+func openSQLite(path string) (*Index, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	ix := &Index{db: db}
+	if err := ix.openSQLite(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return ix, nil
+}
+
+func (ix *Index) openSQLite() error {
+	meta := map[string]string{}
+	rows, err := ix.db.Query("SELECT key, value FROM meta")
+	if err != nil {
+		return errors.New("not a PwnedCheck index database")
+	}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			rows.Close()
+			return err
+		}
+		meta[k] = v
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if meta["version"] != strconv.Itoa(version) {
+		return fmt.Errorf("unsupported index version %q", meta["version"])
+	}
+	switch meta["kind"] {
+	case "SHA-1":
+		ix.hashLen = SHA1Len
+	case "NTLM":
+		ix.hashLen = NTLMLen
+	default:
+		return errors.New("corrupted index database")
+	}
+	if ix.n, err = strconv.ParseInt(meta["count"], 10, 64); err != nil {
+		return errors.New("incomplete index database; rebuild it")
+	}
+	for i := range partitions {
+		if ix.lookup[i], err = ix.db.Prepare("SELECT count FROM " + partition(byte(i)) + " WHERE hash = ?"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ix *Index) lookupSQLite(hash []byte) (int, bool, error) {
+	var count int
+	switch err := ix.lookup[hash[0]].QueryRow(hash).Scan(&count); {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, err
+	}
+	return count, true, nil
+}
+
+// verifySQLite runs SQLite's own consistency check and confirms every
+// record sits in the partition of its first byte and the count matches.
+func (ix *Index) verifySQLite() error {
+	var result string
+	if err := ix.db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("corrupted index database: %s", result)
+	}
+	var total int64
+	for i := range partitions {
+		var n, misplaced int64
+		query := "SELECT count(*), count(*) FILTER (WHERE length(hash) != ? OR substr(hash, 1, 1) != ?) FROM " + partition(byte(i))
+		if err := ix.db.QueryRow(query, ix.hashLen, []byte{byte(i)}).Scan(&n, &misplaced); err != nil {
+			return err
+		}
+		if misplaced > 0 {
+			return fmt.Errorf("%s holds %d records that do not belong there; rebuild the index", partition(byte(i)), misplaced)
+		}
+		total += n
+	}
+	if total != ix.n {
+		return fmt.Errorf("the index database holds %d records but recorded %d; rebuild it", total, ix.n)
+	}
+	return nil
+}
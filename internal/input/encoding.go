@@ -0,0 +1,97 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encodings lists the values accepted for Options.Encoding. "auto" honours
+// UTF-8 and UTF-16 byte order marks and recognises BOM-less UTF-16 by its
+// NUL bytes, falling back to UTF-8.
+var Encodings = []string{"auto", "utf8", "utf16le", "utf16be", "latin1", "cp1252"}
+
+func CheckEncoding(name string) error {
+	if name == "" || slices.Contains(Encodings, name) {
+		return nil
+	}
+	return fmt.Errorf("unknown encoding %q (available: %s)", name, strings.Join(Encodings, ", "))
+}
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// decode converts the decompressed stream to UTF-8 with any BOM removed.
+func decode(rc io.ReadCloser, name string) (io.ReadCloser, error) {
+	if err := CheckEncoding(name); err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(rc)
+	var enc encoding.Encoding
+	switch name {
+	case "utf8":
+		enc = unicode.UTF8BOM
+	case "utf16le":
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case "utf16be":
+		enc = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case "latin1":
+		enc = charmap.ISO8859_1
+	case "cp1252":
+		enc = charmap.Windows1252
+	default:
+		enc = sniffEncoding(br)
+	}
+	if enc == nil {
+		return &readCloser{Reader: br, closers: []io.Closer{rc}}, nil
+	}
+	return &readCloser{Reader: transform.NewReader(br, enc.NewDecoder()), closers: []io.Closer{rc}}, nil
+}
+
+// sniffEncoding looks at the first bytes for a BOM or the NUL pattern of
+// ASCII text in UTF-16. A nil result means plain UTF-8 without a BOM.
+func sniffEncoding(br *bufio.Reader) encoding.Encoding {
+	head, _ := br.Peek(512)
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return unicode.UTF8BOM
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	}
+
+	var even, odd int
+	for i, b := range head {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case pairs == 0:
+		return nil
+	case odd > pairs/2 && even == 0:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case even > pairs/2 && odd == 0:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package input
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte("BZh")
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	magicZip   = []byte("PK\x03\x04")
+)
+
+type Options struct {
+	// Headers are sent with the request when the input is an http(s) URL.
+	Headers http.Header
+	// Encoding is the text encoding of the content, one of Encodings;
+	// empty means "auto".
+	Encoding string
+	// TLS replaces the default TLS settings for http(s) inputs.
+	TLS *tls.Config
+	// Timeout bounds connecting to an http(s) input, waiting for its
+	// response headers and each wait for more of its body, but not the
+	// whole download; 0 means no limit.
+	Timeout time.Duration
+}
+
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// Open opens path for reading and transparently decompresses gzip, bzip2,
+// zstd and zip content. The format is sniffed from the leading bytes rather
+// than the extension, so renamed files still work. Paths starting with
+// http:// or https:// are streamed from the network. The content is decoded
+// to UTF-8 according to opts.Encoding.
+func Open(path string, opts Options) (io.ReadCloser, error) {
+	rc, err := open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decode(rc, opts.Encoding)
+}
+
+func open(path string, opts Options) (io.ReadCloser, error) {
+	if IsURL(path) {
+		return openURL(path, opts)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(file)
+	if head, _ := br.Peek(4); bytes.HasPrefix(head, magicZip) {
+		return openZip(file)
+	}
+	return decompress(br, file)
+}
+
+func openURL(url string, opts Options) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for name, values := range opts.Headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	req.Header.Set("User-Agent", "PwnedCheck/1.0")
+
+	resp, err := httpClient(opts).Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to fetch input: %w", err)
+	}
+	body := watchStalls(resp.Body, opts.Timeout, cancel)
+	if resp.StatusCode != http.StatusOK {
+		body.Close()
+		return nil, fmt.Errorf("failed to fetch input: unexpected status %s", resp.Status)
+	}
+
+	br := bufio.NewReader(body)
+	if head, _ := br.Peek(4); bytes.HasPrefix(head, magicZip) {
+		body.Close()
+		return nil, errors.New("zip archives cannot be streamed from a URL; use .gz, .bz2 or .zst")
+	}
+	return decompress(br, body)
+}
+
+// httpClient builds the client for URL inputs on a copy of the default
+// transport, so the proxy comes from the environment as for HIBP requests.
+// There is no overall timeout: large lists can legitimately take a long
+// time to stream.
+func httpClient(opts Options) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.TLS != nil {
+		t.TLSClientConfig = opts.TLS
+	}
+	if opts.Timeout > 0 {
+		t.DialContext = (&net.Dialer{Timeout: opts.Timeout, KeepAlive: 30 * time.Second}).DialContext
+		t.TLSHandshakeTimeout = opts.Timeout
+		t.ResponseHeaderTimeout = opts.Timeout
+	}
+	return &http.Client{Transport: t}
+}
+
+// stallReader fails a download that sent nothing for timeout while it was
+// read. Time spent between reads doesn't count.
+type stallReader struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	stalled atomic.Bool
+}
+
+// watchStalls wraps body in a stallReader cancelling the request; with no
+// timeout it only releases the request on Close.
+func watchStalls(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) io.ReadCloser {
+	s := &stallReader{ReadCloser: body, timeout: timeout, cancel: cancel}
+	if timeout > 0 {
+		s.timer = time.AfterFunc(timeout, func() {
+			s.stalled.Store(true)
+			cancel()
+		})
+		s.timer.Stop()
+	}
+	return s
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	if s.timer == nil {
+		return s.ReadCloser.Read(p)
+	}
+	s.timer.Reset(s.timeout)
+	n, err := s.ReadCloser.Read(p)
+	s.timer.Stop()
+	if err != nil && s.stalled.Load() {
+		return n, fmt.Errorf("failed to fetch input: no data for %s", s.timeout)
+	}
+	return n, err
+}
+
+func (s *stallReader) Close() error {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	err := s.ReadCloser.Close()
+	s.cancel()
+	return err
+}
+
+func decompress(br *bufio.Reader, src io.Closer) (io.ReadCloser, error) {
+	head, _ := br.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(head, magicGzip):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			src.Close()
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		return &readCloser{Reader: gz, closers: []io.Closer{gz, src}}, nil
+
+	case bytes.HasPrefix(head, magicBzip2):
+		return &readCloser{Reader: bzip2.NewReader(br), closers: []io.Closer{src}}, nil
+
+	case bytes.HasPrefix(head, magicZstd):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			src.Close()
+			return nil, fmt.Errorf("failed to open zstd stream: %w", err)
+		}
+		return &readCloser{Reader: zr, closers: []io.Closer{closerFunc(zr.Close), src}}, nil
+	}
+
+	return &readCloser{Reader: br, closers: []io.Closer{src}}, nil
+}
+
+// openZip concatenates every regular file in the archive, in directory order,
+// with a newline between members so the last line of one file never merges
+// with the first line of the next.
+func openZip(file *os.File) (io.ReadCloser, error) {
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+
+	rc := &readCloser{closers: []io.Closer{file}}
+	var readers []io.Reader
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		member, err := f.Open()
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("failed to open %s in zip archive: %w", f.Name, err)
+		}
+		rc.closers = append(rc.closers, member)
+		readers = append(readers, member, strings.NewReader("\n"))
+	}
+	if len(readers) == 0 {
+		rc.Close()
+		return nil, errors.New("zip archive contains no files")
+	}
+
+	rc.Reader = io.MultiReader(readers...)
+	return rc, nil
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type closerFunc func()
+
+func (f closerFunc) Close() error {
+	f()
+	return nil
+}
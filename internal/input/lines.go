@@ -0,0 +1,82 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Lines reads r line by line like bufio.Scanner, without the Scanner's
+// 64 KiB token limit: a line of up to max bytes (0 for no limit) is kept
+// whole, and a longer one is read past in chunks without being buffered,
+// so memory stays bounded however long the line or large the file.
+type Lines struct {
+	r       *bufio.Reader
+	max     int
+	line    []byte
+	tooLong bool
+	err     error
+}
+
+func NewLines(r io.Reader, max int) *Lines {
+	return &Lines{r: bufio.NewReaderSize(r, 64<<10), max: max}
+}
+
+// Next advances to the next line, reporting false at the end of the input
+// or on a read error.
+func (l *Lines) Next() bool {
+	if l.err != nil {
+		return false
+	}
+	l.line, l.tooLong = l.line[:0], false
+	read := false
+	for {
+		chunk, err := l.r.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if !l.tooLong {
+			if l.max > 0 && len(l.line)+len(chunk) > l.max+len("\r\n") {
+				l.line, l.tooLong = l.line[:0], true
+			} else {
+				l.line = append(l.line, chunk...)
+			}
+		}
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case err == io.EOF:
+			l.err = err
+		case err != nil:
+			l.err = err
+			return false
+		}
+		l.line = bytes.TrimSuffix(l.line, []byte("\n"))
+		l.line = bytes.TrimSuffix(l.line, []byte("\r"))
+		if l.max > 0 && len(l.line) > l.max {
+			l.line, l.tooLong = l.line[:0], true
+		}
+		return err == nil || read
+	}
+}
+
+// Bytes returns the current line without its line ending, or nil when it
+// was too long. The slice is reused by the next call to Next.
+func (l *Lines) Bytes() []byte {
+	if l.tooLong {
+		return nil
+	}
+	return l.line
+}
+
+// TooLong reports whether the current line was longer than the limit.
+func (l *Lines) TooLong() bool {
+	return l.tooLong
+}
+
+// Err returns the first read error other than io.EOF.
+func (l *Lines) Err() error {
+	if l.err == io.EOF {
+		return nil
+	}
+	return l.err
+}
@@ -0,0 +1,44 @@
+package input
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	// longer than the 64 KiB read buffer, so it arrives in chunks
+	huge := strings.Repeat("x", 70<<10)
+	for _, tc := range []struct {
+		name    string
+		input   string
+		max     int
+		lines   []string
+		tooLong []int
+	}{
+		{"empty", "", 0, nil, nil},
+		{"LF", "a\nb\n", 0, []string{"a", "b"}, nil},
+		{"CRLF", "a\r\nb\r\n", 0, []string{"a", "b"}, nil},
+		{"no trailing newline", "a\nb", 0, []string{"a", "b"}, nil},
+		{"blank lines", "a\n\n\nb\n", 0, []string{"a", "", "", "b"}, nil},
+		{"at the limit", "abcd\r\nabcde\n", 4, []string{"abcd", ""}, []int{2}},
+		{"too long last line", "ok\nabcdefgh", 4, []string{"ok", ""}, []int{2}},
+		{"huge line kept", huge + "\nnext\n", 0, []string{huge, "next"}, nil},
+		{"huge line skipped", huge + "\nnext\n", 1024, []string{"", "next"}, []int{1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := NewLines(strings.NewReader(tc.input), tc.max)
+			var got []string
+			var tooLong []int
+			for lines.Next() {
+				got = append(got, string(lines.Bytes()))
+				if lines.TooLong() {
+					tooLong = append(tooLong, len(got))
+				}
+			}
+			if err := lines.Err(); err != nil || !slices.Equal(got, tc.lines) || !slices.Equal(tooLong, tc.tooLong) {
+				t.Fatalf("lines = %d %.20q, too long %v, %v; want %d %.20q, %v, nil", len(got), got, tooLong, err, len(tc.lines), tc.lines, tc.tooLong)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Name describes the store Items reads.
+const Name = "Windows Credential Manager"
+
+var (
+	advapi32          = windows.NewLazySystemDLL("advapi32.dll")
+	procCredEnumerate = advapi32.NewProc("CredEnumerateW")
+	procCredFree      = advapi32.NewProc("CredFree")
+)
+
+// Credential types, from wincred.h.
+const (
+	credTypeGeneric               = 1
+	credTypeDomainPassword        = 2
+	credTypeDomainVisiblePassword = 4
+	credEnumerateAllCredentials   = 0x1
+)
+
+// credential mirrors CREDENTIALW.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// Items returns the generic and domain credentials of the signed-in user,
+// named by target. Windows keeps the passwords of domain credentials from
+// every process but the LSA, so those come back without a secret and are
+// counted in denied; generic credentials, where Git, browsers and most
+// applications store theirs, are read in full.
+func Items() (items []Item, denied int, err error) {
+	var count uint32
+	var list **credential
+	r, _, e := procCredEnumerate.Call(0, credEnumerateAllCredentials, uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&list)))
+	if r == 0 {
+		if errors.Is(e, windows.ERROR_NOT_FOUND) {
+			return nil, 0, nil
+		}
+		return nil, 0, e
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(list)))
+
+	for _, c := range unsafe.Slice(list, count) {
+		kind := "generic"
+		switch c.Type {
+		case credTypeGeneric:
+		case credTypeDomainPassword, credTypeDomainVisiblePassword:
+			kind = "domain"
+		default:
+			continue
+		}
+		it := Item{
+			Service: windows.UTF16PtrToString(c.TargetName),
+			Account: windows.UTF16PtrToString(c.UserName),
+			Kind:    kind,
+		}
+		if c.CredentialBlobSize == 0 {
+			denied++
+			continue
+		}
+		it.Secret = blobText(unsafe.Slice(c.CredentialBlob, c.CredentialBlobSize))
+		items = append(items, it)
+	}
+	return items, denied, nil
+}
+
+// blobText copies a credential blob. Credential Manager and cmdkey store
+// passwords as UTF-16, whose zero bytes tell it from the UTF-8 some
+// applications store instead.
+func blobText(b []byte) []byte {
+	if len(b)%2 == 0 && (bytes.IndexByte(b, 0) >= 0 || !utf8.Valid(b)) {
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+		}
+		if s := string(utf16.Decode(u)); !strings.ContainsAny(s, "\x00\uFFFD") {
+			return []byte(s)
+		}
+	}
+	return bytes.Clone(b)
+}
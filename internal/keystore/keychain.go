@@ -0,0 +1,111 @@
+package keystore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// keychainKinds names the Keychain classes holding passwords; keys,
+// certificates and identities are left out.
+var keychainKinds = map[string]string{
+	"inet": "internet",
+	"genp": "application",
+}
+
+// parseDump reads the output of security dump-keychain -d. Every item starts
+// with a keychain: line, lists its attributes and ends with its data, which
+// is missing when the user denied access to it; denied counts those.
+func parseDump(r io.Reader) (items []Item, denied int, err error) {
+	var it *Item
+	attrs := map[string]string{}
+	inData := false
+	flush := func() {
+		if it != nil {
+			it.Service = attrs["srvr"]
+			if it.Kind == "application" || it.Service == "" {
+				it.Service = attrs["svce"]
+			}
+			it.Account = attrs["acct"]
+			if it.Secret != nil {
+				items = append(items, *it)
+			} else {
+				denied++
+			}
+		}
+		it, inData = nil, false
+		clear(attrs)
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "keychain: "):
+			flush()
+		case strings.HasPrefix(line, "class: "):
+			class, _ := strconv.Unquote(strings.TrimPrefix(line, "class: "))
+			if kind, ok := keychainKinds[class]; ok {
+				it = &Item{Kind: kind}
+			}
+		case it == nil:
+		case line == "data:":
+			inData = true
+		case inData:
+			it.Secret = dumpValue(line)
+			inData = false
+		case strings.HasPrefix(line, "    \""):
+			name, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if name, _, _ = strings.Cut(name, "<"); ok {
+				attrs[strings.Trim(name, "\"")] = string(dumpValue(value))
+			}
+		}
+	}
+	flush()
+	return items, denied, sc.Err()
+}
+
+// dumpValue decodes a value as security prints it: <NULL>, a quoted string
+// with octal escapes, or hex followed by its quoted rendering when it holds
+// unprintable bytes.
+func dumpValue(v string) []byte {
+	if h, ok := strings.CutPrefix(v, "0x"); ok {
+		h, _, _ = strings.Cut(h, " ")
+		b, err := hex.DecodeString(h)
+		if err == nil {
+			return b
+		}
+	}
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return []byte{}
+	}
+	v = v[1 : len(v)-1]
+	var b bytes.Buffer
+	for i := 0; i < len(v); i++ {
+		switch {
+		case v[i] != '\\' || i+1 == len(v):
+			b.WriteByte(v[i])
+		case i+3 < len(v) && isOctal(v[i+1:i+4]):
+			n, _ := strconv.ParseUint(v[i+1:i+4], 8, 8)
+			b.WriteByte(byte(n))
+			i += 3
+		default:
+			i++
+			b.WriteByte(v[i])
+		}
+	}
+	return b.Bytes()
+}
+
+func isOctal(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '7' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package keystore
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Name describes the store Items reads.
+const Name = "macOS Keychain"
+
+// Items returns the internet and application passwords in the user's
+// keychain search list. They are read through security(1), the Security
+// framework's own front end, so macOS asks for consent to each item as it
+// would for any other application; denied is how many were refused.
+func Items() (items []Item, denied int, err error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "dump-keychain", "-d")
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, 0, fmt.Errorf("failed to run security: %w", err)
+	}
+	items, denied, err = parseDump(out)
+	if werr := cmd.Wait(); err == nil && werr != nil && len(items) == 0 {
+		err = fmt.Errorf("security dump-keychain failed: %s", strings.TrimSpace(cmp.Or(stderr.String(), werr.Error())))
+	}
+	return items, denied, err
+}
@@ -0,0 +1,18 @@
+// Package keystore reads the passwords saved in the operating system's
+// credential store, so they can be checked without exporting them first.
+package keystore
+
+import "errors"
+
+// ErrUnsupported is returned by Items on systems without a supported store.
+var ErrUnsupported = errors.New("no supported credential store on this system")
+
+// Item is one saved password. Service is the site or application it
+// belongs to and Kind the store's class of item, such as "internet", or
+// the collection holding it.
+type Item struct {
+	Service string
+	Account string
+	Kind    string
+	Secret  []byte
+}
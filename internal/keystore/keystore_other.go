@@ -0,0 +1,11 @@
+//go:build !darwin && !windows && !linux
+
+package keystore
+
+// Name describes the store Items reads.
+const Name = "credential store"
+
+// Items needs a supported store, which this system lacks.
+func Items() ([]Item, int, error) {
+	return nil, 0, ErrUnsupported
+}
@@ -0,0 +1,136 @@
+package keystore
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Name describes the store Items reads.
+const Name = "Secret Service keyring"
+
+const (
+	secretsName    = "org.freedesktop.secrets"
+	secretsPath    = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretsService = "org.freedesktop.Secret.Service"
+	secretsPrompt  = "org.freedesktop.Secret.Prompt"
+)
+
+// secret mirrors the Secret struct of the Secret Service API.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// Items returns the passwords in the collections of the Secret Service
+// (GNOME Keyring, KWallet or KeePassXC), named by the service and user
+// attributes libsecret applications set, or by their label. Locked
+// collections are unlocked first, which asks for the keyring password;
+// items still locked after that, because the prompt was dismissed, are
+// counted in denied.
+func Items() (items []Item, denied int, err error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, 0, fmt.Errorf("no D-Bus session bus: %w", err)
+	}
+	defer conn.Close()
+	svc := conn.Object(secretsName, secretsPath)
+
+	var out dbus.Variant
+	var session dbus.ObjectPath
+	if err := svc.Call(secretsService+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&out, &session); err != nil {
+		return nil, 0, fmt.Errorf("failed to open a Secret Service session: %w", err)
+	}
+	defer conn.Object(secretsName, session).Call("org.freedesktop.Secret.Session.Close", 0)
+
+	var collections []dbus.ObjectPath
+	if err := property(svc, secretsService+".Collections", &collections); err != nil {
+		return nil, 0, err
+	}
+	if err := unlock(conn, svc, collections); err != nil {
+		return nil, 0, err
+	}
+
+	for _, path := range collections {
+		col := conn.Object(secretsName, path)
+		var label string
+		var paths []dbus.ObjectPath
+		var locked bool
+		if err := property(col, "org.freedesktop.Secret.Collection.Items", &paths); err != nil {
+			return nil, 0, err
+		}
+		property(col, "org.freedesktop.Secret.Collection.Label", &label)
+		property(col, "org.freedesktop.Secret.Collection.Locked", &locked)
+		if locked {
+			denied += len(paths)
+			continue
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		secrets := map[dbus.ObjectPath]secret{}
+		if err := svc.Call(secretsService+".GetSecrets", 0, paths, session).Store(&secrets); err != nil {
+			return nil, 0, fmt.Errorf("failed to read the secrets of %s: %w", cmp.Or(label, string(path)), err)
+		}
+		for _, p := range paths {
+			s, ok := secrets[p]
+			if !ok {
+				denied++
+				continue
+			}
+			item := conn.Object(secretsName, p)
+			var itemLabel string
+			var attrs map[string]string
+			property(item, "org.freedesktop.Secret.Item.Label", &itemLabel)
+			property(item, "org.freedesktop.Secret.Item.Attributes", &attrs)
+			items = append(items, Item{
+				Service: cmp.Or(attrs["service"], attrs["server"], attrs["origin_url"], attrs["url"], itemLabel),
+				Account: cmp.Or(attrs["username"], attrs["user"], attrs["username_value"], attrs["account"]),
+				Kind:    cmp.Or(label, string(path)),
+				Secret:  s.Value,
+			})
+		}
+	}
+	return items, denied, nil
+}
+
+// unlock asks the service to unlock the locked collections, and waits for
+// the user to answer the prompt if it shows one.
+func unlock(conn *dbus.Conn, svc dbus.BusObject, collections []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := svc.Call(secretsService+".Unlock", 0, collections).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("failed to unlock the keyring: %w", err)
+	}
+	if prompt == "/" {
+		return nil
+	}
+	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(prompt), dbus.WithMatchInterface(secretsPrompt), dbus.WithMatchMember("Completed")); err != nil {
+		return err
+	}
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+	if err := conn.Object(secretsName, prompt).Call(secretsPrompt+".Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("failed to prompt for the keyring password: %w", err)
+	}
+	for sig := range signals {
+		if sig.Path == prompt && sig.Name == secretsPrompt+".Completed" {
+			return nil
+		}
+	}
+	return errors.New("lost the D-Bus connection while waiting for the unlock prompt")
+}
+
+// property stores the D-Bus property name of obj in v.
+func property(obj dbus.BusObject, name string, v any) error {
+	p, err := obj.GetProperty(name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return p.Store(v)
+}
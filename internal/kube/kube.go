@@ -0,0 +1,339 @@
+// Package kube is a minimal Kubernetes API client for reading Secrets: it
+// understands kubeconfig files, including exec credential plugins, and
+// lists Secrets over plain REST, without pulling in client-go.
+package kube
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string   `yaml:"name"`
+		User authInfo `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+type authInfo struct {
+	Token                 string `yaml:"token"`
+	TokenFile             string `yaml:"tokenFile"`
+	ClientCertificate     string `yaml:"client-certificate"`
+	ClientCertificateData string `yaml:"client-certificate-data"`
+	ClientKey             string `yaml:"client-key"`
+	ClientKeyData         string `yaml:"client-key-data"`
+	Username              string `yaml:"username"`
+	Password              string `yaml:"password"`
+	Exec                  *struct {
+		Command string   `yaml:"command"`
+		Args    []string `yaml:"args"`
+		Env     []struct {
+			Name  string `yaml:"name"`
+			Value string `yaml:"value"`
+		} `yaml:"env"`
+	} `yaml:"exec"`
+	AuthProvider any `yaml:"auth-provider"`
+}
+
+// Client talks to one cluster as one user, as chosen by a kubeconfig context.
+type Client struct {
+	server string
+	http   *http.Client
+	// Namespace is the context's default namespace, "default" when unset.
+	Namespace string
+	// Context is the name of the kubeconfig context in use.
+	Context  string
+	token    string
+	username string
+	password string
+}
+
+// DefaultConfigPath is the first file in $KUBECONFIG, or ~/.kube/config.
+func DefaultConfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// NewClient loads the kubeconfig at path and connects through context, or
+// the current context when it is empty. Exec credential plugins are run
+// once to obtain a token or client certificate.
+func NewClient(path, context string, timeout time.Duration) (*Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	if context == "" {
+		context = kc.CurrentContext
+	}
+	c := &Client{Context: context}
+	var clusterName, userName string
+	found := false
+	for _, ctx := range kc.Contexts {
+		if ctx.Name == context {
+			clusterName, userName, c.Namespace = ctx.Context.Cluster, ctx.Context.User, ctx.Context.Namespace
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found in %s", context, path)
+	}
+	if c.Namespace == "" {
+		c.Namespace = "default"
+	}
+
+	tlsConfig := &tls.Config{}
+	found = false
+	for _, cl := range kc.Clusters {
+		if cl.Name != clusterName {
+			continue
+		}
+		found = true
+		c.server = strings.TrimSuffix(cl.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = cl.Cluster.InsecureSkipTLSVerify
+		ca, err := dataOrFile(cl.Cluster.CertificateAuthorityData, resolve(cl.Cluster.CertificateAuthority))
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: certificate authority: %w", clusterName, err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("cluster %s: no certificates in the certificate authority", clusterName)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if !found || c.server == "" {
+		return nil, fmt.Errorf("cluster %q of context %q has no server", clusterName, context)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if err := c.authenticate(u.User, resolve, tlsConfig); err != nil {
+			return nil, fmt.Errorf("user %s: %w", userName, err)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.http = &http.Client{Timeout: timeout, Transport: transport}
+	return c, nil
+}
+
+func (c *Client) authenticate(u authInfo, resolve func(string) string, tlsConfig *tls.Config) error {
+	if u.AuthProvider != nil {
+		return errors.New("auth-provider plugins are not supported; use a token, a client certificate or an exec plugin")
+	}
+	certPEM, keyPEM := []byte(nil), []byte(nil)
+	if u.Exec != nil {
+		cred, err := runExec(u)
+		if err != nil {
+			return err
+		}
+		c.token = cred.Token
+		if cred.ClientCertificateData != "" {
+			certPEM, keyPEM = []byte(cred.ClientCertificateData), []byte(cred.ClientKeyData)
+		}
+	}
+	if u.Token != "" {
+		c.token = u.Token
+	} else if u.TokenFile != "" {
+		token, err := os.ReadFile(resolve(u.TokenFile))
+		if err != nil {
+			return err
+		}
+		c.token = strings.TrimSpace(string(token))
+	}
+	c.username, c.password = u.Username, u.Password
+
+	if certPEM == nil {
+		var err error
+		if certPEM, err = dataOrFile(u.ClientCertificateData, resolve(u.ClientCertificate)); err != nil {
+			return fmt.Errorf("client certificate: %w", err)
+		}
+		if keyPEM, err = dataOrFile(u.ClientKeyData, resolve(u.ClientKey)); err != nil {
+			return fmt.Errorf("client key: %w", err)
+		}
+	}
+	if certPEM != nil {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return nil
+}
+
+type execCredential struct {
+	Token                 string `json:"token"`
+	ClientCertificateData string `json:"clientCertificateData"`
+	ClientKeyData         string `json:"clientKeyData"`
+}
+
+// runExec runs an exec credential plugin, such as aws eks get-token or
+// gke-gcloud-auth-plugin, and returns the status of its ExecCredential.
+func runExec(u authInfo) (execCredential, error) {
+	cmd := exec.Command(u.Exec.Command, u.Exec.Args...)
+	cmd.Env = os.Environ()
+	for _, e := range u.Exec.Env {
+		cmd.Env = append(cmd.Env, e.Name+"="+e.Value)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return execCredential{}, fmt.Errorf("exec plugin %s failed: %w", u.Exec.Command, err)
+	}
+	var resp struct {
+		Status execCredential `json:"status"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return execCredential{}, fmt.Errorf("exec plugin %s: invalid ExecCredential: %w", u.Exec.Command, err)
+	}
+	return resp.Status, nil
+}
+
+// dataOrFile decodes inline base64 kubeconfig data, or reads the file; both
+// empty returns nil.
+func dataOrFile(data, path string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	return nil, nil
+}
+
+// Secret is one Secret with its decoded data.
+type Secret struct {
+	Namespace string
+	Name      string
+	Type      string
+	Data      map[string][]byte
+}
+
+// Secrets lists the Secrets of namespace, or of every namespace when it is
+// empty, page by page.
+func (c *Client) Secrets(namespace string) ([]Secret, error) {
+	path := "/api/v1/secrets"
+	if namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/secrets"
+	}
+	var secrets []Secret
+	cont := ""
+	for {
+		q := url.Values{"limit": {"500"}}
+		if cont != "" {
+			q.Set("continue", cont)
+		}
+		var list struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []struct {
+				Metadata struct {
+					Name      string `json:"name"`
+					Namespace string `json:"namespace"`
+				} `json:"metadata"`
+				Type string `json:"type"`
+				// encoding/json decodes the base64 values into bytes
+				Data map[string][]byte `json:"data"`
+			} `json:"items"`
+		}
+		if err := c.get(path+"?"+q.Encode(), &list); err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			secrets = append(secrets, Secret{Namespace: item.Metadata.Namespace, Name: item.Metadata.Name, Type: item.Type, Data: item.Data})
+		}
+		if cont = list.Metadata.Continue; cont == "" {
+			return secrets, nil
+		}
+	}
+}
+
+func (c *Client) get(path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, c.server+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("Kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Kubernetes API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &status) == nil && status.Message != "" {
+			return fmt.Errorf("Kubernetes API: %s: %s", resp.Status, status.Message)
+		}
+		return fmt.Errorf("Kubernetes API: %s", resp.Status)
+	}
+	return json.NewDecoder(bytes.NewReader(body)).Decode(v)
+}
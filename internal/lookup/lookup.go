@@ -0,0 +1,282 @@
+// Package lookup is the Checker behind the pwnedcheck library and command:
+// HIBP lookups through one client, cache and rate limit, answered from an
+// offline filter or index when one is given, and safe to close while other
+// goroutines still use it.
+package lookup
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/index"
+)
+
+// ErrClosed is returned by Checker methods called after Close.
+var ErrClosed = errors.New("checker is closed")
+
+type Options struct {
+	// Logger receives diagnostics; nil discards them.
+	Logger   *slog.Logger
+	CacheTTL time.Duration
+	// CacheEntries bounds the number of cached ranges; 0 means 1024.
+	CacheEntries int
+	// RPS caps HIBP requests per second across all goroutines; 0 means no
+	// limit.
+	RPS float64
+	// Timeout bounds each HIBP request; 0 means 10 seconds.
+	Timeout time.Duration
+	// IdleConns sizes the keep-alive pool; 0 means 8.
+	IdleConns int
+	// Pins are SHA-256 SPKI digests; when set, the HIBP server must present
+	// a matching key.
+	Pins [][]byte
+	// TLS replaces the default TLS settings for HIBP requests.
+	TLS *tls.Config
+	// NTLM checks NTLM hashes against the NTLM corpus instead of SHA-1.
+	NTLM bool
+	// Offline answers SHA-1 checks from a Bloom filter instead of HIBP; hits
+	// report a Count of 0.
+	Offline *bloom.Filter
+	// Index answers checks from a packed index, with counts, instead of
+	// HIBP. The Checker owns it from then on and closes it in Close.
+	Index *index.Index
+	// BaseURL points lookups at another range API; empty means
+	// api.pwnedpasswords.com.
+	BaseURL string
+	// Transport replaces the HTTP transport, making IdleConns, Pins and TLS
+	// no-ops.
+	Transport http.RoundTripper
+}
+
+type Result struct {
+	Pwned bool
+	Count int
+	// Hash is the uppercase hash that was looked up: SHA-1, or NTLM with
+	// Options.NTLM.
+	Hash string
+}
+
+// Checker is safe for concurrent use. Close waits for in-flight calls to
+// finish, then releases idle connections and closes Options.Index; every
+// call after it that would use them returns ErrClosed.
+type Checker struct {
+	mu     sync.RWMutex
+	closed bool
+	client *hibp.Client
+	index  *index.Index
+	logger *slog.Logger
+	ntlm   bool
+}
+
+func New(opts Options) *Checker {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &Checker{logger: logger, ntlm: opts.NTLM, index: opts.Index, client: hibp.NewClient(hibp.Options{
+		Logger:       logger,
+		CacheTTL:     opts.CacheTTL,
+		CacheEntries: opts.CacheEntries,
+		RPS:          opts.RPS,
+		Timeout:      opts.Timeout,
+		IdleConns:    opts.IdleConns,
+		Pins:         opts.Pins,
+		TLS:          opts.TLS,
+		Offline:      opts.Offline,
+		Index:        opts.Index,
+		BaseURL:      opts.BaseURL,
+		Transport:    opts.Transport,
+	})}
+}
+
+// Check looks up a plaintext password, or a SHA-1 hash (NTLM with
+// Options.NTLM) when hashed is set. Malformed hashes fail with an
+// *InvalidHashError before anything is sent.
+func (c *Checker) Check(password string, hashed bool) (Result, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return Result{}, ErrClosed
+	}
+	hash, err := c.Hash(password, hashed)
+	if err != nil {
+		return Result{}, err
+	}
+
+	check := c.client.CheckPassword
+	if c.ntlm {
+		check = c.client.CheckNTLM
+	}
+	found, count, err := check(hash, true)
+	if err != nil {
+		return Result{Hash: hash}, err
+	}
+	return Result{Pwned: found, Count: count, Hash: hash}, nil
+}
+
+// Known answers an uppercase hash from Hash the way Check would, but only
+// from the index, the filters and the cache; ok is false when HIBP has to
+// be asked, with the range of the hash prefix that hibp.Match reads.
+func (c *Checker) Known(hash string) (res Result, ok bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return Result{Hash: hash}, true, ErrClosed
+	}
+	found, count, ok, err := c.client.Known(hash, c.ntlm)
+	return Result{Pwned: found, Count: count, Hash: hash}, ok, err
+}
+
+// Hash returns the uppercase hash Check looks up, without looking it up.
+func (c *Checker) Hash(password string, hashed bool) (string, error) {
+	switch {
+	case hashed:
+		if err := ValidateHash(password, c.ntlm); err != nil {
+			return "", err
+		}
+		return strings.ToUpper(password), nil
+	case c.ntlm:
+		return hibp.HashNTLM(password), nil
+	}
+	return hibp.HashPassword(password), nil
+}
+
+// Range returns every suffix under a 5-character hash prefix of either
+// corpus, with its count, from the cache while it is fresh.
+func (c *Checker) Range(prefix string, ntlm bool) (map[string]int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	return c.client.Range(prefix, ntlm)
+}
+
+// Fetch downloads one range past the cache, as hibp.Client.Fetch does.
+func (c *Checker) Fetch(prefix string, ntlm bool, etag string) (map[string]int, string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil, "", ErrClosed
+	}
+	return c.client.Fetch(prefix, ntlm, etag)
+}
+
+// Probe sends one uncached range request, as hibp.Client.Probe does.
+func (c *Checker) Probe(prefix string, ntlm bool) (hibp.Probe, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return hibp.Probe{}, ErrClosed
+	}
+	return c.client.Probe(prefix, ntlm)
+}
+
+// Retried counts a request the caller is about to send again.
+func (c *Checker) Retried() {
+	c.client.Retried()
+}
+
+// Closed reports whether Close has been called.
+func (c *Checker) Closed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// InvalidHashError reports hashed input that is not a well-formed hash of
+// the expected kind. Looks names the kind it resembles instead, if any.
+type InvalidHashError struct {
+	Want  string
+	Looks string
+}
+
+func (e *InvalidHashError) Error() string {
+	msg := "not a valid " + e.Want + " hash"
+	switch e.Looks {
+	case "NTLM":
+		msg += "; it looks like NTLM, use -ntlm"
+	case "SHA-1":
+		msg += "; it looks like SHA-1, drop -ntlm"
+	}
+	return msg
+}
+
+// ValidateHash accepts 40 hex digits for SHA-1 or 32 for NTLM, and returns
+// an *InvalidHashError otherwise.
+func ValidateHash(s string, ntlm bool) error {
+	want, size := "SHA-1", 40
+	if ntlm {
+		want, size = "NTLM", 32
+	}
+	if !isHex(s) || len(s) != size {
+		err := &InvalidHashError{Want: want}
+		if isHex(s) {
+			switch len(s) {
+			case 32:
+				err.Looks = "NTLM"
+			case 40:
+				err.Looks = "SHA-1"
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// Close is idempotent; only the first call releases resources.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.client.Close()
+	if c.index != nil {
+		return c.index.Close()
+	}
+	return nil
+}
+
+func (c *Checker) CacheStats() hibp.CacheStats {
+	return c.client.CacheStats()
+}
+
+// CacheUsage returns how many ranges are cached and how many fit; both are
+// 0 without a cache.
+func (c *Checker) CacheUsage() (ranges, capacity int) {
+	return c.client.CacheUsage()
+}
+
+func (c *Checker) ConnStats() hibp.ConnStats {
+	return c.client.ConnStats()
+}
+
+func (c *Checker) APIStats() hibp.APIStats {
+	return c.client.APIStats()
+}
+
+// Logger returns Options.Logger, or a logger discarding everything.
+func (c *Checker) Logger() *slog.Logger {
+	return c.logger
+}
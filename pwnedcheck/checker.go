@@ -0,0 +1,264 @@
+// Package pwnedcheck checks passwords against the Have I Been Pwned range
+// API from Go programs, with the cache, rate limit and offline corpora of
+// the pwnedcheck command:
+//
+//	c := pwnedcheck.New(pwnedcheck.Options{CacheTTL: time.Hour})
+//	defer c.Close()
+//	res, err := c.Check(password, false)
+//
+// Only the first 5 characters of each hash are sent.
+package pwnedcheck
+
+import (
+	"cmp"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/bloom"
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/index"
+	"github.com/mohamedation/PwnedCheck/internal/lookup"
+)
+
+// ErrClosed is returned by Checker methods called after Close.
+var ErrClosed = lookup.ErrClosed
+
+// Causes of a failed Check, to match with errors.Is.
+var (
+	// ErrRateLimited is a 429 answer; lower Options.RPS.
+	ErrRateLimited = hibp.ErrRateLimited
+	// ErrTimeout is a request that ran past Options.Timeout.
+	ErrTimeout = hibp.ErrTimeout
+	// ErrBadResponse is any other status than 200 or 304, or a body that
+	// could not be read.
+	ErrBadResponse = hibp.ErrBadResponse
+	// ErrMalformed is a 200 answer whose body is not a range listing, such
+	// as a proxy's login page. It also matches ErrBadResponse.
+	ErrMalformed = hibp.ErrMalformed
+	// ErrOffline is a request that never got an answer.
+	ErrOffline = hibp.ErrOffline
+)
+
+type Options struct {
+	// Logger receives diagnostics; nil discards them.
+	Logger   *slog.Logger
+	CacheTTL time.Duration
+	// CacheEntries bounds the number of cached ranges; 0 means 1024.
+	CacheEntries int
+	// RPS caps HIBP requests per second across all goroutines; 0 means no
+	// limit.
+	RPS float64
+	// Timeout bounds each HIBP request; 0 means 10 seconds.
+	Timeout time.Duration
+	// IdleConns sizes the keep-alive pool; set it to the number of goroutines
+	// calling Check concurrently. 0 means 8.
+	IdleConns int
+	// Pins are SHA-256 SPKI digests; when set, the HIBP server must present
+	// a matching key.
+	Pins [][]byte
+	// TLS replaces the default TLS settings for HIBP requests.
+	TLS *tls.Config
+	// NTLM checks NTLM hashes against the NTLM corpus instead of SHA-1.
+	NTLM bool
+	// Offline answers SHA-1 checks from a filter loaded with LoadFilter
+	// instead of HIBP; hits report a Count of 0.
+	Offline *Filter
+	// Index answers checks from a packed index opened with OpenIndex, with
+	// counts, instead of HIBP. Its hash kind must match NTLM. The Checker
+	// owns it from then on and closes it in Close.
+	Index *Index
+	// BaseURL points lookups at another range API, such as a pwnedcheck
+	// proxy; empty means api.pwnedpasswords.com.
+	BaseURL string
+	// Transport replaces the HTTP transport, making IdleConns, Pins and TLS
+	// no-ops, e.g. to stub the API in tests.
+	Transport http.RoundTripper
+}
+
+type Result struct {
+	Pwned bool
+	Count int
+	// Hash is the uppercase hash that was looked up: SHA-1, or NTLM with
+	// Options.NTLM.
+	Hash string
+}
+
+// Checker is the embeddable entry point for programs that check passwords
+// from long-lived processes. It is safe for concurrent use by multiple
+// goroutines. Close must be called once the Checker is no longer needed: it
+// waits for in-flight checks to finish, then releases idle connections and
+// closes Options.Index. Every call after Close returns ErrClosed.
+type Checker struct {
+	c    *lookup.Checker
+	ntlm bool
+	// workers is how many goroutines CheckAll runs, one per pooled
+	// connection
+	workers int
+}
+
+func New(opts Options) *Checker {
+	lo := lookup.Options{
+		Logger:       opts.Logger,
+		CacheTTL:     opts.CacheTTL,
+		CacheEntries: opts.CacheEntries,
+		RPS:          opts.RPS,
+		Timeout:      opts.Timeout,
+		IdleConns:    opts.IdleConns,
+		Pins:         opts.Pins,
+		TLS:          opts.TLS,
+		NTLM:         opts.NTLM,
+		BaseURL:      opts.BaseURL,
+		Transport:    opts.Transport,
+	}
+	if opts.Offline != nil {
+		lo.Offline = opts.Offline.f
+	}
+	if opts.Index != nil {
+		lo.Index = opts.Index.idx
+	}
+	// 8 matches the default pool of the HIBP client
+	return &Checker{c: lookup.New(lo), ntlm: opts.NTLM, workers: cmp.Or(opts.IdleConns, 8)}
+}
+
+// Check looks up a plaintext password, or a SHA-1 hash (NTLM with
+// Options.NTLM) when hashed is set. Malformed hashes fail with an
+// *InvalidHashError before anything is sent.
+func (c *Checker) Check(password string, hashed bool) (Result, error) {
+	res, err := c.c.Check(password, hashed)
+	return Result(res), invalidHash(err)
+}
+
+// Hash returns the uppercase hash Check looks up, without looking it up.
+func (c *Checker) Hash(password string, hashed bool) (string, error) {
+	hash, err := c.c.Hash(password, hashed)
+	return hash, invalidHash(err)
+}
+
+// Range returns every suffix under a 5-character hash prefix, with its
+// count, in the corpus Check uses.
+func (c *Checker) Range(prefix string) (map[string]int, error) {
+	return c.c.Range(prefix, c.ntlm)
+}
+
+// InvalidHashError reports hashed input that is not a well-formed hash of
+// the expected kind. Looks names the kind it resembles instead, if any.
+type InvalidHashError struct {
+	Want  string
+	Looks string
+}
+
+func (e *InvalidHashError) Error() string {
+	return (*lookup.InvalidHashError)(e).Error()
+}
+
+// invalidHash turns the checker's *InvalidHashError into this package's.
+func invalidHash(err error) error {
+	var invalid *lookup.InvalidHashError
+	if errors.As(err, &invalid) {
+		return (*InvalidHashError)(invalid)
+	}
+	return err
+}
+
+// ValidateHash accepts 40 hex digits for SHA-1 or 32 for NTLM, and returns
+// an *InvalidHashError otherwise.
+func ValidateHash(s string, ntlm bool) error {
+	return invalidHash(lookup.ValidateHash(s, ntlm))
+}
+
+// Close is idempotent; only the first call releases resources.
+func (c *Checker) Close() error {
+	return c.c.Close()
+}
+
+// Filter is a Bloom filter of the SHA-1 corpus, for Options.Offline.
+type Filter struct {
+	f *bloom.Filter
+}
+
+// LoadFilter reads a Bloom filter written by pwnedcheck build-bloom.
+func LoadFilter(path string) (*Filter, error) {
+	f, err := bloom.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{f: f}, nil
+}
+
+// Index is a packed index of either corpus, for Options.Index.
+type Index struct {
+	idx *index.Index
+}
+
+// OpenIndex opens an index written by pwnedcheck index.
+func OpenIndex(path string) (*Index, error) {
+	idx, err := index.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{idx: idx}, nil
+}
+
+// NTLM reports whether the index holds NTLM hashes rather than SHA-1.
+func (i *Index) NTLM() bool {
+	return i.idx.NTLM()
+}
+
+// Close releases an index that was not handed to New.
+func (i *Index) Close() error {
+	return i.idx.Close()
+}
+
+// CacheStats counts cached range lookups; it is all zeros without
+// Options.CacheTTL.
+type CacheStats struct {
+	PositiveHits int
+	NegativeHits int
+	Misses       int
+	// Revalidated counts expired ranges HIBP confirmed unchanged with a 304.
+	Revalidated int
+	// RangeHits counts whole ranges served from the cache by Range.
+	RangeHits int
+}
+
+// ConnStats counts the connections HIBP requests went over.
+type ConnStats struct {
+	New    int
+	Reused int
+	// HTTP2 counts responses served over HTTP/2.
+	HTTP2 int
+}
+
+// APIStats counts the range requests sent so far.
+type APIStats struct {
+	Requests int
+	// Errors counts requests without a usable response: network failures
+	// and any status but 200 and 304.
+	Errors int
+	// RateLimited counts 429 responses; they are also Errors.
+	RateLimited int
+	// Retries counts requests sent again for a range after a failure or an
+	// eviction, and is part of Requests.
+	Retries       int
+	P50, P95, P99 time.Duration
+}
+
+func (c *Checker) CacheStats() CacheStats {
+	return CacheStats(c.c.CacheStats())
+}
+
+func (c *Checker) ConnStats() ConnStats {
+	return ConnStats(c.c.ConnStats())
+}
+
+func (c *Checker) APIStats() APIStats {
+	return APIStats(c.c.APIStats())
+}
+
+// Logger returns Options.Logger, or a logger discarding everything.
+func (c *Checker) Logger() *slog.Logger {
+	return c.c.Logger()
+}
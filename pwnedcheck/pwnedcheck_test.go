@@ -0,0 +1,199 @@
+package pwnedcheck_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/PwnedCheck/internal/hibp"
+	"github.com/mohamedation/PwnedCheck/internal/hibp/hibptest"
+	"github.com/mohamedation/PwnedCheck/pwnedcheck"
+)
+
+func newChecker(t *testing.T, srv *hibptest.Server) *pwnedcheck.Checker {
+	t.Helper()
+	c := pwnedcheck.New(pwnedcheck.Options{BaseURL: srv.URL, Transport: srv.Client().Transport, CacheTTL: time.Hour, IdleConns: 4})
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 10)
+	hash := hibp.HashPassword("password")
+	// a padding entry HIBP adds to hide the range size is not a hit
+	srv.AddHash(hibp.HashPassword("padding"), 0)
+	c := newChecker(t, srv)
+
+	for _, tc := range []struct {
+		password string
+		hashed   bool
+		pwned    bool
+		count    int
+	}{
+		{"password", false, true, 10},
+		{strings.ToLower(hash), true, true, 10},
+		{"padding", false, false, 0},
+		{"padding", false, false, 0},
+		{"correct horse battery staple", false, false, 0},
+	} {
+		res, err := c.Check(tc.password, tc.hashed)
+		if err != nil || res.Pwned != tc.pwned || res.Count != tc.count {
+			t.Errorf("Check(%q, %v) = %+v, %v; want pwned %v, count %d", tc.password, tc.hashed, res, err, tc.pwned, tc.count)
+		}
+	}
+
+	_, err := c.Check(hibp.HashNTLM("password"), true)
+	var invalid *pwnedcheck.InvalidHashError
+	if !errors.As(err, &invalid) || invalid.Want != "SHA-1" || invalid.Looks != "NTLM" {
+		t.Errorf("Check(NTLM hash) = %v, want an InvalidHashError suggesting NTLM", err)
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	var passwords []string
+	for i := range 40 {
+		passwords = append(passwords, fmt.Sprintf("pw%d", i))
+		if i%3 == 0 {
+			srv.Add(passwords[i], i+1)
+		}
+	}
+	c := newChecker(t, srv)
+
+	results, err := c.CheckAll(context.Background(), slices.Values(passwords), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for r := range results {
+		if seen[r.Index] {
+			t.Fatalf("index %d answered twice", r.Index)
+		}
+		seen[r.Index] = true
+		pwned := r.Index%3 == 0
+		if r.Err != nil || r.Pwned != pwned || pwned && r.Count != r.Index+1 || r.Hash != hibp.HashPassword(passwords[r.Index]) {
+			t.Errorf("answer %d = %+v; want pwned %v", r.Index, r, pwned)
+		}
+	}
+	if got := slices.Sorted(maps.Keys(seen)); len(got) != len(passwords) || got[0] != 0 || got[len(got)-1] != len(passwords)-1 {
+		t.Errorf("answered %d of %d passwords", len(got), len(passwords))
+	}
+
+	c.Close()
+	if _, err := c.CheckAll(context.Background(), slices.Values(passwords), false); !errors.Is(err, pwnedcheck.ErrClosed) {
+		t.Errorf("CheckAll after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestCheckAllCancel(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	c := newChecker(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	endless := func(yield func(string) bool) {
+		for i := 0; ; i++ {
+			if !yield(fmt.Sprint(i)) {
+				return
+			}
+		}
+	}
+	results, err := c.CheckAll(ctx, endless, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-results
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel is still open 5s after cancelling")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	srv.Add("password", 10)
+	srv.Add("123456", 20)
+
+	type answer struct {
+		line  int
+		count int
+		err   error
+	}
+	for _, tc := range []struct {
+		name   string
+		source pwnedcheck.Source
+		stop   int
+		want   []answer
+	}{
+		{"lines", pwnedcheck.Source{Reader: strings.NewReader("password\n\n  123456  \r\nclean one\n")},
+			0, []answer{{1, 10, nil}, {3, 20, nil}, {4, 0, nil}}},
+		{"too long", pwnedcheck.Source{Reader: strings.NewReader("password\na much longer line\n123456"), MaxLineLength: 8},
+			0, []answer{{1, 10, nil}, {2, 0, pwnedcheck.ErrLineTooLong}, {3, 20, nil}}},
+		{"hashed", pwnedcheck.Source{Reader: strings.NewReader(hibp.HashPassword("password") + "\nnot a hash\n"), Hashed: true},
+			0, []answer{{1, 10, nil}, {2, 0, &pwnedcheck.InvalidHashError{}}}},
+		{"stopped by fn", pwnedcheck.Source{Reader: strings.NewReader("password\n123456\nclean\n")},
+			2, []answer{{1, 10, nil}, {2, 20, nil}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newChecker(t, srv)
+			stop := errors.New("stop")
+			var got []answer
+			err := c.Walk(context.Background(), tc.source, func(r pwnedcheck.WalkResult) error {
+				got = append(got, answer{r.Line, r.Count, r.Err})
+				if len(got) == tc.stop {
+					return stop
+				}
+				return nil
+			})
+			if tc.stop > 0 && !errors.Is(err, stop) || tc.stop == 0 && err != nil {
+				t.Fatalf("Walk = %v, want fn's error to end it, if any", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("answers = %v, want %v", got, tc.want)
+			}
+			for i, a := range got {
+				w := tc.want[i]
+				var invalid *pwnedcheck.InvalidHashError
+				errOK := w.err == nil && a.err == nil || errors.Is(a.err, w.err) || errors.As(w.err, &invalid) && errors.As(a.err, &invalid)
+				if a.line != w.line || a.count != w.count || !errOK {
+					t.Errorf("answer %d = %+v, want %+v", i+1, a, w)
+				}
+			}
+		})
+	}
+}
+
+func TestWalkEnds(t *testing.T) {
+	srv := hibptest.NewServer()
+	defer srv.Close()
+	c := newChecker(t, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src := pwnedcheck.Source{Reader: strings.NewReader("password\n")}
+	if err := c.Walk(ctx, src, func(pwnedcheck.WalkResult) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Walk with a cancelled context = %v, want context.Canceled", err)
+	}
+
+	c.Close()
+	src = pwnedcheck.Source{Reader: strings.NewReader("password\n")}
+	if err := c.Walk(context.Background(), src, func(pwnedcheck.WalkResult) error { return nil }); !errors.Is(err, pwnedcheck.ErrClosed) {
+		t.Errorf("Walk after Close = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,67 @@
+package pwnedcheck
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// StreamResult is one answer from CheckAll. Index is the position of the
+// password in the sequence, since answers arrive in completion order.
+type StreamResult struct {
+	Result
+	Index int
+	Err   error
+}
+
+// CheckAll checks every password from passwords, hashed as for Check, on
+// as many goroutines as Options.IdleConns, and sends each answer as soon as
+// it is ready, so a server can render results progressively. The channel
+// is closed once every password is answered or ctx is done; cancelling ctx
+// stops reading passwords and drops answers not yet sent, so callers that
+// stop receiving early must cancel it. Passwords still in the sequence
+// when the Checker is closed fail with ErrClosed, which CheckAll itself
+// returns if the Checker is already closed.
+func (c *Checker) CheckAll(ctx context.Context, passwords iter.Seq[string], hashed bool) (<-chan StreamResult, error) {
+	if c.c.Closed() {
+		return nil, ErrClosed
+	}
+
+	type job struct {
+		index    int
+		password string
+	}
+	jobs := make(chan job)
+	out := make(chan StreamResult)
+	var wg sync.WaitGroup
+	for range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res, err := c.Check(j.password, hashed)
+				select {
+				case out <- StreamResult{Result: res, Index: j.index, Err: err}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		i := 0
+		for password := range passwords {
+			select {
+			case jobs <- job{i, password}:
+			case <-ctx.Done():
+				return
+			}
+			i++
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
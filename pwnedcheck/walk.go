@@ -0,0 +1,70 @@
+package pwnedcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mohamedation/PwnedCheck/internal/input"
+)
+
+// ErrLineTooLong is the Err of a WalkResult for a line longer than
+// Source.MaxLineLength.
+var ErrLineTooLong = errors.New("line too long")
+
+// Source is what Walk reads: one password per line, or one hash of the
+// kind Check expects with Hashed.
+type Source struct {
+	Reader io.Reader
+	Hashed bool
+	// MaxLineLength is the longest line checked, in bytes, like
+	// -max-line-length; 0 means no limit.
+	MaxLineLength int
+}
+
+// WalkResult is one answer from Walk. Line is the 1-based line of the
+// password in the source; Err is a failed lookup, or ErrLineTooLong.
+type WalkResult struct {
+	Result
+	Line int
+	Err  error
+}
+
+// Walk checks the non-empty lines of source one at a time, in order, and
+// calls fn with every answer, failed lookups included. Walk stops at the
+// first error fn returns and returns it, so fn can end the scan early,
+// store results or alert as it goes; otherwise it stops when ctx is done,
+// returning ctx.Err(), or when the source ends. Unlike CheckAll nothing
+// runs concurrently, so fn needs no locking.
+func (c *Checker) Walk(ctx context.Context, source Source, fn func(WalkResult) error) error {
+	lines := input.NewLines(source.Reader, source.MaxLineLength)
+	lineNo := 0
+	for lines.Next() {
+		lineNo++
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r := WalkResult{Line: lineNo}
+		if lines.TooLong() {
+			r.Err = ErrLineTooLong
+		} else {
+			password := strings.TrimSpace(string(lines.Bytes()))
+			if password == "" {
+				continue
+			}
+			r.Result, r.Err = c.Check(password, source.Hashed)
+			if errors.Is(r.Err, ErrClosed) {
+				return r.Err
+			}
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read the source: %w", err)
+	}
+	return nil
+}